@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"quotes/internal/dtos"
+	"quotes/internal/services"
+)
+
+// AuthorController serves administrative operations on the authors of all
+// quotes. They change many quotes at once, so the server mounts them only
+// when authentication is enabled, and every route requires one of apiKeys.
+type AuthorController struct {
+	service services.QuoteServiceInterface
+	apiKeys []string
+}
+
+func NewAuthorController(service services.QuoteServiceInterface, apiKeys []string) *AuthorController {
+	return &AuthorController{service: service, apiKeys: apiKeys}
+}
+
+func (c *AuthorController) RegisterRoutes(router *mux.Router) {
+	path := apiV1.prefix + "/authors/rename"
+	router.HandleFunc(path, traceHandler(path, requireApiKey(c.apiKeys, c.renameAuthors))).Methods("POST")
+}
+
+// renameAuthors renames an author, or merges several into one, across all
+// quotes at once.
+func (c *AuthorController) renameAuthors(w http.ResponseWriter, r *http.Request) {
+	var renameDto dtos.AuthorRenameDto
+	if !decodeBody(w, r, &renameDto) {
+		return
+	}
+
+	result, err := c.service.RenameAuthors(r.Context(), renameDto)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to rename authors")
+		return
+	}
+
+	writeJSONResponse(w, result, http.StatusOK)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"quotes/internal/drivers"
+	"quotes/internal/dtos"
+)
+
+func TestRenameAuthors(t *testing.T) {
+	mockService := &MockQuoteService{}
+	controller := NewAuthorController(mockService, nil)
+
+	renameDto := dtos.AuthorRenameDto{From: []string{"Samuel Clemens", "S. Clemens"}, To: "Mark Twain", DryRun: true}
+	mockService.On("RenameAuthors", mock.Anything, renameDto).Return(&dtos.AuthorRenameResultDto{Quotes: 4, DryRun: true}, nil)
+
+	req := httptest.NewRequest("POST", "/v1/authors/rename",
+		strings.NewReader(`{"from": ["Samuel Clemens", "S. Clemens"], "to": "Mark Twain", "dry_run": true}`))
+	rr := httptest.NewRecorder()
+
+	controller.renameAuthors(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"quotes": 4, "dry_run": true}`, rr.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestRenameAuthorsConflict(t *testing.T) {
+	mockService := &MockQuoteService{}
+	controller := NewAuthorController(mockService, nil)
+	mockService.On("RenameAuthors", mock.Anything, mock.Anything).Return(nil, &drivers.VersionConflictError{})
+
+	req := httptest.NewRequest("POST", "/v1/authors/rename", strings.NewReader(`{"from": ["Samuel Clemens"], "to": "Mark Twain"}`))
+	rr := httptest.NewRecorder()
+
+	controller.renameAuthors(rr, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+}
+
+func TestRenameAuthorsRequiresApiKey(t *testing.T) {
+	mockService := &MockQuoteService{}
+	router := mux.NewRouter()
+	NewAuthorController(mockService, []string{"secret"}).RegisterRoutes(router)
+
+	req := httptest.NewRequest("POST", "/v1/authors/rename", strings.NewReader(`{"from": ["Samuel Clemens"], "to": "Mark Twain"}`))
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	mockService.AssertNotCalled(t, "RenameAuthors", mock.Anything, mock.Anything)
+}
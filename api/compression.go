@@ -0,0 +1,281 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// encoders lists the supported content codings in order of preference.
+var encoders = []struct {
+	name string
+	pool *sync.Pool
+}{
+	{"zstd", &sync.Pool{New: func() any {
+		encoder, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return encoder
+	}}},
+	{"gzip", &sync.Pool{New: func() any {
+		return gzip.NewWriter(nil)
+	}}},
+}
+
+// resettableWriter is implemented by both *gzip.Writer and *zstd.Encoder.
+type resettableWriter interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// CompressionMiddleware compresses response bodies of at least minSize bytes
+// with the coding preferred by the Accept-Encoding header of the request.
+//
+// Compressed representations get their own entity tags, made by appending
+// "-zstd" or "-gzip" to the tag set by the handler. The suffix is removed
+// from If-None-Match and If-Match again, so handlers only see their own tags.
+func CompressionMiddleware(minSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ifNoneMatch := r.Header.Get("If-None-Match")
+			for _, header := range []string{"If-None-Match", "If-Match"} {
+				if value := r.Header.Get(header); value != "" {
+					r.Header.Set(header, stripEncodingSuffixes(value))
+				}
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding < 0 || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			writer := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize, ifNoneMatch: ifNoneMatch}
+			defer writer.close()
+
+			next.ServeHTTP(writer, r)
+		})
+	}
+}
+
+// negotiateEncoding returns the index in encoders of the acceptable coding
+// with the highest quality, or -1 if the body should be sent as is.
+func negotiateEncoding(acceptEncoding string) int {
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		quality := 1.0
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil && parsed >= 0 && parsed <= 1 {
+				quality = parsed
+			}
+		}
+		qualities[name] = quality
+	}
+
+	best, bestQuality := -1, 0.0
+	for i, encoder := range encoders {
+		quality, ok := qualities[encoder.name]
+		if !ok {
+			quality = qualities["*"]
+		}
+
+		if quality > bestQuality {
+			best, bestQuality = i, quality
+		}
+	}
+
+	return best
+}
+
+// isCompressible reports whether a body of the given content type is text
+// that is worth compressing.
+func isCompressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") ||
+		mediaType == "application/xml" || strings.HasSuffix(mediaType, "+xml") ||
+		mediaType == "application/yaml"
+}
+
+// stripEncodingSuffixes removes the suffixes added by CompressionMiddleware
+// from a list of entity tags.
+func stripEncodingSuffixes(header string) string {
+	for _, encoder := range encoders {
+		header = strings.ReplaceAll(header, "-"+encoder.name+`"`, `"`)
+	}
+
+	return header
+}
+
+// compressWriter buffers the beginning of a body until it knows whether the
+// body reaches the minimum size, then either compresses it or passes it on.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    int
+	minSize     int
+	ifNoneMatch string
+	statusCode  int
+	buffer      []byte
+	encoder     resettableWriter
+	// decided is set once the headers have been sent.
+	decided bool
+}
+
+func (w *compressWriter) WriteHeader(statusCode int) {
+	if w.decided || w.statusCode != 0 {
+		return
+	}
+
+	// Informational responses are sent right away and do not end the
+	// header, so they are passed through.
+	if statusCode >= 100 && statusCode < 200 {
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+
+	w.statusCode = statusCode
+	if statusCode == http.StatusNoContent || statusCode == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if !w.decided {
+		w.buffer = append(w.buffer, b...)
+		if len(w.buffer) < w.minSize {
+			return len(b), nil
+		}
+
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends what has been written so far, compressing it if the body
+// qualifies, so that streamed responses are not held back.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if w.statusCode == 0 {
+			w.WriteHeader(http.StatusOK)
+		}
+		w.decide(len(w.buffer) > 0)
+	}
+
+	if w.encoder != nil {
+		w.encoder.Flush()
+	}
+
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide sends the headers, with compression if requested and applicable,
+// followed by the buffered part of the body.
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+
+	header := w.ResponseWriter.Header()
+	if w.statusCode == http.StatusNotModified {
+		w.restoreNotModifiedETag()
+	}
+
+	if compress && header.Get("Content-Encoding") == "" && isCompressible(header.Get("Content-Type")) {
+		encoder := encoders[w.encoding]
+
+		header.Set("Content-Encoding", encoder.name)
+		header.Del("Content-Length")
+		if etag := header.Get("ETag"); strings.HasSuffix(etag, `"`) {
+			header.Set("ETag", strings.TrimSuffix(etag, `"`)+"-"+encoder.name+`"`)
+		}
+
+		w.encoder = encoder.pool.Get().(resettableWriter)
+		w.encoder.Reset(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.statusCode)
+
+	buffer := w.buffer
+	w.buffer = nil
+	if len(buffer) == 0 {
+		return nil
+	}
+
+	if w.encoder != nil {
+		_, err := w.encoder.Write(buffer)
+		return err
+	}
+
+	_, err := w.ResponseWriter.Write(buffer)
+	return err
+}
+
+// restoreNotModifiedETag answers a 304 with the tag of the compressed
+// representation the client validated, since a 304 carries no body to
+// decide the coding by.
+func (w *compressWriter) restoreNotModifiedETag() {
+	header := w.ResponseWriter.Header()
+	etag := header.Get("ETag")
+	if !strings.HasSuffix(etag, `"`) {
+		return
+	}
+
+	for _, encoder := range encoders {
+		encoded := strings.TrimSuffix(etag, `"`) + "-" + encoder.name + `"`
+		if strings.Contains(w.ifNoneMatch, encoded) {
+			header.Set("ETag", encoded)
+			return
+		}
+	}
+}
+
+// close sends a body that stayed below the minimum size uncompressed and
+// finishes a compressed one.
+func (w *compressWriter) close() {
+	if !w.decided {
+		if w.statusCode == 0 {
+			// Nothing was written, leave the response to net/http.
+			return
+		}
+		w.decide(false)
+	}
+
+	if w.encoder != nil {
+		w.encoder.Close()
+		w.encoder.Reset(io.Discard)
+		encoders[w.encoding].pool.Put(w.encoder)
+		w.encoder = nil
+	}
+}
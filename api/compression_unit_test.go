@@ -0,0 +1,171 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	for _, test := range []struct {
+		acceptEncoding string
+		expected       string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br, zstd", "zstd"},
+		{"zstd;q=0.5, gzip", "gzip"},
+		{"gzip;q=0, zstd;q=0", ""},
+		{"*", "zstd"},
+		{"*, zstd;q=0", "gzip"},
+		{"GZIP;q=0.8", "gzip"},
+	} {
+		t.Run(test.acceptEncoding, func(t *testing.T) {
+			encoding := negotiateEncoding(test.acceptEncoding)
+			if test.expected == "" {
+				assert.Equal(t, -1, encoding)
+				return
+			}
+			assert.Equal(t, test.expected, encoders[encoding].name)
+		})
+	}
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	body := strings.Repeat(`{"author":"author","text":"text"}`, 100)
+
+	var seenIfNoneMatch string
+	handler := CompressionMiddleware(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenIfNoneMatch = r.Header.Get("If-None-Match")
+		w.Header().Set("ETag", `"7"`)
+		if r.Header.Get("If-None-Match") == `"7"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		size := len(body)
+		if r.URL.Query().Has("small") {
+			size = 10
+		}
+		// Written in pieces to exercise buffering up to the minimum size.
+		for i := 0; i < size; i += 100 {
+			io.WriteString(w, body[i:min(i+100, size)])
+		}
+	}))
+
+	serve := func(path, acceptEncoding, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("gzip", func(t *testing.T) {
+		rr := serve("/", "gzip", "")
+
+		assert.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", rr.Header().Get("Vary"))
+		assert.Equal(t, `"7-gzip"`, rr.Header().Get("ETag"))
+
+		reader, err := gzip.NewReader(rr.Body)
+		require.NoError(t, err)
+		decoded, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, body, string(decoded))
+	})
+
+	t.Run("zstd", func(t *testing.T) {
+		rr := serve("/", "gzip, zstd", "")
+
+		assert.Equal(t, "zstd", rr.Header().Get("Content-Encoding"))
+		assert.Equal(t, `"7-zstd"`, rr.Header().Get("ETag"))
+		assert.Less(t, rr.Body.Len(), len(body))
+
+		decoder, err := zstd.NewReader(rr.Body)
+		require.NoError(t, err)
+		defer decoder.Close()
+		decoded, err := io.ReadAll(decoder)
+		require.NoError(t, err)
+		assert.Equal(t, body, string(decoded))
+	})
+
+	t.Run("below minimum size", func(t *testing.T) {
+		rr := serve("/?small", "gzip", "")
+
+		assert.Empty(t, rr.Header().Get("Content-Encoding"))
+		assert.Equal(t, `"7"`, rr.Header().Get("ETag"))
+		assert.Equal(t, body[:10], rr.Body.String())
+	})
+
+	t.Run("not accepted", func(t *testing.T) {
+		rr := serve("/", "identity", "")
+
+		assert.Empty(t, rr.Header().Get("Content-Encoding"))
+		assert.Equal(t, body, rr.Body.String())
+	})
+
+	t.Run("revalidating a compressed representation", func(t *testing.T) {
+		rr := serve("/", "gzip", `"7-gzip"`)
+
+		assert.Equal(t, `"7"`, seenIfNoneMatch)
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+		assert.Empty(t, rr.Header().Get("Content-Encoding"))
+		assert.Equal(t, `"7-gzip"`, rr.Header().Get("ETag"))
+		assert.Empty(t, rr.Body.String())
+	})
+}
+
+func TestCompressionMiddlewareSkipsIncompressibleContent(t *testing.T) {
+	handler := CompressionMiddleware(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("not really a png"))
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Empty(t, rr.Header().Get("Content-Encoding"))
+	assert.Equal(t, "not really a png", rr.Body.String())
+}
+
+func TestCompressionMiddlewareFlush(t *testing.T) {
+	flushed := make(chan struct{})
+	handler := CompressionMiddleware(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, "first event\n")
+		require.NoError(t, http.NewResponseController(w).Flush())
+		close(flushed)
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+	<-flushed
+
+	assert.True(t, rr.Flushed)
+	assert.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+
+	reader, err := gzip.NewReader(rr.Body)
+	require.NoError(t, err)
+	decoded, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "first event\n", string(decoded))
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"quotes/internal/dtos"
+)
+
+// quoteETag returns the strong entity tag of the representation of a quote
+// negotiated for r, which is its version followed by the format suffix.
+func quoteETag(r *http.Request, quote *dtos.QuoteDto) string {
+	return `"` + strconv.FormatInt(quote.Version, 10) + formatFromContext(r.Context()).etagSuffix + `"`
+}
+
+// listETag returns an entity tag that changes whenever a quote of the list
+// is added, removed, reordered or updated, and between representations.
+func listETag(r *http.Request, quotes []dtos.QuoteDto) string {
+	hash := sha256.New()
+	for _, quote := range quotes {
+		if quote.Id != nil {
+			hash.Write(quote.Id.Bytes[:])
+		}
+		binary.Write(hash, binary.BigEndian, quote.Version)
+	}
+
+	return `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + formatFromContext(r.Context()).etagSuffix + `"`
+}
+
+// setValidators sets the ETag and, when known, Last-Modified headers.
+func setValidators(w http.ResponseWriter, etag string, modifiedAt time.Time) {
+	w.Header().Set("ETag", etag)
+	if !modifiedAt.IsZero() {
+		w.Header().Set("Last-Modified", modifiedAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+// writeNotModified sets the validators of the representation and answers
+// 304 if the If-None-Match or, in its absence, If-Modified-Since header of
+// the request shows the client already has it. It reports whether it did.
+func writeNotModified(w http.ResponseWriter, r *http.Request, etag string, modifiedAt time.Time) bool {
+	setValidators(w, etag, modifiedAt)
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if !etagMatches(ifNoneMatch, etag) {
+			return false
+		}
+	} else if ifModifiedSince := r.Header.Get("If-Modified-Since"); ifModifiedSince != "" && !modifiedAt.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		if err != nil || modifiedAt.Truncate(time.Second).After(since) {
+			return false
+		}
+	} else {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches reports whether a list of entity tags such as the value of
+// If-None-Match matches etag using the weak comparison.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifMatchVersions parses the If-Match header into the quote versions it
+// accepts. anyVersion is true for "*". Tags of any representation name the
+// version they were taken from. Weak and foreign tags are skipped, since
+// If-Match uses the strong comparison.
+func ifMatchVersions(header string) (versions []int64, anyVersion bool) {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return nil, true
+		}
+
+		unquoted, found := strings.CutPrefix(candidate, `"`)
+		unquoted, closed := strings.CutSuffix(unquoted, `"`)
+		if !found || !closed {
+			continue
+		}
+
+		unquoted, _, _ = strings.Cut(unquoted, ".")
+		if version, err := strconv.ParseInt(unquoted, 10, 64); err == nil && version > 0 {
+			versions = append(versions, version)
+		}
+	}
+
+	return versions, false
+}
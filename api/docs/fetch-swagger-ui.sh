@@ -0,0 +1,15 @@
+#!/bin/sh
+# Vendors the files of a swagger-ui-dist release that the /docs page needs
+# into docs/swagger-ui, from where they are embedded into the binary.
+# Usage: fetch-swagger-ui.sh VERSION, run from the api directory.
+set -eu
+
+version="$1"
+target="docs/swagger-ui"
+work="$(mktemp -d)"
+trap 'rm -rf "$work"' EXIT
+
+curl -fsSL "https://registry.npmjs.org/swagger-ui-dist/-/swagger-ui-dist-$version.tgz" | tar -xz -C "$work"
+for file in swagger-ui.css swagger-ui-bundle.js LICENSE NOTICE; do
+	cp "$work/package/$file" "$target/$file"
+done
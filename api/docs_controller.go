@@ -0,0 +1,71 @@
+package api
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"html/template"
+	"io/fs"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+//go:generate sh docs/fetch-swagger-ui.sh 5.18.2
+
+// swaggerUiPath is where the files of Swagger UI are served.
+const swaggerUiPath = "/docs/swagger-ui"
+
+//go:embed docs/index.html
+var docsPage string
+
+//go:embed docs/swagger-ui
+var swaggerUiDist embed.FS
+
+var docsTemplate = template.Must(template.New("docs").Parse(docsPage))
+
+// DocsController serves the OpenAPI document and a Swagger UI page for it.
+// The page and the swagger-ui-dist files it loads are embedded, so that the
+// documentation works without access to the internet.
+type DocsController struct {
+	document []byte
+	page     []byte
+	assets   fs.FS
+}
+
+func NewDocsController() *DocsController {
+	document, err := json.Marshal(openApiDocument())
+	if err != nil {
+		panic("failed to encode OpenAPI document: " + err.Error())
+	}
+
+	var page bytes.Buffer
+	err = docsTemplate.Execute(&page, struct{ AssetsURL, SpecURL string }{swaggerUiPath, "/openapi.json"})
+	if err != nil {
+		panic("failed to render documentation page: " + err.Error())
+	}
+
+	assets, err := fs.Sub(swaggerUiDist, "docs/swagger-ui")
+	if err != nil {
+		panic("failed to open Swagger UI files: " + err.Error())
+	}
+
+	return &DocsController{document: document, page: page.Bytes(), assets: assets}
+}
+
+func (c *DocsController) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/openapi.json", c.getOpenApi).Methods("GET")
+	router.HandleFunc("/docs", c.getDocs).Methods("GET")
+	router.PathPrefix(swaggerUiPath + "/").
+		Handler(http.StripPrefix(swaggerUiPath, http.FileServerFS(c.assets))).Methods("GET")
+}
+
+func (c *DocsController) getOpenApi(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(c.document)
+}
+
+func (c *DocsController) getDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(c.page)
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/services"
+)
+
+// TestOpenApiMatchesRoutes fails when a route is registered without being
+// documented or documented without being registered.
+func TestOpenApiMatchesRoutes(t *testing.T) {
+	router := mux.NewRouter()
+	quoteController := NewQuoteController(&MockQuoteService{}, Deprecation{})
+	quoteController.SetEventBroker(services.NewQuoteEventBroker(&MockQuoteService{}, 1), time.Second)
+	quoteController.RegisterRoutes(router)
+	NewHealthController(nil).RegisterRoutes(router)
+	NewMetricsController(stubCacheStats{}).RegisterRoutes(router)
+	NewGraphqlController(&MockQuoteService{}, nil).RegisterRoutes(router)
+	NewRotationController(&MockQuoteService{}, RotationLimits{}, nil).RegisterRoutes(router)
+	NewWebhookController(&MockWebhookService{}, nil).RegisterRoutes(router)
+	NewAuthorController(&MockQuoteService{}, nil).RegisterRoutes(router)
+
+	var registered []string
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			registered = append(registered, method+" "+path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	var document struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	rr := httptest.NewRecorder()
+	NewDocsController().getOpenApi(rr, httptest.NewRequest("GET", "/openapi.json", nil))
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &document))
+
+	var documented []string
+	for path, item := range document.Paths {
+		for method := range item {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	assert.ElementsMatch(t, registered, documented)
+}
+
+func TestOpenApiDocument(t *testing.T) {
+	router := mux.NewRouter()
+	NewDocsController().RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/openapi.json", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var document map[string]any
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &document))
+	assert.Equal(t, "3.1.0", document["openapi"])
+
+	schemas := document["components"].(map[string]any)["schemas"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"id":     map[string]any{"type": "string", "format": "uuid", "readOnly": true},
+			"author": map[string]any{"type": "string"},
+			"text":   map[string]any{"type": "string"},
+			"tags":   map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+		"required": []any{"author", "text"},
+	}, schemas["Quote"])
+	assert.Contains(t, schemas, "Problem")
+
+	paths := document["paths"].(map[string]any)
+	create := paths["/v1/quotes"].(map[string]any)["post"].(map[string]any)
+	assert.Contains(t, create["responses"], "201")
+	assert.Contains(t, create["responses"], "400")
+	assert.NotContains(t, create, "deprecated")
+
+	legacyCreate := paths["/quotes"].(map[string]any)["post"].(map[string]any)
+	assert.Equal(t, true, legacyCreate["deprecated"])
+	assert.Equal(t, "createQuoteLegacy", legacyCreate["operationId"])
+}
+
+func TestDocsPage(t *testing.T) {
+	router := mux.NewRouter()
+	NewDocsController().RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/docs", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), `src="/docs/swagger-ui/swagger-ui-bundle.js"`)
+	assert.Contains(t, rr.Body.String(), `url: "/openapi.json"`)
+}
+
+func TestDocsServesEmbeddedSwaggerUi(t *testing.T) {
+	router := mux.NewRouter()
+	controller := NewDocsController()
+	controller.RegisterRoutes(router)
+
+	for _, test := range []struct {
+		name        string
+		contentType string
+	}{
+		{"swagger-ui-bundle.js", "javascript"},
+		{"swagger-ui.css", "text/css"},
+		{"LICENSE", "text/plain"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			embedded, err := fs.ReadFile(controller.assets, test.name)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", "/docs/swagger-ui/"+test.name, nil))
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Contains(t, rr.Header().Get("Content-Type"), test.contentType)
+			assert.Equal(t, embedded, rr.Body.Bytes())
+		})
+	}
+}
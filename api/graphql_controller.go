@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	graphql "github.com/graph-gophers/graphql-go"
+	"quotes/internal/services"
+)
+
+// GraphqlPath is where the GraphQL endpoint is served. It authorizes
+// mutations itself, so AuthMiddleware must let it through, and tells them
+// from queries, which ReadYourWritesMiddleware must leave to it too.
+const GraphqlPath = "/graphql"
+
+const graphqlMaxDepth = 10
+
+//go:embed graphql/schema.graphql
+var graphqlSchema string
+
+type GraphqlController struct {
+	schema  *graphql.Schema
+	service services.QuoteServiceInterface
+	apiKeys []string
+}
+
+// NewGraphqlController creates a controller serving the GraphQL schema. With
+// apiKeys set, mutations require an "Authorization: Bearer <key>" header.
+func NewGraphqlController(service services.QuoteServiceInterface, apiKeys []string) *GraphqlController {
+	schema := graphql.MustParseSchema(graphqlSchema, &graphqlResolver{service: service},
+		graphql.UseStringDescriptions(), graphql.MaxDepth(graphqlMaxDepth))
+
+	return &GraphqlController{schema: schema, service: service, apiKeys: apiKeys}
+}
+
+func (c *GraphqlController) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc(GraphqlPath, traceHandler(GraphqlPath, c.execute)).Methods("POST")
+}
+
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    map[string]interface{} `json:"extensions"`
+}
+
+// execute answers every request that could be decoded with 200, reporting
+// failures in the errors of the response as GraphQL over HTTP does.
+func (c *GraphqlController) execute(w http.ResponseWriter, r *http.Request) {
+	var request graphqlRequest
+	if !decodeBody(w, r, &request) {
+		return
+	}
+
+	authorized := len(c.apiKeys) == 0
+	if token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found {
+		authorized = authorized || isValidApiKey(c.apiKeys, token)
+	}
+
+	ctx := context.WithValue(withGraphqlLoaders(r.Context(), c.service), graphqlAuthorizedKey{}, authorized)
+	response := c.schema.Exec(ctx, request.Query, request.OperationName, request.Variables)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
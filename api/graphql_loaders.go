@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"quotes/internal/dtos"
+	"quotes/internal/services"
+)
+
+// batchLoader loads the quotes of authors or tags for one GraphQL request.
+// Loading a key also loads its siblings, the keys of the other elements of
+// the list it was resolved from, so that resolving a field on every element
+// of a list takes one service call instead of one per element. Results are
+// kept for the rest of the request.
+type batchLoader struct {
+	fetch func(ctx context.Context, keys []string) (map[string][]dtos.QuoteDto, error)
+
+	mu      sync.Mutex
+	batches map[string]*batch
+}
+
+// batch is one service call, shared by every key it loads.
+type batch struct {
+	done   chan struct{}
+	quotes map[string][]dtos.QuoteDto
+	err    error
+}
+
+func newBatchLoader(fetch func(ctx context.Context, keys []string) (map[string][]dtos.QuoteDto, error)) *batchLoader {
+	return &batchLoader{fetch: fetch, batches: make(map[string]*batch)}
+}
+
+func (l *batchLoader) load(ctx context.Context, key string, siblings []string) ([]dtos.QuoteDto, error) {
+	l.mu.Lock()
+	if existing, ok := l.batches[key]; ok {
+		l.mu.Unlock()
+		<-existing.done
+		return existing.quotes[key], existing.err
+	}
+
+	current := &batch{done: make(chan struct{})}
+	var keys []string
+	for _, candidate := range append(slices.Clip(siblings), key) {
+		if _, ok := l.batches[candidate]; !ok {
+			l.batches[candidate] = current
+			keys = append(keys, candidate)
+		}
+	}
+	l.mu.Unlock()
+
+	current.quotes, current.err = l.fetch(ctx, keys)
+	close(current.done)
+
+	return current.quotes[key], current.err
+}
+
+// graphqlLoaders holds the loaders of one request.
+type graphqlLoaders struct {
+	quotesByAuthor *batchLoader
+	quotesByTag    *batchLoader
+}
+
+type loadersKey struct{}
+
+func withGraphqlLoaders(ctx context.Context, service services.QuoteServiceInterface) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &graphqlLoaders{
+		quotesByAuthor: newBatchLoader(service.GetQuotesByAuthors),
+		quotesByTag:    newBatchLoader(service.GetQuotesByTags),
+	})
+}
+
+func graphqlLoadersFromContext(ctx context.Context) *graphqlLoaders {
+	return ctx.Value(loadersKey{}).(*graphqlLoaders)
+}
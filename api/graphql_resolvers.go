@@ -0,0 +1,380 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"quotes/internal/drivers"
+	"quotes/internal/dtos"
+	"quotes/internal/logging"
+	"quotes/internal/services"
+)
+
+// graphqlResolver is the root resolver of the GraphQL schema.
+type graphqlResolver struct {
+	service services.QuoteServiceInterface
+}
+
+type pageArgs struct {
+	Limit  int32
+	Offset int32
+}
+
+func (args pageArgs) validate() error {
+	var fieldErrors []dtos.FieldErrorDto
+	if args.Limit < 1 || args.Limit > maxPageSize {
+		fieldErrors = append(fieldErrors, dtos.FieldErrorDto{Field: "limit", Code: "out_of_range", Message: "limit must be between 1 and 100"})
+	}
+	if args.Offset < 0 {
+		fieldErrors = append(fieldErrors, dtos.FieldErrorDto{Field: "offset", Code: "out_of_range", Message: "offset must not be negative"})
+	}
+	if len(fieldErrors) > 0 {
+		return &graphqlError{message: "The arguments are invalid", code: "VALIDATION_FAILED", fields: fieldErrors}
+	}
+	return nil
+}
+
+// end is how many quotes there are up to the end of the page.
+func (args pageArgs) end() int {
+	return int(args.Offset) + int(args.Limit)
+}
+
+// page returns the quotes selected by the validated limit and offset.
+func (args pageArgs) page(quotes []dtos.QuoteDto) []dtos.QuoteDto {
+	start := min(int(args.Offset), len(quotes))
+	end := min(start+int(args.Limit), len(quotes))
+	return quotes[start:end]
+}
+
+func (r *graphqlResolver) Quotes(ctx context.Context, args struct {
+	Author *string
+	Tag    *string
+	pageArgs
+}) ([]*quoteResolver, error) {
+	if err := args.validate(); err != nil {
+		return nil, err
+	}
+
+	var author string
+	if args.Author != nil {
+		author = *args.Author
+	}
+
+	var quotes []dtos.QuoteDto
+	var err error
+	if args.Tag != nil {
+		quotes, err = r.service.GetQuotesByTag(ctx, *args.Tag, author)
+	} else {
+		// Only the quotes up to the end of the page are read.
+		quotes, err = r.service.GetQuotesPage(ctx, author, pgtype.UUID{}, args.end())
+	}
+	if err != nil {
+		return nil, graphqlServiceError(ctx, err, "Failed to retrieve quotes")
+	}
+
+	return newQuoteResolvers(args.page(quotes)), nil
+}
+
+func (r *graphqlResolver) SearchQuotes(ctx context.Context, args struct {
+	Query string
+	pageArgs
+}) ([]*quoteResolver, error) {
+	if strings.TrimSpace(args.Query) == "" {
+		return nil, &graphqlError{message: "The arguments are invalid", code: "VALIDATION_FAILED", fields: []dtos.FieldErrorDto{
+			{Field: "query", Code: "required", Message: "query is required"},
+		}}
+	}
+	if err := args.validate(); err != nil {
+		return nil, err
+	}
+
+	matches, err := scanQuotes(ctx, r.service, args.Query, pgtype.UUID{}, args.end())
+	if err != nil {
+		return nil, graphqlServiceError(ctx, err, "Failed to search quotes")
+	}
+
+	return newQuoteResolvers(args.page(matches)), nil
+}
+
+func (r *graphqlResolver) RandomQuote(ctx context.Context) (*quoteResolver, error) {
+	quote, err := r.service.GetRandomQuote(ctx)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, graphqlServiceError(ctx, err, "Failed to retrieve random quote")
+	}
+
+	return newQuoteResolvers([]dtos.QuoteDto{*quote})[0], nil
+}
+
+func (r *graphqlResolver) Quote(ctx context.Context, args struct{ Id graphql.ID }) (*quoteResolver, error) {
+	id, err := parseUUID(string(args.Id))
+	if err != nil {
+		return nil, invalidIdGraphqlError()
+	}
+
+	quote, err := r.service.GetQuoteById(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, graphqlServiceError(ctx, err, "Failed to retrieve quote")
+	}
+
+	return newQuoteResolvers([]dtos.QuoteDto{*quote})[0], nil
+}
+
+func (r *graphqlResolver) Author(ctx context.Context, args struct{ Name string }) (*authorResolver, error) {
+	author := &authorResolver{name: args.Name}
+	quotes, err := author.Quotes(ctx)
+	if err != nil || len(quotes) == 0 {
+		return nil, err
+	}
+
+	return author, nil
+}
+
+func (r *graphqlResolver) Tag(ctx context.Context, args struct{ Name string }) (*tagResolver, error) {
+	tag := &tagResolver{name: args.Name}
+	quotes, err := tag.Quotes(ctx)
+	if err != nil || len(quotes) == 0 {
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+type quoteInput struct {
+	Author string
+	Text   string
+	Tags   *[]string
+}
+
+func (input quoteInput) dto() dtos.QuoteDto {
+	quote := dtos.QuoteDto{Author: &input.Author, Text: &input.Text}
+	if input.Tags != nil {
+		quote.Tags = *input.Tags
+	}
+	return quote
+}
+
+func (r *graphqlResolver) CreateQuote(ctx context.Context, args struct{ Input quoteInput }) (*quoteResolver, error) {
+	if err := requireGraphqlApiKey(ctx); err != nil {
+		return nil, err
+	}
+	ctx = markWrite(ctx)
+
+	quote, err := r.service.CreateQuote(ctx, args.Input.dto())
+	if err != nil {
+		return nil, graphqlServiceError(ctx, err, "Failed to create quote")
+	}
+
+	return newQuoteResolvers([]dtos.QuoteDto{*quote})[0], nil
+}
+
+func (r *graphqlResolver) UpdateQuote(ctx context.Context, args struct {
+	Id      graphql.ID
+	Input   quoteInput
+	Version int32
+}) (*quoteResolver, error) {
+	if err := requireGraphqlApiKey(ctx); err != nil {
+		return nil, err
+	}
+	ctx = markWrite(ctx)
+
+	id, err := parseUUID(string(args.Id))
+	if err != nil {
+		return nil, invalidIdGraphqlError()
+	}
+
+	quote, err := r.service.UpdateQuote(ctx, id, args.Input.dto(), int64(args.Version))
+	if err != nil {
+		return nil, graphqlServiceError(ctx, err, "Failed to update quote")
+	}
+
+	return newQuoteResolvers([]dtos.QuoteDto{*quote})[0], nil
+}
+
+func (r *graphqlResolver) DeleteQuote(ctx context.Context, args struct {
+	Id      graphql.ID
+	Version int32
+}) (bool, error) {
+	if err := requireGraphqlApiKey(ctx); err != nil {
+		return false, err
+	}
+	ctx = markWrite(ctx)
+
+	id, err := parseUUID(string(args.Id))
+	if err != nil {
+		return false, invalidIdGraphqlError()
+	}
+
+	if err := r.service.DeleteQuote(ctx, id, int64(args.Version)); err != nil {
+		return false, graphqlServiceError(ctx, err, "Failed to delete quote")
+	}
+
+	return true, nil
+}
+
+// siblings are the authors and tags of a list of quotes, which are loaded
+// together when the quotes of one of them are resolved.
+type siblings struct {
+	authors []string
+	tags    []string
+}
+
+type quoteResolver struct {
+	quote    dtos.QuoteDto
+	siblings *siblings
+}
+
+func newQuoteResolvers(quotes []dtos.QuoteDto) []*quoteResolver {
+	shared := &siblings{}
+	resolvers := make([]*quoteResolver, len(quotes))
+	for i, quote := range quotes {
+		if !slices.Contains(shared.authors, *quote.Author) {
+			shared.authors = append(shared.authors, *quote.Author)
+		}
+		for _, tag := range quote.Tags {
+			if !slices.Contains(shared.tags, tag) {
+				shared.tags = append(shared.tags, tag)
+			}
+		}
+		resolvers[i] = &quoteResolver{quote: quote, siblings: shared}
+	}
+	return resolvers
+}
+
+func (r *quoteResolver) Id() graphql.ID {
+	return graphql.ID(r.quote.Id.String())
+}
+
+func (r *quoteResolver) Text() string {
+	return *r.quote.Text
+}
+
+func (r *quoteResolver) Author() *authorResolver {
+	return &authorResolver{name: *r.quote.Author, siblings: r.siblings.authors}
+}
+
+func (r *quoteResolver) Tags() []*tagResolver {
+	tags := make([]*tagResolver, len(r.quote.Tags))
+	for i, tag := range r.quote.Tags {
+		tags[i] = &tagResolver{name: tag, siblings: r.siblings.tags}
+	}
+	return tags
+}
+
+func (r *quoteResolver) Version() int32 {
+	return int32(r.quote.Version)
+}
+
+func (r *quoteResolver) UpdatedAt() string {
+	return r.quote.UpdatedAt.UTC().Format(time.RFC3339)
+}
+
+type authorResolver struct {
+	name     string
+	siblings []string
+}
+
+func (r *authorResolver) Name() string {
+	return r.name
+}
+
+func (r *authorResolver) Quotes(ctx context.Context) ([]*quoteResolver, error) {
+	quotes, err := graphqlLoadersFromContext(ctx).quotesByAuthor.load(ctx, r.name, r.siblings)
+	if err != nil {
+		return nil, graphqlServiceError(ctx, err, "Failed to retrieve quotes")
+	}
+
+	return newQuoteResolvers(quotes), nil
+}
+
+type tagResolver struct {
+	name     string
+	siblings []string
+}
+
+func (r *tagResolver) Name() string {
+	return r.name
+}
+
+func (r *tagResolver) Quotes(ctx context.Context) ([]*quoteResolver, error) {
+	quotes, err := graphqlLoadersFromContext(ctx).quotesByTag.load(ctx, r.name, r.siblings)
+	if err != nil {
+		return nil, graphqlServiceError(ctx, err, "Failed to retrieve quotes")
+	}
+
+	return newQuoteResolvers(quotes), nil
+}
+
+// graphqlError is an error of a GraphQL field with a machine-readable code
+// and, for VALIDATION_FAILED, the rejected fields in its extensions.
+type graphqlError struct {
+	message string
+	code    string
+	fields  []dtos.FieldErrorDto
+}
+
+func (e *graphqlError) Error() string {
+	return e.message
+}
+
+func (e *graphqlError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.code}
+	if len(e.fields) > 0 {
+		extensions["fields"] = e.fields
+	}
+	return extensions
+}
+
+func invalidIdGraphqlError() error {
+	return &graphqlError{message: "id must be a UUID", code: "VALIDATION_FAILED", fields: []dtos.FieldErrorDto{
+		{Field: "id", Code: "invalid", Message: "id must be a UUID"},
+	}}
+}
+
+type graphqlAuthorizedKey struct{}
+
+// requireGraphqlApiKey fails mutations of requests without a valid API key.
+func requireGraphqlApiKey(ctx context.Context) error {
+	if authorized, _ := ctx.Value(graphqlAuthorizedKey{}).(bool); !authorized {
+		return &graphqlError{message: "A valid API key is required", code: "UNAUTHENTICATED"}
+	}
+	return nil
+}
+
+// graphqlServiceError maps a failed service call to a field error like
+// grpcServiceError does for gRPC. Failures are logged.
+func graphqlServiceError(ctx context.Context, err error, message string) error {
+	var validationErr *services.ValidationError
+	if errors.As(err, &validationErr) {
+		return &graphqlError{message: "The quote is invalid", code: "VALIDATION_FAILED", fields: validationErr.Fields}
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &graphqlError{message: "Quote not found", code: "NOT_FOUND"}
+	}
+
+	var conflictErr *drivers.VersionConflictError
+	if errors.As(err, &conflictErr) {
+		return &graphqlError{message: "Quote has been modified", code: "CONFLICT"}
+	}
+
+	logging.FromContext(ctx).Error(message, "error", err)
+
+	var openErr *drivers.CircuitOpenError
+	if errors.As(err, &openErr) {
+		return &graphqlError{message: "The database is unavailable", code: "UNAVAILABLE"}
+	}
+
+	return &graphqlError{message: message, code: "INTERNAL"}
+}
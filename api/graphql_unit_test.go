@@ -0,0 +1,157 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/dtos"
+	"quotes/internal/services"
+)
+
+type graphqlResult struct {
+	Data   map[string]any `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+// execGraphql posts query with variables to a GraphQL controller for service
+// and decodes the result.
+func execGraphql(t *testing.T, service services.QuoteServiceInterface, apiKeys []string, authorization, query string, variables map[string]any) graphqlResult {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	require.NoError(t, err)
+	req := httptest.NewRequest("POST", GraphqlPath, bytes.NewReader(body))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	rr := httptest.NewRecorder()
+	NewGraphqlController(service, apiKeys).execute(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var result graphqlResult
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	return result
+}
+
+func TestGraphqlQuotes(t *testing.T) {
+	service := new(MockQuoteService)
+	quotes := testQuoteDtos(3)
+	quotes[1].Tags = []string{"wisdom"}
+	service.On("GetQuotesPage", mock.Anything, "", pgtype.UUID{}, 3).Return(quotes, nil)
+
+	result := execGraphql(t, service, nil, "", `{ quotes(limit: 2, offset: 1) { id text author { name } tags { name } version } }`, nil)
+	require.Empty(t, result.Errors)
+
+	list := result.Data["quotes"].([]any)
+	require.Len(t, list, 2)
+	first := list[0].(map[string]any)
+	assert.Equal(t, quotes[1].Id.String(), first["id"])
+	assert.Equal(t, "Text b", first["text"])
+	assert.Equal(t, map[string]any{"name": "Author"}, first["author"])
+	assert.Equal(t, []any{map[string]any{"name": "wisdom"}}, first["tags"])
+	assert.Equal(t, float64(1), first["version"])
+}
+
+func TestGraphqlQuotesByTagAndAuthor(t *testing.T) {
+	service := new(MockQuoteService)
+	quotes := testQuoteDtos(2)
+	service.On("GetQuotesByTag", mock.Anything, "wisdom", " author ").Return(quotes, nil)
+
+	result := execGraphql(t, service, nil, "", `{ quotes(author: " author ", tag: "wisdom", offset: 1) { id } }`, nil)
+	require.Empty(t, result.Errors)
+
+	assert.Equal(t, []any{map[string]any{"id": quotes[1].Id.String()}}, result.Data["quotes"])
+	service.AssertExpectations(t)
+}
+
+func TestGraphqlSearchQuotesScansInBatches(t *testing.T) {
+	service := new(MockQuoteService)
+	batch := testQuoteDtos(searchBatchSize)
+	last := *batch[len(batch)-1].Id
+	matches := testQuoteDtos(2)
+	for i := range matches {
+		text := "Needle " + strconv.Itoa(i)
+		matches[i].Text = &text
+	}
+	service.On("GetQuotesPage", mock.Anything, "", pgtype.UUID{}, searchBatchSize).Return(batch, nil)
+	service.On("GetQuotesPage", mock.Anything, "", last, searchBatchSize).Return(matches, nil)
+
+	result := execGraphql(t, service, nil, "", `{ searchQuotes(query: "needle", limit: 1, offset: 1) { text } }`, nil)
+	require.Empty(t, result.Errors)
+
+	assert.Equal(t, []any{map[string]any{"text": "Needle 1"}}, result.Data["searchQuotes"])
+	service.AssertExpectations(t)
+}
+
+func TestGraphqlBatchesNestedQuotes(t *testing.T) {
+	service := new(MockQuoteService)
+	quotes := testQuoteDtos(4)
+	other := "Other"
+	quotes[2].Author = &other
+	quotes[3].Author = &other
+	service.On("GetQuotesPage", mock.Anything, "", pgtype.UUID{}, 20).Return(quotes, nil)
+	service.On("GetQuotesByAuthors", mock.Anything, mock.Anything).Return(map[string][]dtos.QuoteDto{
+		"Author": quotes[:2],
+		"Other":  quotes[2:],
+	}, nil)
+
+	result := execGraphql(t, service, nil, "", `{ quotes { author { name quotes { id } } } }`, nil)
+	require.Empty(t, result.Errors)
+
+	list := result.Data["quotes"].([]any)
+	require.Len(t, list, 4)
+	author := list[3].(map[string]any)["author"].(map[string]any)
+	assert.Equal(t, "Other", author["name"])
+	assert.Len(t, author["quotes"], 2)
+	service.AssertNumberOfCalls(t, "GetQuotesByAuthors", 1)
+	service.AssertCalled(t, "GetQuotesByAuthors", mock.Anything, []string{"Author", "Other"})
+}
+
+func TestGraphqlMutationsRequireApiKey(t *testing.T) {
+	service := new(MockQuoteService)
+	quote := testQuoteDtos(1)[0]
+	service.On("CreateQuote", mock.Anything, mock.Anything).Return(&quote, nil)
+	query := `mutation { createQuote(input: {author: "Author", text: "Text"}) { id } }`
+
+	result := execGraphql(t, service, []string{"secret"}, "", query, nil)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, "UNAUTHENTICATED", result.Errors[0].Extensions["code"])
+	service.AssertNotCalled(t, "CreateQuote", mock.Anything, mock.Anything)
+
+	result = execGraphql(t, service, []string{"secret"}, "Bearer secret", query, nil)
+	require.Empty(t, result.Errors)
+	assert.Equal(t, quote.Id.String(), result.Data["createQuote"].(map[string]any)["id"])
+}
+
+func TestGraphqlErrors(t *testing.T) {
+	service := new(MockQuoteService)
+	service.On("CreateQuote", mock.Anything, mock.Anything).Return(nil, &services.ValidationError{
+		Fields: []dtos.FieldErrorDto{{Field: "author", Code: "required", Message: "Author is required"}},
+	})
+
+	result := execGraphql(t, service, nil, "", `mutation($input: QuoteInput!) { createQuote(input: $input) { id } }`,
+		map[string]any{"input": map[string]any{"author": "", "text": "Text"}})
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, "VALIDATION_FAILED", result.Errors[0].Extensions["code"])
+	assert.Equal(t, []any{map[string]any{"field": "author", "code": "required", "message": "Author is required"}},
+		result.Errors[0].Extensions["fields"])
+
+	result = execGraphql(t, service, nil, "", `{ quotes(limit: 0) { id } }`, nil)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, "VALIDATION_FAILED", result.Errors[0].Extensions["code"])
+
+	result = execGraphql(t, service, nil, "", `{ quote(id: "not-a-uuid") { id } }`, nil)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, "VALIDATION_FAILED", result.Errors[0].Extensions["code"])
+}
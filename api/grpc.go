@@ -0,0 +1,145 @@
+package api
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=quotes --go-grpc_out=.. --go-grpc_opt=module=quotes quotes/v1/quotes.proto
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"quotes/internal/health"
+	"quotes/internal/logging"
+	quotesv1 "quotes/internal/pb/quotesv1"
+	"quotes/internal/services"
+)
+
+const grpcHealthWatchInterval = 5 * time.Second
+
+// modifyingMethods are the RPCs that require an API key when authentication
+// is enabled, like the modifying HTTP methods.
+var modifyingMethods = map[string]bool{
+	quotesv1.QuoteService_CreateQuote_FullMethodName: true,
+	quotesv1.QuoteService_UpdateQuote_FullMethodName: true,
+	quotesv1.QuoteService_DeleteQuote_FullMethodName: true,
+}
+
+// NewGrpcServer creates the gRPC server with the quote service, the health
+// service and server reflection. Every call gets a request ID and an access
+// log record like HTTP requests. With apiKeys set, modifying calls require
+// "authorization: Bearer <key>" metadata.
+func NewGrpcServer(service services.QuoteServiceInterface, checker *health.Checker, logger *slog.Logger, apiKeys []string) *grpc.Server {
+	unary := []grpc.UnaryServerInterceptor{requestIdUnaryInterceptor(logger)}
+	if len(apiKeys) > 0 {
+		unary = append(unary, authUnaryInterceptor(apiKeys))
+	}
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(requestIdStreamInterceptor(logger)),
+	)
+	quotesv1.RegisterQuoteServiceServer(server, NewGrpcQuoteServer(service))
+	healthpb.RegisterHealthServer(server, newGrpcHealthServer(checker, grpcHealthWatchInterval))
+	reflection.Register(server)
+
+	return server
+}
+
+// withGrpcRequestId takes the request ID from the x-request-id metadata or
+// generates one, returns it in the response header and attaches it and a
+// logger carrying it to ctx.
+func withGrpcRequestId(ctx context.Context, logger *slog.Logger, setHeader func(metadata.MD) error) context.Context {
+	var requestId string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(strings.ToLower(requestIdHeader)); len(values) > 0 {
+			requestId = values[0]
+		}
+	}
+	if !isValidRequestId(requestId) {
+		requestId = uuid.NewString()
+	}
+
+	setHeader(metadata.Pairs(strings.ToLower(requestIdHeader), requestId))
+
+	ctx = logging.WithRequestId(ctx, requestId)
+	return logging.WithLogger(ctx, logger.With("request_id", requestId))
+}
+
+func logGrpcCall(ctx context.Context, method string, start time.Time, err error) {
+	remoteAddr := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+
+	logging.FromContext(ctx).Info("Request completed",
+		"method", method,
+		"code", status.Code(err).String(),
+		"duration_ms", float64(time.Since(start).Microseconds())/1000,
+		"remote_addr", remoteAddr,
+	)
+}
+
+func requestIdUnaryInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx = withGrpcRequestId(ctx, logger, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) })
+
+		resp, err := handler(ctx, req)
+		logGrpcCall(ctx, info.FullMethod, start, err)
+
+		return resp, err
+	}
+}
+
+func requestIdStreamInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := withGrpcRequestId(stream.Context(), logger, stream.SetHeader)
+
+		err := handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+		logGrpcCall(ctx, info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+// contextStream replaces the context of a server stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// authUnaryInterceptor rejects modifying calls without a valid API key with
+// UNAUTHENTICATED.
+func authUnaryInterceptor(apiKeys []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !modifyingMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		var token string
+		found := false
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("authorization"); len(values) > 0 {
+				token, found = strings.CutPrefix(values[0], "Bearer ")
+			}
+		}
+		if !found || !isValidApiKey(apiKeys, token) {
+			return nil, status.Error(codes.Unauthenticated, "A valid API key is required")
+		}
+
+		return handler(ctx, req)
+	}
+}
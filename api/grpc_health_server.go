@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"quotes/internal/health"
+	quotesv1 "quotes/internal/pb/quotesv1"
+)
+
+// grpcHealthServer implements the standard gRPC health service with the
+// readiness checks of /readyz. The empty service name stands for the whole
+// server.
+type grpcHealthServer struct {
+	healthpb.UnimplementedHealthServer
+	checker *health.Checker
+	// watchInterval is how often Watch re-runs the checks.
+	watchInterval time.Duration
+}
+
+func newGrpcHealthServer(checker *health.Checker, watchInterval time.Duration) *grpcHealthServer {
+	return &grpcHealthServer{checker: checker, watchInterval: watchInterval}
+}
+
+func (s *grpcHealthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if !isKnownService(req.Service) {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+
+	return &healthpb.HealthCheckResponse{Status: s.status(ctx)}, nil
+}
+
+// Watch sends the serving status and then every change of it until the
+// client goes away.
+func (s *grpcHealthServer) Watch(req *healthpb.HealthCheckRequest, stream grpc.ServerStreamingServer[healthpb.HealthCheckResponse]) error {
+	ctx := stream.Context()
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		current := healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		if isKnownService(req.Service) {
+			current = s.status(ctx)
+		}
+
+		if current != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *grpcHealthServer) status(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	if s.checker.Check(ctx).Status != health.StatusOk {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	return healthpb.HealthCheckResponse_SERVING
+}
+
+func isKnownService(service string) bool {
+	return service == "" || service == quotesv1.QuoteService_ServiceDesc.ServiceName
+}
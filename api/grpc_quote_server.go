@@ -0,0 +1,237 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"quotes/internal/drivers"
+	"quotes/internal/dtos"
+	"quotes/internal/logging"
+	quotesv1 "quotes/internal/pb/quotesv1"
+	"quotes/internal/services"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// GrpcQuoteServer implements the gRPC quote service on top of the same
+// service layer as the REST API.
+type GrpcQuoteServer struct {
+	quotesv1.UnimplementedQuoteServiceServer
+	service services.QuoteServiceInterface
+}
+
+func NewGrpcQuoteServer(service services.QuoteServiceInterface) *GrpcQuoteServer {
+	return &GrpcQuoteServer{service: service}
+}
+
+func (s *GrpcQuoteServer) CreateQuote(ctx context.Context, req *quotesv1.CreateQuoteRequest) (*quotesv1.Quote, error) {
+	quote, err := s.service.CreateQuote(ctx, dtos.QuoteDto{Author: &req.Author, Text: &req.Text, Tags: req.Tags})
+	if err != nil {
+		return nil, grpcServiceError(ctx, err, "Failed to create quote")
+	}
+
+	return toProtoQuote(quote), nil
+}
+
+func (s *GrpcQuoteServer) GetQuote(ctx context.Context, req *quotesv1.GetQuoteRequest) (*quotesv1.Quote, error) {
+	id, err := parseUUID(req.Id)
+	if err != nil {
+		return nil, invalidIdError()
+	}
+
+	quote, err := s.service.GetQuoteById(ctx, id)
+	if err != nil {
+		return nil, grpcServiceError(ctx, err, "Failed to retrieve quote")
+	}
+
+	return toProtoQuote(quote), nil
+}
+
+func (s *GrpcQuoteServer) UpdateQuote(ctx context.Context, req *quotesv1.UpdateQuoteRequest) (*quotesv1.Quote, error) {
+	id, err := parseUUID(req.Id)
+	if err != nil {
+		return nil, invalidIdError()
+	}
+
+	quote, err := s.service.UpdateQuote(ctx, id, dtos.QuoteDto{Author: &req.Author, Text: &req.Text, Tags: req.Tags}, req.Version)
+	if err != nil {
+		return nil, grpcServiceError(ctx, err, "Failed to update quote")
+	}
+
+	return toProtoQuote(quote), nil
+}
+
+func (s *GrpcQuoteServer) DeleteQuote(ctx context.Context, req *quotesv1.DeleteQuoteRequest) (*quotesv1.DeleteQuoteResponse, error) {
+	id, err := parseUUID(req.Id)
+	if err != nil {
+		return nil, invalidIdError()
+	}
+
+	if err := s.service.DeleteQuote(ctx, id, req.Version); err != nil {
+		return nil, grpcServiceError(ctx, err, "Failed to delete quote")
+	}
+
+	return &quotesv1.DeleteQuoteResponse{}, nil
+}
+
+func (s *GrpcQuoteServer) ListQuotes(ctx context.Context, req *quotesv1.ListQuotesRequest) (*quotesv1.ListQuotesResponse, error) {
+	after, size, err := parsePage(req.PageToken, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// One more quote than the page holds tells whether another page follows.
+	quotes, err := s.service.GetQuotesPage(ctx, req.Author, after, size+1)
+	if err != nil {
+		return nil, grpcServiceError(ctx, err, "Failed to retrieve quotes")
+	}
+
+	page, nextPageToken := paginate(quotes, size)
+	return &quotesv1.ListQuotesResponse{Quotes: page, NextPageToken: nextPageToken}, nil
+}
+
+func (s *GrpcQuoteServer) GetRandomQuote(ctx context.Context, _ *quotesv1.GetRandomQuoteRequest) (*quotesv1.Quote, error) {
+	quote, err := s.service.GetRandomQuote(ctx)
+	if err != nil {
+		return nil, grpcServiceError(ctx, err, "Failed to retrieve random quote")
+	}
+
+	return toProtoQuote(quote), nil
+}
+
+func (s *GrpcQuoteServer) SearchQuotes(ctx context.Context, req *quotesv1.SearchQuotesRequest) (*quotesv1.SearchQuotesResponse, error) {
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, status.Error(codes.InvalidArgument, "query is required")
+	}
+
+	after, size, err := parsePage(req.PageToken, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// One more match than the page holds tells whether another page follows.
+	matches, err := scanQuotes(ctx, s.service, req.Query, after, size+1)
+	if err != nil {
+		return nil, grpcServiceError(ctx, err, "Failed to search quotes")
+	}
+
+	page, nextPageToken := paginate(matches, size)
+	return &quotesv1.SearchQuotesResponse{Quotes: page, NextPageToken: nextPageToken}, nil
+}
+
+// parsePage decodes a page token, which is the opaque form of the id of the
+// last quote of the previous page, and applies the default and maximum page
+// size.
+func parsePage(pageToken string, pageSize int32) (after pgtype.UUID, size int, err error) {
+	if pageSize < 0 {
+		return after, 0, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+	size = defaultPageSize
+	if pageSize > 0 {
+		size = min(int(pageSize), maxPageSize)
+	}
+
+	if pageToken == "" {
+		return after, size, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(pageToken)
+	if err == nil {
+		err = after.Scan(string(decoded))
+	}
+	if err != nil || !after.Valid {
+		return after, 0, status.Error(codes.InvalidArgument, "page_token is invalid")
+	}
+
+	return after, size, nil
+}
+
+// paginate returns the first size quotes as the page and, if there are more,
+// the token of the following page.
+func paginate(quotes []dtos.QuoteDto, size int) ([]*quotesv1.Quote, string) {
+	end := min(size, len(quotes))
+
+	page := make([]*quotesv1.Quote, 0, end)
+	for i := range end {
+		page = append(page, toProtoQuote(&quotes[i]))
+	}
+
+	if end == len(quotes) {
+		return page, ""
+	}
+
+	return page, base64.RawURLEncoding.EncodeToString([]byte(quotes[end-1].Id.String()))
+}
+
+func toProtoQuote(quote *dtos.QuoteDto) *quotesv1.Quote {
+	message := &quotesv1.Quote{Version: quote.Version, Tags: quote.Tags}
+	if quote.Id != nil {
+		message.Id = quote.Id.String()
+	}
+	if quote.Author != nil {
+		message.Author = *quote.Author
+	}
+	if quote.Text != nil {
+		message.Text = *quote.Text
+	}
+	if !quote.UpdatedAt.IsZero() {
+		message.UpdateTime = timestamppb.New(quote.UpdatedAt)
+	}
+
+	return message
+}
+
+func invalidIdError() error {
+	return status.Error(codes.InvalidArgument, "id must be a UUID")
+}
+
+// grpcServiceError maps a failed service call to a status like
+// writeServiceError does for HTTP: INVALID_ARGUMENT with the rejected fields
+// when validation fails, NOT_FOUND for missing quotes, ABORTED when the
+// expected version no longer matches, UNAVAILABLE while the database circuit
+// breaker is open and INTERNAL otherwise. Failures are logged.
+func grpcServiceError(ctx context.Context, err error, message string) error {
+	var validationErr *services.ValidationError
+	if errors.As(err, &validationErr) {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(validationErr.Fields))
+		for i, field := range validationErr.Fields {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: field.Field, Description: field.Message, Reason: field.Code}
+		}
+
+		st, detailErr := status.New(codes.InvalidArgument, "The quote is invalid").
+			WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+		if detailErr != nil {
+			return status.Error(codes.InvalidArgument, validationErr.Error())
+		}
+		return st.Err()
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return status.Error(codes.NotFound, "Quote not found")
+	}
+
+	var conflictErr *drivers.VersionConflictError
+	if errors.As(err, &conflictErr) {
+		return status.Error(codes.Aborted, "Quote has been modified")
+	}
+
+	logging.FromContext(ctx).Error(message, "error", err)
+
+	var openErr *drivers.CircuitOpenError
+	if errors.As(err, &openErr) {
+		return status.Error(codes.Unavailable, "The database is unavailable")
+	}
+
+	return status.Error(codes.Internal, message)
+}
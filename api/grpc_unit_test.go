@@ -0,0 +1,215 @@
+package api
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"quotes/internal/drivers"
+	"quotes/internal/dtos"
+	"quotes/internal/health"
+	quotesv1 "quotes/internal/pb/quotesv1"
+	"quotes/internal/services"
+)
+
+// dialGrpc serves a gRPC server for service in memory and returns a
+// connection to it.
+func dialGrpc(t *testing.T, service services.QuoteServiceInterface, checker *health.Checker, apiKeys []string) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	server := NewGrpcServer(service, checker, slog.New(slog.NewTextHandler(io.Discard, nil)), apiKeys)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func testQuoteDtos(n int) []dtos.QuoteDto {
+	quotes := make([]dtos.QuoteDto, n)
+	for i := range quotes {
+		id := pgtype.UUID{Bytes: uuid.New(), Valid: true}
+		author := "Author"
+		text := "Text " + string(rune('a'+i))
+		quotes[i] = dtos.QuoteDto{Id: &id, Author: &author, Text: &text, Version: 1}
+	}
+	return quotes
+}
+
+func TestGrpcGetQuote(t *testing.T) {
+	service := new(MockQuoteService)
+	quote := testQuoteDtos(1)[0]
+	quote.UpdatedAt = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	service.On("GetQuoteById", mock.Anything, *quote.Id).Return(&quote, nil)
+	client := quotesv1.NewQuoteServiceClient(dialGrpc(t, service, health.NewChecker(time.Second), nil))
+
+	var header metadata.MD
+	response, err := client.GetQuote(context.Background(), &quotesv1.GetQuoteRequest{Id: quote.Id.String()}, grpc.Header(&header))
+	require.NoError(t, err)
+
+	assert.Equal(t, quote.Id.String(), response.Id)
+	assert.Equal(t, "Author", response.Author)
+	assert.Equal(t, int64(1), response.Version)
+	assert.Equal(t, quote.UpdatedAt, response.UpdateTime.AsTime())
+	assert.Len(t, header.Get("x-request-id"), 1)
+}
+
+func TestGrpcErrors(t *testing.T) {
+	service := new(MockQuoteService)
+	missing := pgtype.UUID{Bytes: uuid.New(), Valid: true}
+	service.On("GetQuoteById", mock.Anything, missing).Return(nil, pgx.ErrNoRows)
+	service.On("DeleteQuote", mock.Anything, missing, int64(3)).Return(&drivers.VersionConflictError{})
+	service.On("CreateQuote", mock.Anything, mock.Anything).Return(nil, &services.ValidationError{
+		Fields: []dtos.FieldErrorDto{{Field: "author", Code: "required", Message: "Author is required"}},
+	})
+	client := quotesv1.NewQuoteServiceClient(dialGrpc(t, service, health.NewChecker(time.Second), nil))
+	ctx := context.Background()
+
+	_, err := client.GetQuote(ctx, &quotesv1.GetQuoteRequest{Id: "not-a-uuid"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.GetQuote(ctx, &quotesv1.GetQuoteRequest{Id: missing.String()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.DeleteQuote(ctx, &quotesv1.DeleteQuoteRequest{Id: missing.String(), Version: 3})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	_, err = client.CreateQuote(ctx, &quotesv1.CreateQuoteRequest{Text: "Text"})
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	require.Len(t, st.Details(), 1)
+	badRequest := st.Details()[0].(*errdetails.BadRequest)
+	assert.Equal(t, "author", badRequest.FieldViolations[0].Field)
+	assert.Equal(t, "required", badRequest.FieldViolations[0].Reason)
+}
+
+func TestGrpcListQuotesPaginates(t *testing.T) {
+	service := new(MockQuoteService)
+	quotes := testQuoteDtos(5)
+	service.On("GetQuotesPage", mock.Anything, "", pgtype.UUID{}, 3).Return(quotes[:3], nil)
+	service.On("GetQuotesPage", mock.Anything, "", *quotes[1].Id, 3).Return(quotes[2:5], nil)
+	service.On("GetQuotesPage", mock.Anything, "", *quotes[3].Id, 3).Return(quotes[4:], nil)
+	client := quotesv1.NewQuoteServiceClient(dialGrpc(t, service, health.NewChecker(time.Second), nil))
+	ctx := context.Background()
+
+	var ids []string
+	token := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3)
+		response, err := client.ListQuotes(ctx, &quotesv1.ListQuotesRequest{PageSize: 2, PageToken: token})
+		require.NoError(t, err)
+		for _, quote := range response.Quotes {
+			ids = append(ids, quote.Id)
+		}
+		if token = response.NextPageToken; token == "" {
+			break
+		}
+	}
+
+	require.Len(t, ids, 5)
+	assert.Equal(t, quotes[4].Id.String(), ids[4])
+	service.AssertExpectations(t)
+
+	_, err := client.ListQuotes(ctx, &quotesv1.ListQuotesRequest{PageToken: "???"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGrpcSearchQuotes(t *testing.T) {
+	service := new(MockQuoteService)
+	quotes := testQuoteDtos(3)
+	service.On("GetQuotesPage", mock.Anything, "", pgtype.UUID{}, searchBatchSize).Return(quotes, nil)
+	client := quotesv1.NewQuoteServiceClient(dialGrpc(t, service, health.NewChecker(time.Second), nil))
+
+	response, err := client.SearchQuotes(context.Background(), &quotesv1.SearchQuotesRequest{Query: "TEXT B"})
+	require.NoError(t, err)
+
+	require.Len(t, response.Quotes, 1)
+	assert.Equal(t, "Text b", response.Quotes[0].Text)
+	assert.Empty(t, response.NextPageToken)
+}
+
+func TestGrpcSearchQuotesScansInBatches(t *testing.T) {
+	service := new(MockQuoteService)
+	quotes := testQuoteDtos(searchBatchSize + 2)
+	first, second := quotes[:searchBatchSize], quotes[searchBatchSize:]
+	unique := "Text found only once"
+	second[1].Text = &unique
+	service.On("GetQuotesPage", mock.Anything, "", pgtype.UUID{}, searchBatchSize).Return(first, nil)
+	service.On("GetQuotesPage", mock.Anything, "", *first[searchBatchSize-1].Id, searchBatchSize).Return(second, nil)
+	client := quotesv1.NewQuoteServiceClient(dialGrpc(t, service, health.NewChecker(time.Second), nil))
+
+	// Every quote matches, so the first batch fills the page and the next
+	// page starts right after it.
+	response, err := client.SearchQuotes(context.Background(), &quotesv1.SearchQuotesRequest{Query: "text", PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, response.Quotes, 2)
+	service.AssertNumberOfCalls(t, "GetQuotesPage", 1)
+
+	after, _, err := parsePage(response.NextPageToken, 0)
+	require.NoError(t, err)
+	assert.Equal(t, *quotes[1].Id, after)
+
+	// A match in the second batch is found past a first batch without any.
+	response, err = client.SearchQuotes(context.Background(), &quotesv1.SearchQuotesRequest{Query: "only once"})
+	require.NoError(t, err)
+	require.Len(t, response.Quotes, 1)
+	assert.Equal(t, second[1].Id.String(), response.Quotes[0].Id)
+}
+
+func TestGrpcAuthRequiresKeyForModifyingCalls(t *testing.T) {
+	service := new(MockQuoteService)
+	quote := testQuoteDtos(1)[0]
+	service.On("CreateQuote", mock.Anything, mock.Anything).Return(&quote, nil)
+	service.On("GetRandomQuote", mock.Anything).Return(&quote, nil)
+	client := quotesv1.NewQuoteServiceClient(dialGrpc(t, service, health.NewChecker(time.Second), []string{"secret"}))
+	ctx := context.Background()
+
+	_, err := client.GetRandomQuote(ctx, &quotesv1.GetRandomQuoteRequest{})
+	assert.NoError(t, err)
+
+	_, err = client.CreateQuote(ctx, &quotesv1.CreateQuoteRequest{Author: "Author", Text: "Text"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	authorized := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+	_, err = client.CreateQuote(authorized, &quotesv1.CreateQuoteRequest{Author: "Author", Text: "Text"})
+	assert.NoError(t, err)
+}
+
+func TestGrpcHealth(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	client := healthpb.NewHealthClient(dialGrpc(t, new(MockQuoteService), checker, nil))
+	ctx := context.Background()
+
+	response, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "quotes.v1.QuoteService"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, response.Status)
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	checker.SetShuttingDown()
+	response, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, response.Status)
+}
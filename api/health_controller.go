@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"quotes/internal/dtos"
+	"quotes/internal/health"
+)
+
+type HealthController struct {
+	checker *health.Checker
+}
+
+func NewHealthController(checker *health.Checker) *HealthController {
+	return &HealthController{checker: checker}
+}
+
+func (c *HealthController) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/healthz", c.getHealth).Methods("GET")
+	router.HandleFunc("/readyz", c.getReadiness).Methods("GET")
+}
+
+func (c *HealthController) getHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSONResponse(w, dtos.HealthDto{Status: health.StatusOk}, http.StatusOK)
+}
+
+func (c *HealthController) getReadiness(w http.ResponseWriter, r *http.Request) {
+	readiness := c.checker.Check(r.Context())
+
+	statusCode := http.StatusOK
+	if readiness.Status != health.StatusOk {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	writeJSONResponse(w, readiness, statusCode)
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"quotes/internal/dtos"
+	"quotes/internal/health"
+)
+
+func TestGetHealth(t *testing.T) {
+	controller := NewHealthController(health.NewChecker(time.Second))
+
+	req := httptest.NewRequest("GET", "/healthz", nil)
+	rr := httptest.NewRecorder()
+
+	controller.getHealth(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var response dtos.HealthDto
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, health.StatusOk, response.Status)
+}
+
+func TestGetReadinessUnavailable(t *testing.T) {
+	checker := health.NewChecker(time.Second, health.Check{
+		Name: "database",
+		Run: func(ctx context.Context) error {
+			return errors.New("connection refused")
+		},
+	})
+	controller := NewHealthController(checker)
+
+	req := httptest.NewRequest("GET", "/readyz", nil)
+	rr := httptest.NewRecorder()
+
+	controller.getReadiness(rr, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	var response dtos.HealthDto
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, health.StatusUnavailable, response.Status)
+	assert.Equal(t, "connection refused", response.Checks["database"].Error)
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"quotes/internal/services"
+)
+
+type CacheStatsProvider interface {
+	Stats() services.CacheStats
+}
+
+// MetricsController exposes the cache counters in the Prometheus text
+// format.
+type MetricsController struct {
+	cache CacheStatsProvider
+}
+
+func NewMetricsController(cache CacheStatsProvider) *MetricsController {
+	return &MetricsController{cache: cache}
+}
+
+func (c *MetricsController) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/metrics", c.getMetrics).Methods("GET")
+}
+
+func (c *MetricsController) getMetrics(w http.ResponseWriter, r *http.Request) {
+	stats := c.cache.Stats()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	writeMetric(w, "quotes_cache_hits_total", "counter", "Reads served from the quote cache.", stats.Hits)
+	writeMetric(w, "quotes_cache_misses_total", "counter", "Reads that missed the quote cache.", stats.Misses)
+	writeMetric(w, "quotes_cache_invalidations_total", "counter", "Invalidations of the quote cache.", stats.Invalidations)
+	writeMetric(w, "quotes_cache_entries", "gauge", "Entries currently in the quote cache.", int64(stats.Size))
+}
+
+func writeMetric(w http.ResponseWriter, name, metricType, help string, value int64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, metricType, name, value)
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"quotes/internal/services"
+)
+
+type stubCacheStats services.CacheStats
+
+func (s stubCacheStats) Stats() services.CacheStats {
+	return services.CacheStats(s)
+}
+
+func TestGetMetrics(t *testing.T) {
+	controller := NewMetricsController(stubCacheStats{Hits: 7, Misses: 3, Invalidations: 1, Size: 2})
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	rr := httptest.NewRecorder()
+
+	controller.getMetrics(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, rr.Body.String(), "# TYPE quotes_cache_hits_total counter\nquotes_cache_hits_total 7\n")
+	assert.Contains(t, rr.Body.String(), "quotes_cache_misses_total 3\n")
+	assert.Contains(t, rr.Body.String(), "quotes_cache_entries 2\n")
+}
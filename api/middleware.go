@@ -0,0 +1,298 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"quotes/internal/drivers"
+	"quotes/internal/logging"
+)
+
+const (
+	requestIdHeader    = "X-Request-ID"
+	maxRequestIdLength = 128
+)
+
+// RequestIdMiddleware propagates the incoming X-Request-ID header, or
+// generates a new one, and attaches it together with a request-scoped logger
+// to the request context.
+func RequestIdMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestId := r.Header.Get(requestIdHeader)
+			if !isValidRequestId(requestId) {
+				requestId = uuid.NewString()
+			}
+
+			w.Header().Set(requestIdHeader, requestId)
+
+			ctx := logging.WithRequestId(r.Context(), requestId)
+			ctx = logging.WithLogger(ctx, logger.With("request_id", requestId))
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// AccessLogMiddleware writes one log record per request after it completes.
+func AccessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		logging.FromContext(r.Context()).Info("Request completed",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.statusCode,
+			"bytes", recorder.bytesWritten,
+			"duration_ms", float64(time.Since(start).Microseconds())/1000,
+			"remote_addr", r.RemoteAddr,
+			"user_agent", r.UserAgent(),
+		)
+	})
+}
+
+// CorsMiddleware answers preflight requests and adds CORS headers for the
+// allowed origins. An empty origin list disables CORS entirely.
+func CorsMiddleware(allowedOrigins, allowedMethods, allowedHeaders []string, maxAge time.Duration) func(http.Handler) http.Handler {
+	methods := strings.Join(allowedMethods, ", ")
+	headers := strings.Join(allowedHeaders, ", ")
+	anyOrigin := slices.Contains(allowedOrigins, "*")
+
+	return func(next http.Handler) http.Handler {
+		if len(allowedOrigins) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" || (!anyOrigin && !slices.Contains(allowedOrigins, origin)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Origin")
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Expose-Headers", requestIdHeader+", ETag, Deprecation, Sunset, Link")
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", methods)
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(maxAge.Seconds())))
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AuthMiddleware requires a valid "Authorization: Bearer <key>" header on
+// every request that may modify data. Safe methods stay public, as do the
+// selfAuthorizedPaths, whose handlers check the key themselves.
+func AuthMiddleware(apiKeys []string, selfAuthorizedPaths ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isModifyingMethod(r.Method) || slices.Contains(selfAuthorizedPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !hasApiKey(r, apiKeys) {
+				writeUnauthorized(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireApiKey protects handlers that are not public even for safe
+// methods. Without keys, when authentication is disabled, every request
+// passes.
+func requireApiKey(apiKeys []string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(apiKeys) > 0 && !hasApiKey(r, apiKeys) {
+			writeUnauthorized(w, r)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func hasApiKey(r *http.Request, apiKeys []string) bool {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return found && isValidApiKey(apiKeys, token)
+}
+
+func writeUnauthorized(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="quotes"`)
+	writeProblem(w, r, problemUnauthorized, "")
+}
+
+// BodyLimitMiddleware fails reading request bodies beyond maxBytes, which
+// handlers answer with 413.
+func BodyLimitMiddleware(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+const readPrimaryCookie = "quotes_read_primary_until"
+
+// ReadYourWritesMiddleware sends every read of a client to the primary
+// database for the given window after that client successfully modified
+// data, so that it sees its own writes despite replication lag. The window
+// is tracked with a cookie holding its end as a Unix timestamp in
+// milliseconds. Modifying requests always use the primary, except on the
+// selfRoutedPaths, whose handlers call markWrite for the requests that
+// modify data.
+func ReadYourWritesMiddleware(window time.Duration, selfRoutedPaths ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			selfRouted := slices.Contains(selfRoutedPaths, r.URL.Path)
+			if isModifyingMethod(r.Method) && !selfRouted {
+				writer := &readYourWritesWriter{ResponseWriter: w, window: window}
+				writer.modified.Store(true)
+				next.ServeHTTP(writer, r.WithContext(drivers.WithPrimary(r.Context())))
+				return
+			}
+
+			if cookie, err := r.Cookie(readPrimaryCookie); err == nil {
+				until, err := strconv.ParseInt(cookie.Value, 10, 64)
+				if err == nil && time.Now().UnixMilli() < until {
+					r = r.WithContext(drivers.WithPrimary(r.Context()))
+				}
+			}
+
+			if selfRouted {
+				writer := &readYourWritesWriter{ResponseWriter: w, window: window}
+				next.ServeHTTP(writer, r.WithContext(context.WithValue(r.Context(), readYourWritesKey{}, writer)))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type readYourWritesKey struct{}
+
+// markWrite sends the rest of a request to the primary database and, if
+// ReadYourWritesMiddleware serves the request, starts the window of the
+// client once the request succeeds.
+func markWrite(ctx context.Context) context.Context {
+	if writer, ok := ctx.Value(readYourWritesKey{}).(*readYourWritesWriter); ok {
+		writer.modified.Store(true)
+	}
+
+	return drivers.WithPrimary(ctx)
+}
+
+type readYourWritesWriter struct {
+	http.ResponseWriter
+	window      time.Duration
+	modified    atomic.Bool
+	wroteHeader bool
+}
+
+func (w *readYourWritesWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader && w.modified.Load() && statusCode < http.StatusBadRequest {
+		until := time.Now().Add(w.window)
+		http.SetCookie(w.ResponseWriter, &http.Cookie{
+			Name:     readPrimaryCookie,
+			Value:    strconv.FormatInt(until.UnixMilli(), 10),
+			Path:     "/",
+			Expires:  until,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *readYourWritesWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *readYourWritesWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func isModifyingMethod(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+}
+
+func isValidApiKey(apiKeys []string, token string) bool {
+	valid := false
+	for _, key := range apiKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(token)) == 1 {
+			valid = true
+		}
+	}
+
+	return valid
+}
+
+func isValidRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > maxRequestIdLength {
+		return false
+	}
+
+	for _, ch := range requestId {
+		if ch < 0x21 || ch > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode   int
+	bytesWritten int
+	wroteHeader  bool
+}
+
+func (r *statusRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.statusCode = statusCode
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytesWritten += n
+
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
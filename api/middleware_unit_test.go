@@ -0,0 +1,186 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/drivers"
+	"quotes/internal/logging"
+)
+
+func TestRequestIdMiddlewarePropagatesHeader(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))
+
+	var requestId string
+	handler := RequestIdMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId = logging.RequestIdFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/quotes", nil)
+	req.Header.Set(requestIdHeader, "abc-123")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, "abc-123", requestId)
+	assert.Equal(t, "abc-123", rr.Header().Get(requestIdHeader))
+}
+
+func TestRequestIdMiddlewareGeneratesId(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))
+	handler := RequestIdMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest("GET", "/quotes", nil)
+	req.Header.Set(requestIdHeader, "bad id\n")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	_, err := uuid.Parse(rr.Header().Get(requestIdHeader))
+	assert.NoError(t, err)
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := RequestIdMiddleware(logger)(AccessLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("tea"))
+	})))
+
+	req := httptest.NewRequest("GET", "/quotes", nil)
+	req.Header.Set(requestIdHeader, "abc-123")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "abc-123", record["request_id"])
+	assert.Equal(t, "GET", record["method"])
+	assert.Equal(t, "/quotes", record["path"])
+	assert.Equal(t, float64(http.StatusTeapot), record["status"])
+	assert.Equal(t, float64(3), record["bytes"])
+}
+
+func TestCorsMiddlewarePreflight(t *testing.T) {
+	handler := CorsMiddleware([]string{"https://example.com"}, []string{"GET", "POST"}, []string{"Content-Type"}, time.Minute)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("preflight must not reach the handler")
+		}))
+
+	req := httptest.NewRequest("OPTIONS", "/quotes", nil)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "https://example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST", rr.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "60", rr.Header().Get("Access-Control-Max-Age"))
+}
+
+func TestCorsMiddlewareRejectsUnknownOrigin(t *testing.T) {
+	handler := CorsMiddleware([]string{"https://example.com"}, nil, nil, time.Minute)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest("GET", "/quotes", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	handler := AuthMiddleware([]string{"secret"}, "/graphql")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		expectedCode  int
+	}{
+		{"read without key", "GET", "/quotes", "", http.StatusNoContent},
+		{"write without key", "POST", "/quotes", "", http.StatusUnauthorized},
+		{"write with wrong key", "DELETE", "/quotes", "Bearer wrong", http.StatusUnauthorized},
+		{"write with valid key", "POST", "/quotes", "Bearer secret", http.StatusNoContent},
+		{"self-authorized path without key", "POST", "/graphql", "", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+		})
+	}
+}
+
+func TestReadYourWritesMiddleware(t *testing.T) {
+	var readsPrimary bool
+	handler := ReadYourWritesMiddleware(time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		readsPrimary = drivers.UsePrimary(r.Context())
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/quotes", nil))
+
+	assert.True(t, readsPrimary)
+	cookies := rr.Result().Cookies()
+	require.Len(t, cookies, 1)
+
+	req := httptest.NewRequest("GET", "/quotes", nil)
+	req.AddCookie(cookies[0])
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.True(t, readsPrimary)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/quotes", nil))
+	assert.False(t, readsPrimary)
+}
+
+func TestReadYourWritesMiddlewareTreatsOnlyGraphqlMutationsAsWrites(t *testing.T) {
+	service := new(MockQuoteService)
+	quote := testQuoteDtos(1)[0]
+	readsReplica := func(ctx context.Context) bool { return !drivers.UsePrimary(ctx) }
+	service.On("GetQuoteById", mock.MatchedBy(readsReplica), *quote.Id).Return(&quote, nil)
+	service.On("DeleteQuote", mock.MatchedBy(drivers.UsePrimary), *quote.Id, int64(0)).Return(nil)
+	handler := ReadYourWritesMiddleware(time.Minute, GraphqlPath)(http.HandlerFunc(NewGraphqlController(service, nil).execute))
+
+	post := func(query string) *http.Response {
+		body, err := json.Marshal(map[string]any{"query": query, "variables": map[string]any{"id": quote.Id.String()}})
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("POST", GraphqlPath, bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, rr.Code)
+		return rr.Result()
+	}
+
+	assert.Empty(t, post(`query($id: ID!) { quote(id: $id) { id } }`).Cookies())
+	assert.Len(t, post(`mutation($id: ID!) { deleteQuote(id: $id) }`).Cookies(), 1)
+	service.AssertExpectations(t)
+}
@@ -0,0 +1,320 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"quotes/internal/dtos"
+	"quotes/internal/logging"
+)
+
+// responseFormat is a representation the quote endpoints can return.
+type responseFormat struct {
+	contentType string
+	// mediaTypes are matched against the Accept header, the first one being
+	// the canonical media type.
+	mediaTypes []string
+	// etagSuffix keeps entity tags of different representations apart.
+	etagSuffix string
+	// versioned formats render the body mapped by the API version.
+	versioned bool
+	render    func(w io.Writer, data any) error
+}
+
+// responseFormats are listed in order of preference for wildcard ranges.
+var responseFormats = []*responseFormat{
+	{
+		contentType: "application/json",
+		mediaTypes:  []string{"application/json"},
+		versioned:   true,
+		render: func(w io.Writer, data any) error {
+			return json.NewEncoder(w).Encode(data)
+		},
+	},
+	{
+		contentType: "text/plain; charset=utf-8",
+		mediaTypes:  []string{"text/plain"},
+		etagSuffix:  ".txt",
+		render:      renderText,
+	},
+	{
+		contentType: "text/csv; charset=utf-8",
+		mediaTypes:  []string{"text/csv"},
+		etagSuffix:  ".csv",
+		render:      renderCsv,
+	},
+	{
+		contentType: "application/yaml",
+		mediaTypes:  []string{"application/yaml", "application/x-yaml", "text/yaml"},
+		etagSuffix:  ".yaml",
+		render: func(w io.Writer, data any) error {
+			records, err := quoteRecords(data)
+			if err != nil {
+				return err
+			}
+			return yaml.NewEncoder(w).Encode(records)
+		},
+	},
+	{
+		contentType: "application/xml; charset=utf-8",
+		mediaTypes:  []string{"application/xml", "text/xml"},
+		etagSuffix:  ".xml",
+		render:      renderXml,
+	},
+}
+
+type formatKey struct{}
+
+// negotiated picks the response format from the Accept header before the
+// handler runs, so that unacceptable requests fail with 406 without side
+// effects. Problems are always sent as JSON.
+func negotiated(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+
+		format := negotiateFormat(r.Header.Get("Accept"))
+		if format == nil {
+			writeProblem(w, r, problemNotAcceptable, "Supported media types: "+supportedMediaTypes())
+			return
+		}
+
+		handler(w, r.WithContext(context.WithValue(r.Context(), formatKey{}, format)))
+	}
+}
+
+func formatFromContext(ctx context.Context) *responseFormat {
+	if format, ok := ctx.Value(formatKey{}).(*responseFormat); ok {
+		return format
+	}
+
+	return responseFormats[0]
+}
+
+// writeQuotesResponse writes quotes in the format negotiated for r and the
+// API version of its path.
+func writeQuotesResponse(w http.ResponseWriter, r *http.Request, data any, statusCode int) {
+	format := formatFromContext(r.Context())
+	if format.versioned {
+		data = versionFromContext(r.Context()).mapBody(data)
+	}
+
+	w.Header().Set("Content-Type", format.contentType)
+	w.WriteHeader(statusCode)
+
+	if err := format.render(w, data); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to encode response", "error", err)
+	}
+}
+
+// negotiateFormat returns the acceptable format with the highest quality,
+// or nil if there is none. A missing Accept header accepts JSON.
+func negotiateFormat(accept string) *responseFormat {
+	if strings.TrimSpace(accept) == "" {
+		return responseFormats[0]
+	}
+
+	ranges := parseAccept(accept)
+
+	var best *responseFormat
+	bestQuality := 0.0
+	for _, format := range responseFormats {
+		quality := 0.0
+		for _, mediaType := range format.mediaTypes {
+			quality = max(quality, acceptQuality(ranges, mediaType))
+		}
+
+		if quality > bestQuality {
+			best, bestQuality = format, quality
+		}
+	}
+
+	return best
+}
+
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil && parsed >= 0 && parsed <= 1 {
+				quality = parsed
+			}
+		}
+
+		ranges = append(ranges, mediaRange{mediaType: mediaType, quality: quality})
+	}
+
+	return ranges
+}
+
+// acceptQuality returns the quality of the most specific range matching
+// mediaType, so that "text/csv;q=0, */*" rejects CSV.
+func acceptQuality(ranges []mediaRange, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+
+	quality, specificity := 0.0, -1
+	for _, r := range ranges {
+		matched := -1
+		switch r.mediaType {
+		case mediaType:
+			matched = 2
+		case mainType + "/*":
+			matched = 1
+		case "*/*":
+			matched = 0
+		}
+
+		if matched > specificity {
+			quality, specificity = r.quality, matched
+		}
+	}
+
+	return quality
+}
+
+func supportedMediaTypes() string {
+	var mediaTypes []string
+	for _, format := range responseFormats {
+		mediaTypes = append(mediaTypes, format.mediaTypes[0])
+	}
+
+	return strings.Join(mediaTypes, ", ")
+}
+
+// quoteRecord is the representation of a quote in YAML and XML, which
+// cannot encode pgtype.UUID themselves.
+type quoteRecord struct {
+	XMLName xml.Name `xml:"quote" yaml:"-"`
+	Id      string   `xml:"id" yaml:"id"`
+	Author  string   `xml:"author" yaml:"author"`
+	Text    string   `xml:"text" yaml:"text"`
+	Tags    tagList  `xml:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// tagList encodes tags as <tags><tag>...</tag></tags>. Unlike the "tags>tag"
+// field tag, it leaves out the element when there are no tags.
+type tagList []string
+
+func (l tagList) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(struct {
+		Tags []string `xml:"tag"`
+	}{l}, start)
+}
+
+type quoteListRecord struct {
+	XMLName xml.Name      `xml:"quotes"`
+	Quotes  []quoteRecord `xml:"quote"`
+}
+
+func newQuoteRecord(quote *dtos.QuoteDto) quoteRecord {
+	var record quoteRecord
+	if quote.Id != nil {
+		record.Id = quote.Id.String()
+	}
+	if quote.Author != nil {
+		record.Author = *quote.Author
+	}
+	if quote.Text != nil {
+		record.Text = *quote.Text
+	}
+	record.Tags = quote.Tags
+
+	return record
+}
+
+// quoteRecords converts a quote or a list of quotes into records.
+func quoteRecords(data any) (any, error) {
+	switch data := data.(type) {
+	case *dtos.QuoteDto:
+		return newQuoteRecord(data), nil
+	case []dtos.QuoteDto:
+		records := make([]quoteRecord, len(data))
+		for i := range data {
+			records[i] = newQuoteRecord(&data[i])
+		}
+		return records, nil
+	default:
+		return nil, fmt.Errorf("cannot represent %T as quotes", data)
+	}
+}
+
+func recordList(data any) ([]quoteRecord, error) {
+	records, err := quoteRecords(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if record, ok := records.(quoteRecord); ok {
+		return []quoteRecord{record}, nil
+	}
+
+	return records.([]quoteRecord), nil
+}
+
+// renderText writes one "text — author" line per quote.
+func renderText(w io.Writer, data any) error {
+	records, err := recordList(data)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if _, err := fmt.Fprintf(w, "%s — %s\n", record.Text, record.Author); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func renderCsv(w io.Writer, data any) error {
+	records, err := recordList(data)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"id", "author", "text"})
+	for _, record := range records {
+		writer.Write([]string{record.Id, record.Author, record.Text})
+	}
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func renderXml(w io.Writer, data any) error {
+	records, err := quoteRecords(data)
+	if err != nil {
+		return err
+	}
+
+	if list, ok := records.([]quoteRecord); ok {
+		records = quoteListRecord{Quotes: list}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	return xml.NewEncoder(w).Encode(records)
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"quotes/internal/dtos"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	for _, test := range []struct {
+		accept   string
+		expected string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"application/json", "application/json"},
+		{"text/plain", "text/plain"},
+		{"text/*", "text/plain"},
+		{"text/csv, text/plain;q=0.5", "text/csv"},
+		{"text/csv;q=0.2, application/xml;q=0.8", "application/xml"},
+		{"text/x-yaml, application/x-yaml", "application/yaml"},
+		{"text/xml", "application/xml"},
+		{"application/json;q=0, */*", "text/plain"},
+		{"image/png", ""},
+		{"application/json;q=0", ""},
+	} {
+		t.Run(test.accept, func(t *testing.T) {
+			format := negotiateFormat(test.accept)
+			if test.expected == "" {
+				assert.Nil(t, format)
+				return
+			}
+			if assert.NotNil(t, format) {
+				assert.Equal(t, test.expected, format.mediaTypes[0])
+			}
+		})
+	}
+}
+
+func TestGetQuotesFormats(t *testing.T) {
+	mockService := &MockQuoteService{}
+	router := mux.NewRouter()
+	NewQuoteController(mockService, Deprecation{}).RegisterRoutes(router)
+
+	firstId := pgtype.UUID{Bytes: uuid.MustParse("11111111-1111-1111-1111-111111111111"), Valid: true}
+	secondId := pgtype.UUID{Bytes: uuid.MustParse("22222222-2222-2222-2222-222222222222"), Valid: true}
+	firstAuthor, firstText := "Author", "Text"
+	secondAuthor, secondText := "Other <author>", "Text, with \"quotes\""
+	quotes := []dtos.QuoteDto{
+		{Id: &firstId, Author: &firstAuthor, Text: &firstText, Version: 1},
+		{Id: &secondId, Author: &secondAuthor, Text: &secondText, Version: 2},
+	}
+
+	mockService.On("GetLastModified", mock.Anything).Return(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC), nil)
+	mockService.On("GetAllQuotes", mock.Anything).Return(quotes, nil)
+
+	for _, test := range []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{
+			"text/plain",
+			"text/plain; charset=utf-8",
+			"Text — Author\nText, with \"quotes\" — Other <author>\n",
+		},
+		{
+			"text/csv",
+			"text/csv; charset=utf-8",
+			"id,author,text\n" +
+				"11111111-1111-1111-1111-111111111111,Author,Text\n" +
+				"22222222-2222-2222-2222-222222222222,Other <author>,\"Text, with \"\"quotes\"\"\"\n",
+		},
+		{
+			"application/yaml",
+			"application/yaml",
+			"- id: 11111111-1111-1111-1111-111111111111\n" +
+				"  author: Author\n" +
+				"  text: Text\n" +
+				"- id: 22222222-2222-2222-2222-222222222222\n" +
+				"  author: Other <author>\n" +
+				"  text: Text, with \"quotes\"\n",
+		},
+		{
+			"application/xml",
+			"application/xml; charset=utf-8",
+			"<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<quotes>" +
+				"<quote><id>11111111-1111-1111-1111-111111111111</id><author>Author</author><text>Text</text></quote>" +
+				"<quote><id>22222222-2222-2222-2222-222222222222</id><author>Other &lt;author&gt;</author><text>Text, with &#34;quotes&#34;</text></quote>" +
+				"</quotes>",
+		},
+	} {
+		t.Run(test.accept, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/quotes", nil)
+			req.Header.Set("Accept", test.accept)
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, test.contentType, rr.Header().Get("Content-Type"))
+			assert.Equal(t, "Accept", rr.Header().Get("Vary"))
+			assert.Equal(t, test.body, rr.Body.String())
+		})
+	}
+
+	t.Run("representations have distinct entity tags", func(t *testing.T) {
+		etags := make(map[string]bool)
+		for _, accept := range []string{"application/json", "text/plain", "text/csv", "application/yaml", "application/xml"} {
+			req := httptest.NewRequest("GET", "/quotes", nil)
+			req.Header.Set("Accept", accept)
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			etags[rr.Header().Get("ETag")] = true
+		}
+		assert.Len(t, etags, 5)
+	})
+}
+
+func TestGetQuoteByIdText(t *testing.T) {
+	mockService := &MockQuoteService{}
+	router := mux.NewRouter()
+	NewQuoteController(mockService, Deprecation{}).RegisterRoutes(router)
+
+	idBytes := uuid.New()
+	id := pgtype.UUID{Bytes: idBytes, Valid: true}
+	author, text := "author", "text"
+	mockService.On("GetQuoteById", mock.Anything, id).
+		Return(&dtos.QuoteDto{Id: &id, Author: &author, Text: &text, Version: 4}, nil)
+
+	req := httptest.NewRequest("GET", "/quotes/"+idBytes.String(), nil)
+	req.Header.Set("Accept", "text/plain")
+	req.Header.Set("If-None-Match", `"4.txt"`)
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Equal(t, `"4.txt"`, rr.Header().Get("ETag"))
+
+	req.Header.Del("If-None-Match")
+	rr = httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text — author\n", rr.Body.String())
+}
+
+func TestNotAcceptable(t *testing.T) {
+	mockService := &MockQuoteService{}
+	router := mux.NewRouter()
+	NewQuoteController(mockService, Deprecation{}).RegisterRoutes(router)
+
+	req := httptest.NewRequest("POST", "/quotes", nil)
+	req.Header.Set("Accept", "image/png")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotAcceptable, rr.Code)
+	assert.Contains(t, rr.Body.String(), "text/csv")
+	mockService.AssertNotCalled(t, "CreateQuote", mock.Anything, mock.Anything)
+}
+
+func TestIfMatchVersionsWithFormatSuffix(t *testing.T) {
+	versions, anyVersion := ifMatchVersions(`"3.csv", "4"`)
+	assert.False(t, anyVersion)
+	assert.Equal(t, []int64{3, 4}, versions)
+}
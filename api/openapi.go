@@ -0,0 +1,519 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"quotes/internal/dtos"
+)
+
+// apiOperation documents one registered route in the OpenAPI document.
+type apiOperation struct {
+	method      string
+	path        string
+	operationId string
+	summary     string
+	tag         string
+	// modifying operations require an API key when authentication is enabled.
+	modifying bool
+	// versioned operations are served under every version prefix and, as
+	// deprecated aliases, without one.
+	versioned   bool
+	deprecated  bool
+	parameters  []any
+	requestBody any
+	responses   map[string]any
+}
+
+// schemaComponents maps DTOs to the names of their schemas in the document.
+var schemaComponents = map[reflect.Type]string{
+	reflect.TypeOf(dtos.QuoteDto{}):              "Quote",
+	reflect.TypeOf(dtos.ProblemDto{}):            "Problem",
+	reflect.TypeOf(dtos.FieldErrorDto{}):         "FieldError",
+	reflect.TypeOf(dtos.HealthDto{}):             "Health",
+	reflect.TypeOf(dtos.HealthCheckDto{}):        "HealthCheck",
+	reflect.TypeOf(dtos.WebhookDto{}):            "Webhook",
+	reflect.TypeOf(dtos.WebhookDeliveryDto{}):    "WebhookDelivery",
+	reflect.TypeOf(dtos.BatchRequestDto{}):       "BatchRequest",
+	reflect.TypeOf(dtos.BatchOperationDto{}):     "BatchOperation",
+	reflect.TypeOf(dtos.BatchResponseDto{}):      "BatchResponse",
+	reflect.TypeOf(dtos.BatchResultDto{}):        "BatchResult",
+	reflect.TypeOf(dtos.AuthorRenameDto{}):       "AuthorRename",
+	reflect.TypeOf(dtos.AuthorRenameResultDto{}): "AuthorRenameResult",
+}
+
+// apiOperations lists every route registered by the controllers except the
+// documentation itself.
+func apiOperations() []apiOperation {
+	quote := schemaRef(dtos.QuoteDto{})
+	quoteList := map[string]any{"type": "array", "items": quote}
+	idParameter := map[string]any{
+		"name": "id", "in": "path", "required": true,
+		"schema": map[string]any{"type": "string", "format": "uuid"},
+	}
+	ifMatchParameter := map[string]any{
+		"name": "If-Match", "in": "header",
+		"description": "Entity tags the quote must still have, e.g. \"3\".",
+		"schema":      map[string]any{"type": "string"},
+	}
+	ifNoneMatchParameter := map[string]any{
+		"name": "If-None-Match", "in": "header",
+		"description": "Entity tags the client already has.",
+		"schema":      map[string]any{"type": "string"},
+	}
+	quoteBody := map[string]any{
+		"required": true,
+		"content":  map[string]any{"application/json": map[string]any{"schema": quote}},
+	}
+	webhook := schemaRef(dtos.WebhookDto{})
+	webhookBody := map[string]any{
+		"required": true,
+		"content":  map[string]any{"application/json": map[string]any{"schema": webhook}},
+	}
+
+	return []apiOperation{
+		{
+			method: http.MethodGet, path: "/quotes", operationId: "listQuotes", tag: "quotes", versioned: true,
+			summary: "List all quotes or the quotes of an author",
+			parameters: []any{
+				map[string]any{"name": "author", "in": "query", "schema": map[string]any{"type": "string"}},
+				ifNoneMatchParameter,
+			},
+			responses: withProblems(map[string]any{
+				"200": quoteResponse("Quotes", quoteList),
+				"304": map[string]any{"description": "The client's copy is current"},
+			}, problemNotAcceptable, problemInternal, problemUnavailable),
+		},
+		{
+			method: http.MethodPost, path: "/quotes", operationId: "createQuote", tag: "quotes", versioned: true,
+			summary: "Create a quote", modifying: true, requestBody: quoteBody,
+			responses: withProblems(map[string]any{
+				"201": quoteResponse("Created quote", quote),
+			}, problemValidation, problemUnauthorized, problemNotAcceptable, problemBodyTooLarge, problemInternal, problemUnavailable),
+		},
+		{
+			method: http.MethodGet, path: "/quotes/random", operationId: "getRandomQuote", tag: "quotes", versioned: true,
+			summary: "Get a random quote",
+			responses: withProblems(map[string]any{
+				"200": quoteResponse("Random quote", quote),
+			}, problemNotAcceptable, problemInternal, problemUnavailable),
+		},
+		{
+			method: http.MethodGet, path: "/quotes/stream", operationId: "streamQuoteChanges", tag: "quotes", versioned: true,
+			summary: "Stream created, updated and deleted quotes as server-sent events, when the database is PostgreSQL",
+			parameters: []any{map[string]any{
+				"name": "Last-Event-ID", "in": "header",
+				"description": "ID of the last event received, to resume after it.",
+				"schema":      map[string]any{"type": "string"},
+			}},
+			responses: map[string]any{
+				"200": map[string]any{
+					"description": "Events named created, updated, deleted or resync; created and updated carry the quote, deleted its ID",
+					"content":     map[string]any{"text/event-stream": map[string]any{"schema": map[string]any{"type": "string"}}},
+				},
+			},
+		},
+		{
+			method: http.MethodGet, path: RotationPath, operationId: "rotateRandomQuotes", tag: "quotes",
+			summary: "Receive random quotes over a WebSocket every interval, optionally of an author or with a tag",
+			parameters: []any{
+				map[string]any{"name": "author", "in": "query", "schema": map[string]any{"type": "string"}},
+				map[string]any{"name": "tag", "in": "query", "schema": map[string]any{"type": "string"}},
+				map[string]any{
+					"name": "interval", "in": "query",
+					"description": "Duration between quotes, e.g. 30s.",
+					"schema":      map[string]any{"type": "string"},
+				},
+			},
+			responses: withProblems(map[string]any{
+				"101": map[string]any{"description": "Switched to the WebSocket protocol"},
+			}, problemValidation, problemWebsocketRequired, problemForbiddenOrigin, problemTooManyRequests, problemUnavailable),
+		},
+		{
+			method: http.MethodPost, path: "/quotes/batch", operationId: "applyQuoteBatch", tag: "quotes", versioned: true,
+			summary:   "Create, update and delete quotes in one transaction, or each on its own if atomic is false",
+			modifying: true,
+			requestBody: map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": schemaRef(dtos.BatchRequestDto{})}},
+			},
+			responses: withProblems(map[string]any{
+				"200": jsonResponse("Result of every operation", schemaRef(dtos.BatchResponseDto{})),
+			}, problemInvalidJson, problemValidation, problemUnauthorized, problemNotFound, problemPreconditionFailed,
+				problemBodyTooLarge, problemInternal, problemUnavailable),
+		},
+		{
+			method: http.MethodGet, path: "/quotes/{id}", operationId: "getQuote", tag: "quotes", versioned: true,
+			summary:    "Get a quote by ID",
+			parameters: []any{idParameter, ifNoneMatchParameter},
+			responses: withProblems(map[string]any{
+				"200": quoteResponse("Quote", quote),
+				"304": map[string]any{"description": "The client's copy is current"},
+			}, problemInvalidId, problemNotFound, problemNotAcceptable, problemInternal, problemUnavailable),
+		},
+		{
+			method: http.MethodPut, path: "/quotes/{id}", operationId: "updateQuote", tag: "quotes", versioned: true,
+			summary: "Replace the author, text and tags of a quote", modifying: true,
+			parameters:  []any{idParameter, ifMatchParameter},
+			requestBody: quoteBody,
+			responses: withProblems(map[string]any{
+				"200": quoteResponse("Updated quote", quote),
+			}, problemValidation, problemUnauthorized, problemNotFound, problemNotAcceptable, problemPreconditionFailed, problemBodyTooLarge, problemInternal, problemUnavailable),
+		},
+		{
+			method: http.MethodDelete, path: "/quotes/{id}", operationId: "deleteQuote", tag: "quotes", versioned: true,
+			summary: "Delete a quote", modifying: true,
+			parameters: []any{idParameter, ifMatchParameter},
+			responses: withProblems(map[string]any{
+				"204": map[string]any{"description": "Quote deleted"},
+			}, problemInvalidId, problemUnauthorized, problemPreconditionFailed, problemInternal, problemUnavailable),
+		},
+		{
+			method: http.MethodPost, path: apiV1.prefix + "/authors/rename", operationId: "renameAuthors", tag: "authors",
+			summary:   "Rename an author, or merge several authors into one, across all quotes in one transaction",
+			modifying: true,
+			requestBody: map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": schemaRef(dtos.AuthorRenameDto{})}},
+			},
+			responses: withProblems(map[string]any{
+				"200": jsonResponse("Number of quotes renamed, or to be renamed by a dry run", schemaRef(dtos.AuthorRenameResultDto{})),
+			}, problemInvalidJson, problemValidation, problemUnauthorized, problemPreconditionFailed, problemBodyTooLarge,
+				problemInternal, problemUnavailable),
+		},
+		{
+			method: http.MethodGet, path: apiV1.prefix + "/webhooks", operationId: "listWebhooks", tag: "webhooks",
+			summary: "List the webhook subscriptions", modifying: true,
+			responses: withProblems(map[string]any{
+				"200": jsonResponse("Subscriptions", map[string]any{"type": "array", "items": webhook}),
+			}, problemUnauthorized, problemInternal, problemUnavailable),
+		},
+		{
+			method: http.MethodPost, path: apiV1.prefix + "/webhooks", operationId: "createWebhook", tag: "webhooks",
+			summary:   "Subscribe a URL to quote events; the response holds the signing secret, which is not shown again",
+			modifying: true, requestBody: webhookBody,
+			responses: withProblems(map[string]any{
+				"201": jsonResponse("Subscription created", webhook),
+			}, problemInvalidJson, problemValidation, problemUnauthorized, problemBodyTooLarge, problemInternal, problemUnavailable),
+		},
+		{
+			method: http.MethodGet, path: apiV1.prefix + "/webhooks/{id}", operationId: "getWebhook", tag: "webhooks",
+			summary: "Get a webhook subscription", modifying: true,
+			parameters: []any{idParameter},
+			responses: withProblems(map[string]any{
+				"200": jsonResponse("Subscription", webhook),
+			}, problemInvalidId, problemUnauthorized, problemNotFound, problemInternal, problemUnavailable),
+		},
+		{
+			method: http.MethodPut, path: apiV1.prefix + "/webhooks/{id}", operationId: "updateWebhook", tag: "webhooks",
+			summary:   "Replace the URL and events of a webhook subscription, and its secret if one is given",
+			modifying: true, parameters: []any{idParameter}, requestBody: webhookBody,
+			responses: withProblems(map[string]any{
+				"200": jsonResponse("Subscription updated", webhook),
+			}, problemInvalidJson, problemValidation, problemInvalidId, problemUnauthorized, problemNotFound,
+				problemBodyTooLarge, problemInternal, problemUnavailable),
+		},
+		{
+			method: http.MethodDelete, path: apiV1.prefix + "/webhooks/{id}", operationId: "deleteWebhook", tag: "webhooks",
+			summary: "Delete a webhook subscription and its deliveries", modifying: true,
+			parameters: []any{idParameter},
+			responses: withProblems(map[string]any{
+				"204": map[string]any{"description": "Subscription deleted"},
+			}, problemInvalidId, problemUnauthorized, problemNotFound, problemInternal, problemUnavailable),
+		},
+		{
+			method: http.MethodGet, path: apiV1.prefix + "/webhooks/{id}/deliveries", operationId: "listWebhookDeliveries", tag: "webhooks",
+			summary: "List the latest deliveries to a webhook subscription; status=dead lists the dead letters", modifying: true,
+			parameters: []any{
+				idParameter,
+				map[string]any{"name": "status", "in": "query", "schema": map[string]any{
+					"type": "string", "enum": []string{"pending", "succeeded", "dead"},
+				}},
+				map[string]any{"name": "limit", "in": "query", "schema": map[string]any{
+					"type": "integer", "minimum": 1, "maximum": maxDeliveriesLimit, "default": defaultDeliveriesLimit,
+				}},
+			},
+			responses: withProblems(map[string]any{
+				"200": jsonResponse("Deliveries, newest first", map[string]any{"type": "array", "items": schemaRef(dtos.WebhookDeliveryDto{})}),
+			}, problemValidation, problemInvalidId, problemUnauthorized, problemNotFound, problemInternal, problemUnavailable),
+		},
+		{
+			method: http.MethodPost, path: apiV1.prefix + "/webhooks/{id}/deliveries/{deliveryId}/retry", operationId: "retryWebhookDelivery", tag: "webhooks",
+			summary: "Schedule a dead-lettered delivery again with its attempts reset", modifying: true,
+			parameters: []any{
+				idParameter,
+				map[string]any{"name": "deliveryId", "in": "path", "required": true, "schema": map[string]any{"type": "integer"}},
+			},
+			responses: withProblems(map[string]any{
+				"202": map[string]any{"description": "Delivery scheduled"},
+			}, problemInvalidId, problemUnauthorized, problemNotFound, problemInternal, problemUnavailable),
+		},
+		{
+			method: http.MethodPost, path: GraphqlPath, operationId: "executeGraphql", tag: "graphql",
+			summary: "Execute a GraphQL query or mutation; mutations require an API key when authentication is enabled",
+			requestBody: map[string]any{
+				"required": true,
+				"content": map[string]any{"application/json": map[string]any{"schema": map[string]any{
+					"type":     "object",
+					"required": []string{"query"},
+					"properties": map[string]any{
+						"query":         map[string]any{"type": "string"},
+						"operationName": map[string]any{"type": "string"},
+						"variables":     map[string]any{"type": "object"},
+					},
+				}}},
+			},
+			responses: withProblems(map[string]any{
+				"200": jsonResponse("Result with data and errors", map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"data":   map[string]any{"type": "object"},
+						"errors": map[string]any{"type": "array", "items": map[string]any{"type": "object"}},
+					},
+				}),
+			}, problemInvalidJson, problemBodyTooLarge),
+		},
+		{
+			method: http.MethodGet, path: "/healthz", operationId: "getHealth", tag: "health",
+			summary: "Check that the process is alive",
+			responses: map[string]any{
+				"200": jsonResponse("Process is alive", schemaRef(dtos.HealthDto{})),
+			},
+		},
+		{
+			method: http.MethodGet, path: "/readyz", operationId: "getReadiness", tag: "health",
+			summary: "Check that the database is reachable and migrated",
+			responses: map[string]any{
+				"200": jsonResponse("Ready to serve requests", schemaRef(dtos.HealthDto{})),
+				"503": jsonResponse("Not ready", schemaRef(dtos.HealthDto{})),
+			},
+		},
+		{
+			method: http.MethodGet, path: "/metrics", operationId: "getMetrics", tag: "health",
+			summary: "Cache metrics in the Prometheus text format, when the cache is enabled",
+			responses: map[string]any{
+				"200": map[string]any{
+					"description": "Metrics",
+					"content":     map[string]any{"text/plain": map[string]any{"schema": map[string]any{"type": "string"}}},
+				},
+			},
+		},
+	}
+}
+
+// withVersions expands versioned operations into one operation per version
+// prefix and a deprecated legacy alias.
+func withVersions(operations []apiOperation) []apiOperation {
+	var expanded []apiOperation
+	for _, op := range operations {
+		if !op.versioned {
+			expanded = append(expanded, op)
+			continue
+		}
+
+		versioned := op
+		versioned.path = apiV1.prefix + op.path
+		legacy := op
+		legacy.operationId += "Legacy"
+		legacy.deprecated = true
+		expanded = append(expanded, versioned, legacy)
+	}
+
+	return expanded
+}
+
+// openApiDocument builds the OpenAPI 3.1 document of the service.
+func openApiDocument() map[string]any {
+	paths := make(map[string]any)
+	for _, op := range withVersions(apiOperations()) {
+		operation := map[string]any{
+			"operationId": op.operationId,
+			"summary":     op.summary,
+			"tags":        []string{op.tag},
+			"responses":   op.responses,
+		}
+		if op.deprecated {
+			operation["deprecated"] = true
+			operation["description"] = "Deprecated alias of " + apiV1.prefix + op.path + ", answered with Deprecation and Sunset headers."
+		}
+		if op.parameters != nil {
+			operation["parameters"] = op.parameters
+		}
+		if op.requestBody != nil {
+			operation["requestBody"] = op.requestBody
+		}
+		if op.modifying {
+			operation["security"] = []any{map[string]any{}, map[string]any{"bearerAuth": []string{}}}
+		}
+
+		item, ok := paths[op.path].(map[string]any)
+		if !ok {
+			item = make(map[string]any)
+			paths[op.path] = item
+		}
+		item[strings.ToLower(op.method)] = operation
+	}
+
+	schemas := make(map[string]any)
+	for dto, name := range schemaComponents {
+		schemas[name] = objectSchema(dto)
+	}
+
+	// The ID is assigned by the service and ignored in request bodies.
+	quoteSchema := schemas["Quote"].(map[string]any)
+	quoteSchema["properties"].(map[string]any)["id"].(map[string]any)["readOnly"] = true
+	quoteSchema["required"] = []string{"author", "text"}
+	webhookSchema := schemas["Webhook"].(map[string]any)
+	for _, name := range []string{"id", "created_at", "updated_at"} {
+		webhookSchema["properties"].(map[string]any)[name].(map[string]any)["readOnly"] = true
+	}
+	webhookSchema["required"] = []string{"url", "events"}
+	operationSchema := schemas["BatchOperation"].(map[string]any)
+	operationSchema["properties"].(map[string]any)["op"].(map[string]any)["enum"] = []string{"create", "update", "delete"}
+	schemas["BatchRequest"].(map[string]any)["properties"].(map[string]any)["atomic"].(map[string]any)["default"] = true
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "Quotes",
+			"version": "1.0.0",
+			"description": "Stores quotes and their authors. Quote responses are negotiated with the " +
+				"Accept header, errors are RFC 7807 problem details.",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// quoteResponse describes a response in every negotiable format, with
+// validators for conditional requests.
+func quoteResponse(description string, schema any) map[string]any {
+	content := make(map[string]any)
+	for _, format := range responseFormats {
+		mediaSchema := schema
+		if strings.HasPrefix(format.mediaTypes[0], "text/") {
+			mediaSchema = map[string]any{"type": "string"}
+		}
+		content[format.mediaTypes[0]] = map[string]any{"schema": mediaSchema}
+	}
+
+	return map[string]any{
+		"description": description,
+		"headers": map[string]any{
+			"ETag":          map[string]any{"schema": map[string]any{"type": "string"}},
+			"Last-Modified": map[string]any{"schema": map[string]any{"type": "string"}},
+		},
+		"content": content,
+	}
+}
+
+func jsonResponse(description string, schema any) map[string]any {
+	return map[string]any{
+		"description": description,
+		"content":     map[string]any{"application/json": map[string]any{"schema": schema}},
+	}
+}
+
+// withProblems adds problem responses to responses. Problems sharing a
+// status are documented together.
+func withProblems(responses map[string]any, problems ...problemType) map[string]any {
+	for _, problem := range problems {
+		code := strconv.Itoa(problem.status)
+		if existing, ok := responses[code].(map[string]any); ok {
+			existing["description"] = existing["description"].(string) + "; " + problem.title
+			continue
+		}
+
+		responses[code] = map[string]any{
+			"description": problem.title,
+			"content": map[string]any{
+				problemContentType: map[string]any{"schema": schemaRef(dtos.ProblemDto{})},
+			},
+		}
+	}
+
+	return responses
+}
+
+func schemaRef(dto any) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + schemaComponents[reflect.TypeOf(dto)]}
+}
+
+var (
+	uuidType = reflect.TypeOf(pgtype.UUID{})
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+// objectSchema derives the schema of a DTO from its JSON encoding. Fields
+// without omitempty are required.
+func objectSchema(dto reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+
+	for i := range dto.NumField() {
+		field := dto.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = typeSchema(field.Type)
+		if !strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if required != nil {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+func typeSchema(t reflect.Type) map[string]any {
+	if name, ok := schemaComponents[t]; ok {
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+
+	switch t {
+	case uuidType:
+		return map[string]any{"type": "string", "format": "uuid"}
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		return objectSchema(t)
+	default:
+		return map[string]any{}
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"quotes/internal/dtos"
+	"quotes/internal/logging"
+)
+
+const problemContentType = "application/problem+json"
+
+// problemType is a kind of error response. Its URI is the "type" member
+// clients use to tell problems apart, the title stays the same for every
+// occurrence.
+type problemType struct {
+	uri    string
+	title  string
+	status int
+}
+
+var (
+	problemInvalidJson        = problemType{"/problems/invalid-json", "Request body is not valid JSON", http.StatusBadRequest}
+	problemValidation         = problemType{"/problems/validation-error", "Request body failed validation", http.StatusBadRequest}
+	problemInvalidId          = problemType{"/problems/invalid-id", "Resource ID is not valid", http.StatusBadRequest}
+	problemUnauthorized       = problemType{"/problems/unauthorized", "Valid API key is required", http.StatusUnauthorized}
+	problemForbiddenOrigin    = problemType{"/problems/forbidden-origin", "Origin is not allowed", http.StatusForbidden}
+	problemNotFound           = problemType{"/problems/not-found", "Resource not found", http.StatusNotFound}
+	problemMethodNotAllowed   = problemType{"/problems/method-not-allowed", "Method not allowed", http.StatusMethodNotAllowed}
+	problemNotAcceptable      = problemType{"/problems/not-acceptable", "Requested media type is not supported", http.StatusNotAcceptable}
+	problemBodyTooLarge       = problemType{"/problems/body-too-large", "Request body is too large", http.StatusRequestEntityTooLarge}
+	problemPreconditionFailed = problemType{"/problems/precondition-failed", "Quote has been modified", http.StatusPreconditionFailed}
+	problemWebsocketRequired  = problemType{"/problems/websocket-required", "WebSocket handshake is required", http.StatusBadRequest}
+	problemTooManyRequests    = problemType{"/problems/too-many-requests", "Too many requests", http.StatusTooManyRequests}
+	problemInternal           = problemType{"/problems/internal-error", "Internal server error", http.StatusInternalServerError}
+	problemUnavailable        = problemType{"/problems/service-unavailable", "Service temporarily unavailable", http.StatusServiceUnavailable}
+)
+
+// writeProblem answers with an application/problem+json body of the given
+// type. The request ID becomes the instance.
+func writeProblem(w http.ResponseWriter, r *http.Request, problem problemType, detail string, fieldErrors ...dtos.FieldErrorDto) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.status)
+
+	json.NewEncoder(w).Encode(newProblemDto(r, problem, detail, fieldErrors...))
+}
+
+func newProblemDto(r *http.Request, problem problemType, detail string, fieldErrors ...dtos.FieldErrorDto) dtos.ProblemDto {
+	return dtos.ProblemDto{
+		Type:     problem.uri,
+		Title:    problem.title,
+		Status:   problem.status,
+		Detail:   detail,
+		Instance: logging.RequestIdFromContext(r.Context()),
+		Errors:   fieldErrors,
+	}
+}
+
+// NotFound answers requests to unknown paths with a problem.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, problemNotFound, "No resource at "+r.URL.Path)
+}
+
+// MethodNotAllowed answers requests with a method the path does not support
+// with a problem.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, problemMethodNotAllowed, r.Method+" is not supported for "+r.URL.Path)
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/dtos"
+	"quotes/internal/logging"
+	"quotes/internal/services"
+)
+
+func decodeProblem(t *testing.T, rr *httptest.ResponseRecorder) dtos.ProblemDto {
+	t.Helper()
+
+	assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+
+	var problem dtos.ProblemDto
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+	assert.Equal(t, rr.Code, problem.Status)
+
+	return problem
+}
+
+func TestCreateQuoteValidationProblem(t *testing.T) {
+	mockService := &MockQuoteService{}
+	controller := NewQuoteController(mockService, Deprecation{})
+
+	fieldErrors := []dtos.FieldErrorDto{
+		{Field: "author", Code: "required", Message: "Author is required"},
+		{Field: "text", Code: "too_long", Message: "Text must be at most 2000 characters"},
+	}
+	mockService.On("CreateQuote", mock.Anything, mock.Anything).Return(nil, &services.ValidationError{Fields: fieldErrors})
+
+	req := httptest.NewRequest("POST", "/quotes", bytes.NewBufferString(`{"author": " "}`))
+	req = req.WithContext(logging.WithRequestId(req.Context(), "request-1"))
+	rr := httptest.NewRecorder()
+
+	controller.createQuote(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	problem := decodeProblem(t, rr)
+	assert.Equal(t, "/problems/validation-error", problem.Type)
+	assert.Equal(t, "request-1", problem.Instance)
+	assert.Equal(t, fieldErrors, problem.Errors)
+	mockService.AssertExpectations(t)
+}
+
+func TestDecodeBodyProblems(t *testing.T) {
+	mockService := &MockQuoteService{}
+	controller := NewQuoteController(mockService, Deprecation{})
+	handler := BodyLimitMiddleware(64)(http.HandlerFunc(controller.createQuote))
+
+	for _, test := range []struct {
+		name          string
+		body          string
+		expectedCode  int
+		expectedType  string
+		expectedField dtos.FieldErrorDto
+	}{
+		{"malformed", `{"author": "a"`, http.StatusBadRequest, "/problems/invalid-json", dtos.FieldErrorDto{}},
+		{"trailing data", `{"author": "a"} {}`, http.StatusBadRequest, "/problems/invalid-json", dtos.FieldErrorDto{}},
+		{
+			"unknown field", `{"author": "a", "text": "t", "source": "s"}`,
+			http.StatusBadRequest, "/problems/validation-error",
+			dtos.FieldErrorDto{Field: "source", Code: "unknown", Message: "Unknown field source"},
+		},
+		{
+			"wrong type", `{"author": 42}`,
+			http.StatusBadRequest, "/problems/validation-error",
+			dtos.FieldErrorDto{Field: "author", Code: "invalid_type", Message: "author must be a string"},
+		},
+		{"too large", `{"author": "` + strings.Repeat("a", 100) + `"}`, http.StatusRequestEntityTooLarge, "/problems/body-too-large", dtos.FieldErrorDto{}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/quotes", bytes.NewBufferString(test.body))
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedCode, rr.Code)
+			problem := decodeProblem(t, rr)
+			assert.Equal(t, test.expectedType, problem.Type)
+			if test.expectedField.Field != "" {
+				assert.Equal(t, []dtos.FieldErrorDto{test.expectedField}, problem.Errors)
+			}
+		})
+	}
+
+	mockService.AssertNotCalled(t, "CreateQuote", mock.Anything, mock.Anything)
+}
+
+func TestProblemTypes(t *testing.T) {
+	mockService := &MockQuoteService{}
+	router := mux.NewRouter()
+	router.NotFoundHandler = http.HandlerFunc(NotFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(MethodNotAllowed)
+	NewQuoteController(mockService, Deprecation{}).RegisterRoutes(router)
+
+	missingId := uuid.New()
+	mockService.On("GetQuoteById", mock.Anything, pgtype.UUID{Bytes: missingId, Valid: true}).Return(nil, pgx.ErrNoRows)
+
+	for _, test := range []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		expectedCode int
+		expectedType string
+	}{
+		{"invalid JSON", "POST", "/quotes", "{", http.StatusBadRequest, "/problems/invalid-json"},
+		{"invalid ID", "GET", "/quotes/nope", "", http.StatusBadRequest, "/problems/invalid-id"},
+		{"missing quote", "GET", "/quotes/" + missingId.String(), "", http.StatusNotFound, "/problems/not-found"},
+		{"unknown path", "GET", "/authors", "", http.StatusNotFound, "/problems/not-found"},
+		{"unsupported method", "PATCH", "/quotes", "", http.StatusMethodNotAllowed, "/problems/method-not-allowed"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.path, bytes.NewBufferString(test.body))
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedCode, rr.Code)
+			problem := decodeProblem(t, rr)
+			assert.Equal(t, test.expectedType, problem.Type)
+			assert.NotEmpty(t, problem.Title)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"quotes/internal/drivers"
+	"quotes/internal/dtos"
+	"quotes/internal/logging"
+	"quotes/internal/services"
+)
+
+// applyBatch applies the create, update and delete operations of the body,
+// all or nothing unless "atomic" is false. It answers 200 with the result
+// of every operation; a failed atomic batch is answered like the failing
+// operation would have been on its own.
+func (c *QuoteController) applyBatch(w http.ResponseWriter, r *http.Request) {
+	var batch dtos.BatchRequestDto
+	if !decodeBody(w, r, &batch) {
+		return
+	}
+	atomic := batch.Atomic == nil || *batch.Atomic
+
+	results, err := c.service.ApplyBatch(r.Context(), batch.Operations, atomic)
+	var batchErr *services.BatchError
+	if errors.As(err, &batchErr) {
+		writeBatchError(w, r, batchErr)
+		return
+	}
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to apply batch")
+		return
+	}
+
+	response := dtos.BatchResponseDto{Atomic: atomic, Results: make([]dtos.BatchResultDto, len(results))}
+	for i, result := range results {
+		response.Results[i] = newBatchResultDto(r, batch.Operations[i].Op, result)
+	}
+
+	writeJSONResponse(w, response, http.StatusOK)
+}
+
+// writeBatchError answers an atomic batch rolled back by a failed operation,
+// naming the operation in the detail or in the rejected fields.
+func writeBatchError(w http.ResponseWriter, r *http.Request, batchErr *services.BatchError) {
+	operation := fmt.Sprintf("operations[%d]", batchErr.Index)
+
+	var validationErr *services.ValidationError
+	var conflictErr *drivers.VersionConflictError
+	switch {
+	case errors.As(batchErr.Err, &validationErr):
+		fields := make([]dtos.FieldErrorDto, len(validationErr.Fields))
+		for i, field := range validationErr.Fields {
+			field.Field = operation + ".quote." + field.Field
+			fields[i] = field
+		}
+		writeProblem(w, r, problemValidation, "No operation was applied", fields...)
+	case errors.As(batchErr.Err, &conflictErr):
+		writeProblem(w, r, problemPreconditionFailed, "The quote of "+operation+" is not at the given version; no operation was applied")
+	case errors.Is(batchErr.Err, pgx.ErrNoRows):
+		writeProblem(w, r, problemNotFound, "The quote of "+operation+" was not found; no operation was applied")
+	default:
+		writeServiceError(w, r, batchErr, "Failed to apply batch")
+	}
+}
+
+// newBatchResultDto describes the outcome of an operation with the status
+// and body of the corresponding single request.
+func newBatchResultDto(r *http.Request, op string, result services.BatchResult) dtos.BatchResultDto {
+	if result.Err == nil {
+		switch op {
+		case services.BatchCreate:
+			return dtos.BatchResultDto{Status: http.StatusCreated, Quote: result.Quote, Version: result.Quote.Version}
+		case services.BatchUpdate:
+			return dtos.BatchResultDto{Status: http.StatusOK, Quote: result.Quote, Version: result.Quote.Version}
+		default:
+			return dtos.BatchResultDto{Status: http.StatusNoContent}
+		}
+	}
+
+	var problem dtos.ProblemDto
+	var validationErr *services.ValidationError
+	var conflictErr *drivers.VersionConflictError
+	var openErr *drivers.CircuitOpenError
+	switch {
+	case errors.As(result.Err, &validationErr):
+		problem = newProblemDto(r, problemValidation, "", validationErr.Fields...)
+	case errors.As(result.Err, &conflictErr):
+		problem = newProblemDto(r, problemPreconditionFailed, "")
+	case errors.Is(result.Err, pgx.ErrNoRows):
+		problem = newProblemDto(r, problemNotFound, "Quote not found")
+	case errors.As(result.Err, &openErr):
+		problem = newProblemDto(r, problemUnavailable, "The database is unavailable")
+	default:
+		logging.FromContext(r.Context()).Error("Failed to apply batch operation", "error", result.Err)
+		problem = newProblemDto(r, problemInternal, "Failed to apply operation")
+	}
+
+	return dtos.BatchResultDto{Status: problem.Status, Error: &problem}
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/drivers"
+	"quotes/internal/dtos"
+	"quotes/internal/services"
+)
+
+func TestApplyBatchReportsEveryOperation(t *testing.T) {
+	mockService := &MockQuoteService{}
+	controller := NewQuoteController(mockService, Deprecation{})
+
+	id := pgtype.UUID{Bytes: uuid.New(), Valid: true}
+	author, text := "author", "text"
+	created := dtos.QuoteDto{Id: &id, Author: &author, Text: &text, Tags: []string{}, Version: 1}
+	mockService.On("ApplyBatch", mock.Anything, mock.Anything, false).Return([]services.BatchResult{
+		{Quote: &created},
+		{Err: &drivers.VersionConflictError{Id: id, Version: 3}},
+		{Err: pgx.ErrNoRows},
+		{},
+	}, nil)
+
+	req := httptest.NewRequest("POST", "/v1/quotes/batch", strings.NewReader(`{"atomic": false, "operations": [
+		{"op": "create", "quote": {"author": "author", "text": "text"}},
+		{"op": "update", "id": "`+id.String()+`", "version": 3, "quote": {"author": "author", "text": "text"}},
+		{"op": "delete", "id": "`+uuid.NewString()+`"},
+		{"op": "delete", "id": "`+id.String()+`"}
+	]}`))
+	rr := httptest.NewRecorder()
+
+	controller.applyBatch(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var response dtos.BatchResponseDto
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.False(t, response.Atomic)
+	require.Len(t, response.Results, 4)
+	assert.Equal(t, http.StatusCreated, response.Results[0].Status)
+	assert.Equal(t, "text", *response.Results[0].Quote.Text)
+	assert.Equal(t, int64(1), response.Results[0].Version)
+	assert.Equal(t, http.StatusPreconditionFailed, response.Results[1].Status)
+	assert.Equal(t, "/problems/precondition-failed", response.Results[1].Error.Type)
+	assert.Equal(t, http.StatusNotFound, response.Results[2].Status)
+	assert.Equal(t, dtos.BatchResultDto{Status: http.StatusNoContent}, response.Results[3])
+
+	operations := mockService.Calls[0].Arguments.Get(1).([]dtos.BatchOperationDto)
+	assert.Equal(t, int64(3), operations[1].Version)
+	assert.Equal(t, id, *operations[1].Id)
+}
+
+func TestApplyBatchIsAtomicByDefault(t *testing.T) {
+	mockService := &MockQuoteService{}
+	controller := NewQuoteController(mockService, Deprecation{})
+
+	mockService.On("ApplyBatch", mock.Anything, mock.Anything, true).Return(nil, &services.BatchError{
+		Index: 1,
+		Err: &services.ValidationError{Fields: []dtos.FieldErrorDto{
+			{Field: "text", Code: "required", Message: "Text is required"},
+		}},
+	})
+
+	req := httptest.NewRequest("POST", "/v1/quotes/batch", strings.NewReader(`{"operations": [
+		{"op": "create", "quote": {"author": "author", "text": "text"}},
+		{"op": "create", "quote": {"author": "author"}}
+	]}`))
+	rr := httptest.NewRecorder()
+
+	controller.applyBatch(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	var problem dtos.ProblemDto
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+	assert.Equal(t, "operations[1].quote.text", problem.Errors[0].Field)
+	mockService.AssertExpectations(t)
+}
+
+func TestApplyBatchNamesMissingQuote(t *testing.T) {
+	mockService := &MockQuoteService{}
+	controller := NewQuoteController(mockService, Deprecation{})
+	mockService.On("ApplyBatch", mock.Anything, mock.Anything, true).Return(nil, &services.BatchError{Index: 0, Err: pgx.ErrNoRows})
+
+	req := httptest.NewRequest("POST", "/v1/quotes/batch",
+		strings.NewReader(`{"atomic": true, "operations": [{"op": "delete", "id": "`+uuid.NewString()+`"}]}`))
+	rr := httptest.NewRecorder()
+
+	controller.applyBatch(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "operations[0]")
+}
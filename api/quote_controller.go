@@ -1,71 +1,118 @@
 package api
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"quotes/internal/dtos"
 	"quotes/internal/services"
 )
 
 type QuoteController struct {
-	service services.QuoteServiceInterface
+	service     services.QuoteServiceInterface
+	deprecation Deprecation
+	// events is set when quote changes are streamed.
+	events            *services.QuoteEventBroker
+	heartbeatInterval time.Duration
 }
 
-func NewQuoteController(service services.QuoteServiceInterface) *QuoteController {
-	return &QuoteController{service: service}
+// NewQuoteController creates a controller serving the quote routes under
+// every API version prefix and, announcing deprecation, without one.
+func NewQuoteController(service services.QuoteServiceInterface, deprecation Deprecation) *QuoteController {
+	return &QuoteController{service: service, deprecation: deprecation}
 }
 
 func (c *QuoteController) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/quotes", c.handleQuotes).Methods("GET", "POST")
-	router.HandleFunc("/quotes/random", c.getRandomQuote).Methods("GET")
-	router.HandleFunc("/quotes/{id}", c.deleteQuote).Methods("DELETE")
+	c.registerVersion(router, apiV1, apiV1.prefix)
+	c.registerVersion(router, legacyVersion, "")
 }
 
-func (c *QuoteController) handleQuotes(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case "GET":
-		c.getQuotes(w, r)
-	case "POST":
-		c.createQuote(w, r)
+func (c *QuoteController) registerVersion(router *mux.Router, version *apiVersion, prefix string) {
+	handle := func(path, method string, handler http.HandlerFunc) {
+		handler = withVersion(version, handler)
+		if prefix == "" {
+			handler = c.deprecation.deprecated(version, handler)
+		}
+		router.HandleFunc(prefix+path, traceHandler(prefix+path, handler)).Methods(method)
 	}
+
+	handle("/quotes", "GET", negotiated(c.getQuotes))
+	handle("/quotes", "POST", negotiated(c.createQuote))
+	handle("/quotes/random", "GET", negotiated(c.getRandomQuote))
+	if c.events != nil {
+		handle("/quotes/stream", "GET", c.streamQuotes)
+	}
+	handle("/quotes/batch", "POST", c.applyBatch)
+	handle("/quotes/{id}", "GET", negotiated(c.getQuoteById))
+	handle("/quotes/{id}", "PUT", negotiated(c.updateQuote))
+	handle("/quotes/{id}", "DELETE", c.deleteQuote)
 }
 
 func (c *QuoteController) createQuote(w http.ResponseWriter, r *http.Request) {
 	var quoteDto dtos.QuoteDto
+	if !decodeBody(w, r, &quoteDto) {
+		return
+	}
 
-	if err := json.NewDecoder(r.Body).Decode(&quoteDto); err != nil {
-		c.writeErrorResponse(w, "Invalid JSON format", http.StatusBadRequest)
+	createdQuote, err := c.service.CreateQuote(r.Context(), quoteDto)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to create quote")
 		return
 	}
 
-	if quoteDto.Author == nil || strings.TrimSpace(*quoteDto.Author) == "" {
-		c.writeErrorResponse(w, "Author is required", http.StatusBadRequest)
+	setValidators(w, quoteETag(r, createdQuote), createdQuote.UpdatedAt)
+	writeQuotesResponse(w, r, createdQuote, http.StatusCreated)
+}
+
+func (c *QuoteController) updateQuote(w http.ResponseWriter, r *http.Request) {
+	pgUuid, err := parseUUID(mux.Vars(r)["id"])
+	if err != nil {
+		writeProblem(w, r, problemInvalidId, "")
 		return
 	}
 
-	if quoteDto.Text == nil || strings.TrimSpace(*quoteDto.Text) == "" {
-		c.writeErrorResponse(w, "Text is required", http.StatusBadRequest)
+	var quoteDto dtos.QuoteDto
+	if !decodeBody(w, r, &quoteDto) {
 		return
 	}
 
-	createdQuote, err := c.service.CreateQuote(r.Context(), quoteDto)
+	version, ok := c.expectedVersion(w, r, pgUuid)
+	if !ok {
+		return
+	}
+
+	updatedQuote, err := c.service.UpdateQuote(r.Context(), pgUuid, quoteDto, version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeNotFound(w, r)
+		return
+	}
 	if err != nil {
-		c.writeErrorResponse(w, "Failed to create quote", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Failed to update quote")
 		return
 	}
 
-	c.writeJSONResponse(w, createdQuote, http.StatusCreated)
+	setValidators(w, quoteETag(r, updatedQuote), updatedQuote.UpdatedAt)
+	writeQuotesResponse(w, r, updatedQuote, http.StatusOK)
 }
 
 func (c *QuoteController) getQuotes(w http.ResponseWriter, r *http.Request) {
 	author := r.URL.Query().Get("author")
 
+	// Read before the list, so that a concurrent change makes the
+	// Last-Modified older rather than newer than the content.
+	modifiedAt, err := c.service.GetLastModified(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to retrieve quotes")
+		return
+	}
+
 	var quotes []dtos.QuoteDto
-	var err error
 
 	if author != "" {
 		quotes, err = c.service.GetQuotesByAuthor(r.Context(), author)
@@ -74,21 +121,49 @@ func (c *QuoteController) getQuotes(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		c.writeErrorResponse(w, "Failed to retrieve quotes", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Failed to retrieve quotes")
 		return
 	}
 
-	c.writeJSONResponse(w, quotes, http.StatusOK)
+	if writeNotModified(w, r, listETag(r, quotes), modifiedAt) {
+		return
+	}
+
+	writeQuotesResponse(w, r, quotes, http.StatusOK)
 }
 
 func (c *QuoteController) getRandomQuote(w http.ResponseWriter, r *http.Request) {
 	quote, err := c.service.GetRandomQuote(r.Context())
 	if err != nil {
-		c.writeErrorResponse(w, "Failed to retrieve random quote", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Failed to retrieve random quote")
 		return
 	}
 
-	c.writeJSONResponse(w, quote, http.StatusOK)
+	writeQuotesResponse(w, r, quote, http.StatusOK)
+}
+
+func (c *QuoteController) getQuoteById(w http.ResponseWriter, r *http.Request) {
+	pgUuid, err := parseUUID(mux.Vars(r)["id"])
+	if err != nil {
+		writeProblem(w, r, problemInvalidId, "")
+		return
+	}
+
+	quote, err := c.service.GetQuoteById(r.Context(), pgUuid)
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeProblem(w, r, problemNotFound, "Quote not found")
+		return
+	}
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to retrieve quote")
+		return
+	}
+
+	if writeNotModified(w, r, quoteETag(r, quote), quote.UpdatedAt) {
+		return
+	}
+
+	writeQuotesResponse(w, r, quote, http.StatusOK)
 }
 
 func (c *QuoteController) deleteQuote(w http.ResponseWriter, r *http.Request) {
@@ -96,26 +171,86 @@ func (c *QuoteController) deleteQuote(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["id"]
 
 	if idStr == "" {
-		c.writeErrorResponse(w, "Quote ID is required", http.StatusBadRequest)
+		writeProblem(w, r, problemInvalidId, "Quote ID is required")
 		return
 	}
 
-	pgUuid, err := c.parseUUID(idStr)
+	pgUuid, err := parseUUID(idStr)
 	if err != nil {
-		c.writeErrorResponse(w, "Invalid UUID format", http.StatusBadRequest)
+		writeProblem(w, r, problemInvalidId, "")
 		return
 	}
 
-	err = c.service.DeleteQuote(r.Context(), pgUuid)
+	version, ok := c.expectedVersion(w, r, pgUuid)
+	if !ok {
+		return
+	}
+
+	err = c.service.DeleteQuote(r.Context(), pgUuid, version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeNotFound(w, r)
+		return
+	}
 	if err != nil {
-		c.writeErrorResponse(w, "Failed to delete quote", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Failed to delete quote")
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (c *QuoteController) parseUUID(uuidStr string) (pgtype.UUID, error) {
+// expectedVersion resolves the If-Match header of an update or delete to the
+// version the change must apply to, 0 meaning any. It answers 412 and
+// returns false when none of the listed entity tags can match.
+func (c *QuoteController) expectedVersion(w http.ResponseWriter, r *http.Request, id pgtype.UUID) (int64, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return 0, true
+	}
+
+	versions, anyVersion := ifMatchVersions(header)
+	switch {
+	case anyVersion:
+		return 0, true
+	case len(versions) == 1:
+		return versions[0], true
+	case len(versions) == 0:
+		writeProblem(w, r, problemPreconditionFailed, "")
+		return 0, false
+	}
+
+	// Several tags: the change applies to the current version if it is one
+	// of them, and still fails if the quote changes in the meantime.
+	quote, err := c.service.GetQuoteById(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeProblem(w, r, problemPreconditionFailed, "")
+		return 0, false
+	}
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to retrieve quote")
+		return 0, false
+	}
+
+	if !slices.Contains(versions, quote.Version) {
+		writeProblem(w, r, problemPreconditionFailed, "")
+		return 0, false
+	}
+
+	return quote.Version, true
+}
+
+// writeNotFound answers a change to a missing quote: 404, or 412 if the
+// request was conditional on the quote existing.
+func writeNotFound(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("If-Match") != "" {
+		writeProblem(w, r, problemPreconditionFailed, "")
+		return
+	}
+
+	writeProblem(w, r, problemNotFound, "Quote not found")
+}
+
+func parseUUID(uuidStr string) (pgtype.UUID, error) {
 	uuidStr = strings.TrimSpace(uuidStr)
 
 	if len(uuidStr) != 36 ||
@@ -133,23 +268,6 @@ func (c *QuoteController) parseUUID(uuidStr string) (pgtype.UUID, error) {
 	return pgUuid, nil
 }
 
-func (c *QuoteController) writeJSONResponse(w http.ResponseWriter, data interface{}, statusCode int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-	}
-}
-
-func (c *QuoteController) writeErrorResponse(w http.ResponseWriter, message string, statusCode int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	errorResponse := map[string]string{"error": message}
-	json.NewEncoder(w).Encode(errorResponse)
-}
-
 type InvalidUUIDError struct {
 	UUID string
 }
@@ -4,16 +4,24 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/drivers"
 	"quotes/internal/dtos"
+	"quotes/internal/logging"
+	"quotes/internal/services"
 )
 
 type MockQuoteService struct {
@@ -44,6 +52,38 @@ func (m *MockQuoteService) GetQuotesByAuthor(ctx context.Context, author string)
 	return args.Get(0).([]dtos.QuoteDto), args.Error(1)
 }
 
+func (m *MockQuoteService) GetQuotesByAuthors(ctx context.Context, authors []string) (map[string][]dtos.QuoteDto, error) {
+	args := m.Called(ctx, authors)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string][]dtos.QuoteDto), args.Error(1)
+}
+
+func (m *MockQuoteService) GetQuotesByTags(ctx context.Context, tags []string) (map[string][]dtos.QuoteDto, error) {
+	args := m.Called(ctx, tags)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string][]dtos.QuoteDto), args.Error(1)
+}
+
+func (m *MockQuoteService) GetQuotesByTag(ctx context.Context, tag, author string) ([]dtos.QuoteDto, error) {
+	args := m.Called(ctx, tag, author)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]dtos.QuoteDto), args.Error(1)
+}
+
+func (m *MockQuoteService) GetQuotesPage(ctx context.Context, author string, after pgtype.UUID, limit int) ([]dtos.QuoteDto, error) {
+	args := m.Called(ctx, author, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]dtos.QuoteDto), args.Error(1)
+}
+
 func (m *MockQuoteService) GetRandomQuote(ctx context.Context) (*dtos.QuoteDto, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
@@ -52,14 +92,51 @@ func (m *MockQuoteService) GetRandomQuote(ctx context.Context) (*dtos.QuoteDto,
 	return args.Get(0).(*dtos.QuoteDto), args.Error(1)
 }
 
-func (m *MockQuoteService) DeleteQuote(ctx context.Context, id pgtype.UUID) error {
+func (m *MockQuoteService) GetQuoteById(ctx context.Context, id pgtype.UUID) (*dtos.QuoteDto, error) {
 	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dtos.QuoteDto), args.Error(1)
+}
+
+func (m *MockQuoteService) UpdateQuote(ctx context.Context, id pgtype.UUID, dto dtos.QuoteDto, version int64) (*dtos.QuoteDto, error) {
+	args := m.Called(ctx, id, dto, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dtos.QuoteDto), args.Error(1)
+}
+
+func (m *MockQuoteService) DeleteQuote(ctx context.Context, id pgtype.UUID, version int64) error {
+	args := m.Called(ctx, id, version)
 	return args.Error(0)
 }
 
+func (m *MockQuoteService) GetLastModified(ctx context.Context) (time.Time, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockQuoteService) RenameAuthors(ctx context.Context, renameDto dtos.AuthorRenameDto) (*dtos.AuthorRenameResultDto, error) {
+	args := m.Called(ctx, renameDto)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dtos.AuthorRenameResultDto), args.Error(1)
+}
+
+func (m *MockQuoteService) ApplyBatch(ctx context.Context, operations []dtos.BatchOperationDto, allOrNothing bool) ([]services.BatchResult, error) {
+	args := m.Called(ctx, operations, allOrNothing)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]services.BatchResult), args.Error(1)
+}
+
 func TestCreateQuote(t *testing.T) {
 	mockService := &MockQuoteService{}
-	controller := NewQuoteController(mockService)
+	controller := NewQuoteController(mockService, Deprecation{})
 
 	author := "author"
 	text := "text"
@@ -93,7 +170,7 @@ func TestCreateQuote(t *testing.T) {
 
 func TestGetAllQuotes(t *testing.T) {
 	mockService := &MockQuoteService{}
-	controller := NewQuoteController(mockService)
+	controller := NewQuoteController(mockService, Deprecation{})
 
 	author := "author"
 	text := "text"
@@ -103,6 +180,7 @@ func TestGetAllQuotes(t *testing.T) {
 			Text:   &text,
 		},
 	}
+	mockService.On("GetLastModified", mock.Anything).Return(time.Now(), nil)
 	mockService.On("GetAllQuotes", mock.Anything).Return(expectedQuotes, nil)
 
 	req := httptest.NewRequest("GET", "/quotes", nil)
@@ -123,7 +201,7 @@ func TestGetAllQuotes(t *testing.T) {
 
 func TestGetQuotesByAuthor(t *testing.T) {
 	mockService := &MockQuoteService{}
-	controller := NewQuoteController(mockService)
+	controller := NewQuoteController(mockService, Deprecation{})
 
 	author := "author"
 	text := "text"
@@ -133,6 +211,7 @@ func TestGetQuotesByAuthor(t *testing.T) {
 			Text:   &text,
 		},
 	}
+	mockService.On("GetLastModified", mock.Anything).Return(time.Now(), nil)
 	mockService.On("GetQuotesByAuthor", mock.Anything, author).Return(expectedQuotes, nil)
 
 	req := httptest.NewRequest("GET", "/quotes?author="+author, nil)
@@ -152,7 +231,7 @@ func TestGetQuotesByAuthor(t *testing.T) {
 
 func TestGetRandomQuote(t *testing.T) {
 	mockService := &MockQuoteService{}
-	controller := NewQuoteController(mockService)
+	controller := NewQuoteController(mockService, Deprecation{})
 
 	author := "author"
 	text := "text"
@@ -180,12 +259,12 @@ func TestGetRandomQuote(t *testing.T) {
 
 func TestDeleteQuote(t *testing.T) {
 	mockService := &MockQuoteService{}
-	controller := NewQuoteController(mockService)
+	controller := NewQuoteController(mockService, Deprecation{})
 
 	idBytes := uuid.New()
 	id := pgtype.UUID{Bytes: idBytes, Valid: true}
 
-	mockService.On("DeleteQuote", mock.Anything, id).Return(nil)
+	mockService.On("DeleteQuote", mock.Anything, id, int64(0)).Return(nil)
 
 	req := httptest.NewRequest("DELETE", "/quotes/"+idBytes.String(), nil)
 	req = mux.SetURLVars(req, map[string]string{"id": idBytes.String()})
@@ -198,3 +277,281 @@ func TestDeleteQuote(t *testing.T) {
 
 	mockService.AssertExpectations(t)
 }
+
+func TestDeleteQuoteNotFound(t *testing.T) {
+	mockService := &MockQuoteService{}
+	controller := NewQuoteController(mockService, Deprecation{})
+
+	idBytes := uuid.New()
+	mockService.On("DeleteQuote", mock.Anything, pgtype.UUID{Bytes: idBytes, Valid: true}, int64(0)).Return(pgx.ErrNoRows)
+
+	req := httptest.NewRequest("DELETE", "/quotes/"+idBytes.String(), nil)
+	req = mux.SetURLVars(req, map[string]string{"id": idBytes.String()})
+	rr := httptest.NewRecorder()
+
+	controller.deleteQuote(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateQuoteLogsServiceError(t *testing.T) {
+	mockService := &MockQuoteService{}
+	controller := NewQuoteController(mockService, Deprecation{})
+
+	author := "author"
+	text := "text"
+	inputDto := dtos.QuoteDto{
+		Author: &author,
+		Text:   &text,
+	}
+
+	mockService.On("CreateQuote", mock.Anything, inputDto).Return(nil, errors.New("connection refused"))
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	jsonBody, _ := json.Marshal(inputDto)
+	req := httptest.NewRequest("POST", "/quotes", bytes.NewBuffer(jsonBody))
+	req = req.WithContext(logging.WithLogger(req.Context(), logger))
+	rr := httptest.NewRecorder()
+
+	controller.createQuote(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	var record map[string]any
+	err := json.Unmarshal(logs.Bytes(), &record)
+	assert.NoError(t, err)
+	assert.Equal(t, "Failed to create quote", record["msg"])
+	assert.Equal(t, "connection refused", record["error"])
+	mockService.AssertExpectations(t)
+}
+
+func TestGetRandomQuoteCircuitOpen(t *testing.T) {
+	mockService := &MockQuoteService{}
+	controller := NewQuoteController(mockService, Deprecation{})
+
+	mockService.On("GetRandomQuote", mock.Anything).Return(nil, &drivers.CircuitOpenError{RetryAfter: 2500 * time.Millisecond})
+
+	req := httptest.NewRequest("GET", "/quotes/random", nil)
+	rr := httptest.NewRecorder()
+
+	controller.getRandomQuote(rr, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "3", rr.Header().Get("Retry-After"))
+	mockService.AssertExpectations(t)
+}
+
+func TestGetQuoteById(t *testing.T) {
+	mockService := &MockQuoteService{}
+	controller := NewQuoteController(mockService, Deprecation{})
+
+	idBytes := uuid.New()
+	id := pgtype.UUID{Bytes: idBytes, Valid: true}
+	author := "author"
+	text := "text"
+	expectedQuote := dtos.QuoteDto{Id: &id, Author: &author, Text: &text}
+
+	mockService.On("GetQuoteById", mock.Anything, id).Return(&expectedQuote, nil)
+
+	req := httptest.NewRequest("GET", "/quotes/"+idBytes.String(), nil)
+	req = mux.SetURLVars(req, map[string]string{"id": idBytes.String()})
+	rr := httptest.NewRecorder()
+
+	controller.getQuoteById(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var responseQuote dtos.QuoteDto
+	err := json.Unmarshal(rr.Body.Bytes(), &responseQuote)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedQuote, responseQuote)
+	mockService.AssertExpectations(t)
+}
+
+func TestGetQuoteByIdNotFound(t *testing.T) {
+	mockService := &MockQuoteService{}
+	controller := NewQuoteController(mockService, Deprecation{})
+
+	idBytes := uuid.New()
+	mockService.On("GetQuoteById", mock.Anything, pgtype.UUID{Bytes: idBytes, Valid: true}).Return(nil, pgx.ErrNoRows)
+
+	req := httptest.NewRequest("GET", "/quotes/"+idBytes.String(), nil)
+	req = mux.SetURLVars(req, map[string]string{"id": idBytes.String()})
+	rr := httptest.NewRecorder()
+
+	controller.getQuoteById(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestGetQuoteByIdNotModified(t *testing.T) {
+	mockService := &MockQuoteService{}
+	controller := NewQuoteController(mockService, Deprecation{})
+
+	idBytes := uuid.New()
+	id := pgtype.UUID{Bytes: idBytes, Valid: true}
+	author := "author"
+	text := "text"
+	updatedAt := time.Date(2025, 3, 1, 12, 0, 0, 500, time.UTC)
+	quote := dtos.QuoteDto{Id: &id, Author: &author, Text: &text, Version: 3, UpdatedAt: updatedAt}
+
+	mockService.On("GetQuoteById", mock.Anything, id).Return(&quote, nil)
+
+	for _, test := range []struct {
+		name         string
+		header       string
+		value        string
+		expectedCode int
+	}{
+		{"no condition", "", "", http.StatusOK},
+		{"matching If-None-Match", "If-None-Match", `"2", "3"`, http.StatusNotModified},
+		{"weak If-None-Match", "If-None-Match", `W/"3"`, http.StatusNotModified},
+		{"stale If-None-Match", "If-None-Match", `"2"`, http.StatusOK},
+		{"current If-Modified-Since", "If-Modified-Since", updatedAt.Format(http.TimeFormat), http.StatusNotModified},
+		{"older If-Modified-Since", "If-Modified-Since", updatedAt.Add(-time.Second).Format(http.TimeFormat), http.StatusOK},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/quotes/"+idBytes.String(), nil)
+			req = mux.SetURLVars(req, map[string]string{"id": idBytes.String()})
+			if test.header != "" {
+				req.Header.Set(test.header, test.value)
+			}
+			rr := httptest.NewRecorder()
+
+			controller.getQuoteById(rr, req)
+
+			assert.Equal(t, test.expectedCode, rr.Code)
+			assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+			assert.Equal(t, "Sat, 01 Mar 2025 12:00:00 GMT", rr.Header().Get("Last-Modified"))
+			if test.expectedCode == http.StatusNotModified {
+				assert.Empty(t, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetAllQuotesNotModified(t *testing.T) {
+	mockService := &MockQuoteService{}
+	controller := NewQuoteController(mockService, Deprecation{})
+
+	id := pgtype.UUID{Bytes: uuid.New(), Valid: true}
+	author := "author"
+	text := "text"
+	quotes := []dtos.QuoteDto{{Id: &id, Author: &author, Text: &text, Version: 1}}
+
+	mockService.On("GetLastModified", mock.Anything).Return(time.Now(), nil)
+	mockService.On("GetAllQuotes", mock.Anything).Return(quotes, nil)
+
+	rr := httptest.NewRecorder()
+	controller.getQuotes(rr, httptest.NewRequest("GET", "/quotes", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	etag := rr.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req := httptest.NewRequest("GET", "/quotes", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	controller.getQuotes(rr, req)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+
+	quotes[0].Version = 2
+	req = httptest.NewRequest("GET", "/quotes", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	controller.getQuotes(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code, "an updated quote should change the list ETag")
+}
+
+func TestUpdateQuote(t *testing.T) {
+	idBytes := uuid.New()
+	id := pgtype.UUID{Bytes: idBytes, Valid: true}
+	author := "author"
+	text := "text"
+	inputDto := dtos.QuoteDto{Author: &author, Text: &text}
+	updatedDto := dtos.QuoteDto{Id: &id, Author: &author, Text: &text, Version: 4, UpdatedAt: time.Now()}
+
+	for _, test := range []struct {
+		name            string
+		ifMatch         string
+		expectedVersion int64
+		serviceErr      error
+		expectedCode    int
+	}{
+		{"unconditional", "", 0, nil, http.StatusOK},
+		{"any version", "*", 0, nil, http.StatusOK},
+		{"matching version", `"3"`, 3, nil, http.StatusOK},
+		{"stale version", `"2"`, 2, &drivers.VersionConflictError{Id: id, Version: 2}, http.StatusPreconditionFailed},
+		{"missing quote", "", 0, pgx.ErrNoRows, http.StatusNotFound},
+		{"missing quote with If-Match", `"3"`, 3, pgx.ErrNoRows, http.StatusPreconditionFailed},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			mockService := &MockQuoteService{}
+			controller := NewQuoteController(mockService, Deprecation{})
+
+			if test.serviceErr != nil {
+				mockService.On("UpdateQuote", mock.Anything, id, inputDto, test.expectedVersion).Return(nil, test.serviceErr)
+			} else {
+				mockService.On("UpdateQuote", mock.Anything, id, inputDto, test.expectedVersion).Return(&updatedDto, nil)
+			}
+
+			jsonBody, _ := json.Marshal(inputDto)
+			req := httptest.NewRequest("PUT", "/quotes/"+idBytes.String(), bytes.NewBuffer(jsonBody))
+			req = mux.SetURLVars(req, map[string]string{"id": idBytes.String()})
+			if test.ifMatch != "" {
+				req.Header.Set("If-Match", test.ifMatch)
+			}
+			rr := httptest.NewRecorder()
+
+			controller.updateQuote(rr, req)
+
+			assert.Equal(t, test.expectedCode, rr.Code)
+			if test.expectedCode == http.StatusOK {
+				assert.Equal(t, `"4"`, rr.Header().Get("ETag"))
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteQuoteIfMatch(t *testing.T) {
+	idBytes := uuid.New()
+	id := pgtype.UUID{Bytes: idBytes, Valid: true}
+	author := "author"
+	text := "text"
+	current := dtos.QuoteDto{Id: &id, Author: &author, Text: &text, Version: 5}
+
+	for _, test := range []struct {
+		name         string
+		ifMatch      string
+		expectedCode int
+	}{
+		{"one of several tags matches", `"4", "5"`, http.StatusNoContent},
+		{"no tag matches", `"3", "4"`, http.StatusPreconditionFailed},
+		{"only weak tags", `W/"5"`, http.StatusPreconditionFailed},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			mockService := &MockQuoteService{}
+			controller := NewQuoteController(mockService, Deprecation{})
+
+			mockService.On("GetQuoteById", mock.Anything, id).Return(&current, nil).Maybe()
+			mockService.On("DeleteQuote", mock.Anything, id, int64(5)).Return(nil).Maybe()
+
+			req := httptest.NewRequest("DELETE", "/quotes/"+idBytes.String(), nil)
+			req = mux.SetURLVars(req, map[string]string{"id": idBytes.String()})
+			req.Header.Set("If-Match", test.ifMatch)
+			rr := httptest.NewRecorder()
+
+			controller.deleteQuote(rr, req)
+
+			assert.Equal(t, test.expectedCode, rr.Code)
+			if test.expectedCode != http.StatusNoContent {
+				mockService.AssertNotCalled(t, "DeleteQuote", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"quotes/internal/services"
+)
+
+// SetEventBroker makes the controller serve /quotes/stream with the events
+// of broker, sending a comment every heartbeatInterval so that proxies keep
+// idle streams open.
+func (c *QuoteController) SetEventBroker(broker *services.QuoteEventBroker, heartbeatInterval time.Duration) {
+	c.events = broker
+	c.heartbeatInterval = heartbeatInterval
+}
+
+// streamQuotes sends quote changes as server-sent events until the client
+// disconnects. A Last-Event-ID header resumes after that event; if it is
+// no longer known, a resync event is sent first.
+func (c *QuoteController) streamQuotes(w http.ResponseWriter, r *http.Request) {
+	var lastEventId int64
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		var err error
+		if lastEventId, err = strconv.ParseInt(header, 10, 64); err != nil || lastEventId == 0 {
+			// Never matches, so that the client resyncs.
+			lastEventId = -1
+		}
+	}
+
+	replay, subscription := c.events.Subscribe(lastEventId)
+	defer c.events.Unsubscribe(subscription)
+
+	// Streams outlive the write timeout of ordinary responses.
+	controller := http.NewResponseController(w)
+	controller.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	version := versionFromContext(r.Context())
+	for _, event := range replay {
+		writeQuoteEvent(w, version, event)
+	}
+	if controller.Flush() != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(c.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return
+			}
+			writeQuoteEvent(w, version, event)
+		}
+
+		if controller.Flush() != nil {
+			return
+		}
+	}
+}
+
+// writeQuoteEvent writes event in the text/event-stream format. Created and
+// updated quotes are sent in the JSON representation of the version, other
+// events only with the quote ID.
+func writeQuoteEvent(w http.ResponseWriter, version *apiVersion, event services.QuoteEvent) {
+	var data any = map[string]any{}
+	switch {
+	case event.Quote != nil:
+		data = version.mapQuote(event.Quote)
+	case event.QuoteId.Valid:
+		data = map[string]any{"id": event.QuoteId}
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		body = []byte("{}")
+	}
+
+	if event.Id != 0 {
+		fmt.Fprintf(w, "id: %d\n", event.Id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, body)
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/drivers"
+	"quotes/internal/services"
+)
+
+// readEvent reads the lines of the next server-sent event.
+func readEvent(t *testing.T, reader *bufio.Reader) []string {
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestStreamQuotes(t *testing.T) {
+	mockService := &MockQuoteService{}
+	quote := testQuoteDtos(1)[0]
+	mockService.On("GetQuoteById", mock.Anything, *quote.Id).Return(&quote, nil)
+	broker := services.NewQuoteEventBroker(mockService, 10)
+	broker.Publish(drivers.QuoteChange{Operation: drivers.QuoteInserted, Id: *quote.Id, EventId: 1})
+	broker.Publish(drivers.QuoteChange{Operation: drivers.QuoteUpdated, Id: *quote.Id, EventId: 2})
+
+	controller := NewQuoteController(mockService, Deprecation{})
+	controller.SetEventBroker(broker, time.Minute)
+	router := mux.NewRouter()
+	controller.RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+	defer broker.Close()
+
+	req, err := http.NewRequest("GET", server.URL+"/v1/quotes/stream", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	reader := bufio.NewReader(resp.Body)
+
+	replayed := readEvent(t, reader)
+	require.Len(t, replayed, 3)
+	assert.Equal(t, "id: 2", replayed[0])
+	assert.Equal(t, "event: updated", replayed[1])
+	assert.Contains(t, replayed[2], `"text":"Text a"`)
+
+	broker.Publish(drivers.QuoteChange{Operation: drivers.QuoteDeleted, Id: *quote.Id, EventId: 3})
+	assert.Equal(t, []string{"id: 3", "event: deleted", `data: {"id":"` + quote.Id.String() + `"}`}, readEvent(t, reader))
+}
+
+func TestStreamQuotesResyncsUnknownLastEvent(t *testing.T) {
+	mockService := &MockQuoteService{}
+	broker := services.NewQuoteEventBroker(mockService, 10)
+	controller := NewQuoteController(mockService, Deprecation{})
+	controller.SetEventBroker(broker, time.Minute)
+	router := mux.NewRouter()
+	controller.RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+	defer broker.Close()
+
+	req, err := http.NewRequest("GET", server.URL+"/v1/quotes/stream", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "42")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, []string{"event: resync", "data: {}"}, readEvent(t, bufio.NewReader(resp.Body)))
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"quotes/internal/drivers"
+	"quotes/internal/dtos"
+	"quotes/internal/logging"
+	"quotes/internal/services"
+)
+
+func writeJSONResponse(w http.ResponseWriter, data interface{}, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// writeServiceError answers a failed service call: 400 with the rejected
+// fields when validation fails, 412 when an If-Match precondition no
+// longer holds, 503 with Retry-After while the database
+// circuit breaker is open, and 500 otherwise. Failures are logged.
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	var validationErr *services.ValidationError
+	if errors.As(err, &validationErr) {
+		writeProblem(w, r, problemValidation, "", validationErr.Fields...)
+		return
+	}
+
+	var conflictErr *drivers.VersionConflictError
+	if errors.As(err, &conflictErr) {
+		writeProblem(w, r, problemPreconditionFailed, "")
+		return
+	}
+
+	logging.FromContext(r.Context()).Error(message, "error", err)
+
+	var openErr *drivers.CircuitOpenError
+	if errors.As(err, &openErr) {
+		retryAfter := int(math.Ceil(openErr.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+		writeProblem(w, r, problemUnavailable, "The database is unavailable")
+		return
+	}
+
+	writeProblem(w, r, problemInternal, message)
+}
+
+// decodeBody decodes a JSON request body into dst, rejecting unknown fields.
+// It answers with a problem and returns false if the body cannot be used:
+// 413 when it exceeds the limit of BodyLimitMiddleware, 400 with the
+// offending field for unknown fields and values of the wrong type, and 400
+// for malformed JSON.
+func decodeBody(w http.ResponseWriter, r *http.Request, dst any) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(dst)
+	if err == nil && decoder.More() {
+		err = errors.New("unexpected data after the JSON value")
+	}
+	if err == nil {
+		return true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		writeProblem(w, r, problemBodyTooLarge, fmt.Sprintf("Request body must not exceed %d bytes", maxBytesErr.Limit))
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		writeProblem(w, r, problemValidation, "", dtos.FieldErrorDto{
+			Field:   typeErr.Field,
+			Code:    "invalid_type",
+			Message: fmt.Sprintf("%s must be a %s", typeErr.Field, jsonTypeName(typeErr.Type.Kind())),
+		})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no error type for unknown fields.
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		writeProblem(w, r, problemValidation, "", dtos.FieldErrorDto{
+			Field:   field,
+			Code:    "unknown",
+			Message: "Unknown field " + field,
+		})
+	default:
+		writeProblem(w, r, problemInvalidJson, "")
+	}
+
+	return false
+}
+
+func jsonTypeName(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return "number"
+	}
+}
@@ -0,0 +1,366 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v5"
+	"quotes/internal/dtos"
+	"quotes/internal/logging"
+	"quotes/internal/services"
+)
+
+// RotationPath is where clients connect to receive random quotes over a
+// WebSocket.
+var RotationPath = apiV1.prefix + "/quotes/random/ws"
+
+const (
+	rotationWriteTimeout = 10 * time.Second
+	// rotationMaxMessageBytes limits the subscribe messages of clients.
+	rotationMaxMessageBytes = 4096
+)
+
+// RotationLimits bounds the resources of the quote rotation.
+type RotationLimits struct {
+	MaxConnections          int
+	MaxConnectionsPerClient int
+	MinInterval             time.Duration
+	DefaultInterval         time.Duration
+	// PingInterval is how often the server pings; connections that have
+	// not answered for two intervals are closed.
+	PingInterval time.Duration
+}
+
+// RotationController pushes random quotes to WebSocket clients on the
+// schedule and with the filters they subscribe with.
+type RotationController struct {
+	service        services.QuoteServiceInterface
+	limits         RotationLimits
+	allowedOrigins []string
+	upgrader       websocket.Upgrader
+
+	mu          sync.Mutex
+	connections int
+	perClient   map[string]int
+	closing     chan struct{}
+	closed      bool
+}
+
+// NewRotationController creates the controller. Browsers may connect from
+// the page's own origin and from allowedOrigins, where "*" allows any.
+func NewRotationController(service services.QuoteServiceInterface, limits RotationLimits, allowedOrigins []string) *RotationController {
+	c := &RotationController{
+		service:        service,
+		limits:         limits,
+		allowedOrigins: allowedOrigins,
+		perClient:      make(map[string]int),
+		closing:        make(chan struct{}),
+	}
+	c.upgrader = websocket.Upgrader{
+		CheckOrigin: c.checkOrigin,
+		Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+			if status == http.StatusForbidden {
+				writeProblem(w, r, problemForbiddenOrigin, "")
+				return
+			}
+			writeProblem(w, r, problemWebsocketRequired, reason.Error())
+		},
+	}
+
+	return c
+}
+
+func (c *RotationController) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc(RotationPath, withVersion(apiV1, c.rotate)).Methods("GET")
+}
+
+// Close ends every connection with a going away close message and rejects
+// new ones.
+func (c *RotationController) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.closed {
+		c.closed = true
+		close(c.closing)
+	}
+}
+
+func (c *RotationController) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || slices.Contains(c.allowedOrigins, "*") || slices.Contains(c.allowedOrigins, origin) {
+		return true
+	}
+
+	parsed, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(parsed.Host, r.Host)
+}
+
+// acquire reserves a connection for client, answering with a problem if a
+// limit is reached.
+func (c *RotationController) acquire(w http.ResponseWriter, r *http.Request, client string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case c.closed:
+		writeProblem(w, r, problemUnavailable, "The server is shutting down")
+	case c.connections >= c.limits.MaxConnections:
+		writeProblem(w, r, problemUnavailable, "Too many WebSocket connections")
+	case c.perClient[client] >= c.limits.MaxConnectionsPerClient:
+		writeProblem(w, r, problemTooManyRequests, "Too many WebSocket connections from this client")
+	default:
+		c.connections++
+		c.perClient[client]++
+		return true
+	}
+
+	return false
+}
+
+func (c *RotationController) release(client string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.connections--
+	if c.perClient[client]--; c.perClient[client] == 0 {
+		delete(c.perClient, client)
+	}
+}
+
+// rotationSubscription selects the quotes sent and how often.
+type rotationSubscription struct {
+	Author   string
+	Tag      string
+	Interval time.Duration
+}
+
+// rotationMessage is a message in either direction. Clients send
+// "subscribe"; the server sends "subscribed", "quote" and "error".
+type rotationMessage struct {
+	Type     string         `json:"type"`
+	Author   string         `json:"author,omitempty"`
+	Tag      string         `json:"tag,omitempty"`
+	Interval string         `json:"interval,omitempty"`
+	Quote    *dtos.QuoteDto `json:"quote,omitempty"`
+	Code     string         `json:"code,omitempty"`
+	Message  string         `json:"message,omitempty"`
+}
+
+// subscription validates the filters and interval of a subscribe message
+// or of the query of the connection request.
+func (c *RotationController) subscription(author, tag, interval string) (rotationSubscription, *rotationMessage) {
+	subscription := rotationSubscription{
+		Author:   strings.TrimSpace(author),
+		Tag:      strings.TrimSpace(tag),
+		Interval: c.limits.DefaultInterval,
+	}
+
+	if interval != "" {
+		parsed, err := time.ParseDuration(interval)
+		if err != nil || parsed < c.limits.MinInterval {
+			return subscription, &rotationMessage{
+				Type: "error", Code: "invalid_interval",
+				Message: "interval must be a duration such as 30s of at least " + c.limits.MinInterval.String(),
+			}
+		}
+		subscription.Interval = parsed
+	}
+
+	return subscription, nil
+}
+
+// rotate upgrades the connection and sends a random quote matching the
+// subscription right away and then every interval. The query parameters
+// author, tag and interval form the initial subscription; subscribe
+// messages replace it.
+func (c *RotationController) rotate(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	subscription, problem := c.subscription(query.Get("author"), query.Get("tag"), query.Get("interval"))
+	if problem != nil {
+		writeProblem(w, r, problemValidation, "", dtos.FieldErrorDto{Field: "interval", Code: problem.Code, Message: problem.Message})
+		return
+	}
+
+	client, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		client = r.RemoteAddr
+	}
+	if !c.acquire(w, r, client) {
+		return
+	}
+	defer c.release(client)
+
+	conn, err := c.upgrader.Upgrade(hijackableWriter{w}, r, nil)
+	if err != nil {
+		// The upgrader has answered already.
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	messages := make(chan rotationMessage)
+	go c.readMessages(ctx, conn, messages, cancel)
+
+	ping := time.NewTicker(c.limits.PingInterval)
+	defer ping.Stop()
+
+	send := func(message rotationMessage) bool {
+		conn.SetWriteDeadline(time.Now().Add(rotationWriteTimeout))
+		return conn.WriteJSON(message) == nil
+	}
+
+	var rotation *time.Ticker
+	// previous is the ID of the last quote sent, which is not repeated
+	// right away.
+	var previous string
+	subscribe := func(next rotationSubscription) bool {
+		subscription, previous = next, ""
+		if rotation != nil {
+			rotation.Stop()
+		}
+		rotation = time.NewTicker(subscription.Interval)
+		return send(rotationMessage{Type: "subscribed", Author: next.Author, Tag: next.Tag, Interval: next.Interval.String()})
+	}
+	sendQuote := func() bool {
+		var message rotationMessage
+		message, previous = c.randomQuote(ctx, subscription, previous)
+		return send(message)
+	}
+
+	if !subscribe(subscription) || !sendQuote() {
+		return
+	}
+	defer func() { rotation.Stop() }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.closing:
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+				time.Now().Add(rotationWriteTimeout))
+			return
+		case <-ping.C:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(rotationWriteTimeout)) != nil {
+				return
+			}
+		case message := <-messages:
+			if message.Type != "subscribe" {
+				if !send(rotationMessage{Type: "error", Code: "invalid_message", Message: `Messages must be JSON objects of type "subscribe"`}) {
+					return
+				}
+				continue
+			}
+			next, problem := c.subscription(message.Author, message.Tag, message.Interval)
+			if problem != nil {
+				if !send(*problem) {
+					return
+				}
+				continue
+			}
+			if !subscribe(next) || !sendQuote() {
+				return
+			}
+		case <-rotation.C:
+			if !sendQuote() {
+				return
+			}
+		}
+	}
+}
+
+// readMessages passes the messages of the client to the writing goroutine,
+// with an empty type if they are not valid JSON, and keeps the connection
+// alive while pongs arrive. It cancels ctx when the connection fails or the
+// client closes it.
+func (c *RotationController) readMessages(ctx context.Context, conn *websocket.Conn, messages chan<- rotationMessage, cancel context.CancelFunc) {
+	defer cancel()
+
+	deadline := 2 * c.limits.PingInterval
+	conn.SetReadLimit(rotationMaxMessageBytes)
+	conn.SetReadDeadline(time.Now().Add(deadline))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(deadline))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) && ctx.Err() == nil {
+				logging.FromContext(ctx).Debug("WebSocket connection failed", "error", err)
+			}
+			return
+		}
+
+		var message rotationMessage
+		if json.Unmarshal(data, &message) != nil {
+			message = rotationMessage{}
+		}
+
+		select {
+		case messages <- message:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// randomQuote picks a quote matching subscription, avoiding the previous
+// one if there are others, and returns the message to send and the ID of
+// the quote sent.
+func (c *RotationController) randomQuote(ctx context.Context, subscription rotationSubscription, previous string) (rotationMessage, string) {
+	var quotes []dtos.QuoteDto
+	var err error
+	switch {
+	case subscription.Tag != "":
+		quotes, err = c.service.GetQuotesByTag(ctx, subscription.Tag, subscription.Author)
+	case subscription.Author != "":
+		quotes, err = c.service.GetQuotesByAuthor(ctx, subscription.Author)
+	default:
+		var quote *dtos.QuoteDto
+		if quote, err = c.service.GetRandomQuote(ctx); err == nil {
+			quotes = []dtos.QuoteDto{*quote}
+		}
+	}
+
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		logging.FromContext(ctx).Error("Failed to retrieve random quote", "error", err)
+		return rotationMessage{Type: "error", Code: "unavailable", Message: "Quotes are temporarily unavailable"}, previous
+	}
+	if len(quotes) > 1 {
+		quotes = slices.DeleteFunc(slices.Clone(quotes), func(quote dtos.QuoteDto) bool { return quote.Id.String() == previous })
+	}
+	if len(quotes) == 0 {
+		return rotationMessage{Type: "error", Code: "not_found", Message: "No quotes match the subscription"}, previous
+	}
+
+	quote := quotes[rand.IntN(len(quotes))]
+	return rotationMessage{Type: "quote", Quote: &quote}, quote.Id.String()
+}
+
+// hijackableWriter exposes the http.Hijacker of the writer wrapped by the
+// middleware, since the upgrader does not unwrap writers.
+type hijackableWriter struct {
+	http.ResponseWriter
+}
+
+func (w hijackableWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
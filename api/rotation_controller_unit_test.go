@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testRotationLimits = RotationLimits{
+	MaxConnections:          10,
+	MaxConnectionsPerClient: 1,
+	MinInterval:             10 * time.Millisecond,
+	DefaultInterval:         time.Minute,
+	PingInterval:            time.Minute,
+}
+
+// serveRotation serves a rotation controller for service and returns the
+// WebSocket URL of the rotation.
+func serveRotation(t *testing.T, service *MockQuoteService, limits RotationLimits) (string, *RotationController) {
+	controller := NewRotationController(service, limits, nil)
+	router := mux.NewRouter()
+	controller.RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	t.Cleanup(controller.Close)
+
+	return "ws" + strings.TrimPrefix(server.URL, "http") + RotationPath, controller
+}
+
+func readRotationMessage(t *testing.T, conn *websocket.Conn) rotationMessage {
+	var message rotationMessage
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, conn.ReadJSON(&message))
+	return message
+}
+
+func TestRotationSendsQuotesOfSubscription(t *testing.T) {
+	mockService := &MockQuoteService{}
+	quotes := testQuoteDtos(2)
+	mockService.On("GetQuotesByAuthor", mock.Anything, "Author").Return(quotes, nil)
+	tagged := testQuoteDtos(1)
+	mockService.On("GetQuotesByTag", mock.Anything, "wisdom", "").Return(tagged, nil)
+	url, _ := serveRotation(t, mockService, testRotationLimits)
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?author=Author&interval=20ms", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assert.Equal(t, rotationMessage{Type: "subscribed", Author: "Author", Interval: "20ms"}, readRotationMessage(t, conn))
+	first := readRotationMessage(t, conn)
+	require.Equal(t, "quote", first.Type)
+	second := readRotationMessage(t, conn)
+	require.Equal(t, "quote", second.Type)
+	assert.NotEqual(t, first.Quote.Id, second.Quote.Id, "the previous quote is not repeated")
+
+	require.NoError(t, conn.WriteJSON(map[string]string{"type": "subscribe", "tag": "wisdom"}))
+	for {
+		message := readRotationMessage(t, conn)
+		if message.Type == "subscribed" {
+			assert.Equal(t, "wisdom", message.Tag)
+			assert.Equal(t, "1m0s", message.Interval)
+			break
+		}
+	}
+	message := readRotationMessage(t, conn)
+	require.Equal(t, "quote", message.Type)
+	assert.Equal(t, tagged[0].Id, message.Quote.Id)
+}
+
+func TestRotationLeavesServiceResultsUnchanged(t *testing.T) {
+	mockService := &MockQuoteService{}
+	quotes := testQuoteDtos(3)
+	cached := slices.Clone(quotes)
+	mockService.On("GetQuotesByAuthor", mock.Anything, "Author").Return(quotes, nil)
+	controller := NewRotationController(mockService, testRotationLimits, nil)
+
+	previous := ""
+	for range 5 {
+		var message rotationMessage
+		message, previous = controller.randomQuote(context.Background(), rotationSubscription{Author: "Author"}, previous)
+		require.Equal(t, "quote", message.Type)
+	}
+
+	assert.Equal(t, cached, quotes, "the slice may be shared with the cache")
+}
+
+func TestRotationFiltersTaggedQuotesByAuthorThroughService(t *testing.T) {
+	mockService := &MockQuoteService{}
+	quotes := testQuoteDtos(1)
+	mockService.On("GetQuotesByTag", mock.Anything, "wisdom", "Author").Return(quotes, nil)
+	controller := NewRotationController(mockService, testRotationLimits, nil)
+
+	message, _ := controller.randomQuote(context.Background(), rotationSubscription{Author: "Author", Tag: "wisdom"}, "")
+	require.Equal(t, "quote", message.Type)
+	assert.Equal(t, quotes[0].Id, message.Quote.Id)
+	mockService.AssertExpectations(t)
+}
+
+func TestRotationRejectsInvalidMessages(t *testing.T) {
+	mockService := &MockQuoteService{}
+	quote := testQuoteDtos(1)[0]
+	mockService.On("GetRandomQuote", mock.Anything).Return(&quote, nil)
+	url, _ := serveRotation(t, mockService, testRotationLimits)
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, "subscribed", readRotationMessage(t, conn).Type)
+	assert.Equal(t, "quote", readRotationMessage(t, conn).Type)
+
+	require.NoError(t, conn.WriteJSON(map[string]string{"type": "subscribe", "interval": "1ms"}))
+	assert.Equal(t, "invalid_interval", readRotationMessage(t, conn).Code)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+	assert.Equal(t, "invalid_message", readRotationMessage(t, conn).Code)
+}
+
+func TestRotationLimitsConnectionsPerClient(t *testing.T) {
+	mockService := &MockQuoteService{}
+	quote := testQuoteDtos(1)[0]
+	mockService.On("GetRandomQuote", mock.Anything).Return(&quote, nil)
+	url, controller := serveRotation(t, mockService, testRotationLimits)
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	readRotationMessage(t, conn)
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	controller.Close()
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+			break
+		}
+	}
+}
+
+func TestRotationRequiresWebsocket(t *testing.T) {
+	url, _ := serveRotation(t, &MockQuoteService{}, testRotationLimits)
+
+	resp, err := http.Get("http" + strings.TrimPrefix(url, "ws"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, problemContentType, resp.Header.Get("Content-Type"))
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"quotes/internal/dtos"
+	"quotes/internal/services"
+)
+
+// searchBatchSize is how many quotes scanQuotes reads at a time.
+const searchBatchSize = 500
+
+// scanQuotes returns up to limit quotes matching query among those following
+// the quote with id after, or from the first if after is not valid. Quotes
+// are read a batch at a time until enough match, so the whole table is never
+// loaded at once.
+func scanQuotes(ctx context.Context, service services.QuoteServiceInterface, query string, after pgtype.UUID, limit int) ([]dtos.QuoteDto, error) {
+	var matches []dtos.QuoteDto
+	for len(matches) < limit {
+		quotes, err := service.GetQuotesPage(ctx, "", after, searchBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		matches = append(matches, searchQuotes(quotes, query)...)
+		if len(quotes) < searchBatchSize {
+			break
+		}
+		after = *quotes[len(quotes)-1].Id
+	}
+
+	return matches[:min(limit, len(matches))], nil
+}
+
+// searchQuotes returns the quotes whose author, text or one of whose tags
+// contain query, ignoring case. It serves the search of the gRPC and GraphQL
+// APIs.
+func searchQuotes(quotes []dtos.QuoteDto, query string) []dtos.QuoteDto {
+	query = strings.ToLower(strings.TrimSpace(query))
+	contains := func(value string) bool {
+		return strings.Contains(strings.ToLower(value), query)
+	}
+
+	var matches []dtos.QuoteDto
+	for _, quote := range quotes {
+		if contains(*quote.Author) || contains(*quote.Text) || slices.ContainsFunc(quote.Tags, contains) {
+			matches = append(matches, quote)
+		}
+	}
+
+	return matches
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"quotes/internal/dtos"
+)
+
+// apiVersion describes how one version of the API represents quotes. The
+// mappings turn service DTOs into the JSON bodies of the version, so that a
+// new version can change response shapes without affecting older ones.
+// Text, CSV, YAML and XML representations are the same in every version.
+type apiVersion struct {
+	prefix    string
+	mapQuote  func(quote *dtos.QuoteDto) any
+	mapQuotes func(quotes []dtos.QuoteDto) any
+}
+
+var apiV1 = &apiVersion{
+	prefix:    "/v1",
+	mapQuote:  func(quote *dtos.QuoteDto) any { return quote },
+	mapQuotes: func(quotes []dtos.QuoteDto) any { return quotes },
+}
+
+// legacyVersion is the version served on the paths without a prefix, which
+// predate versioning.
+var legacyVersion = apiV1
+
+type versionKey struct{}
+
+func withVersion(version *apiVersion, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r.WithContext(context.WithValue(r.Context(), versionKey{}, version)))
+	}
+}
+
+func versionFromContext(ctx context.Context) *apiVersion {
+	if version, ok := ctx.Value(versionKey{}).(*apiVersion); ok {
+		return version
+	}
+
+	return legacyVersion
+}
+
+// mapBody converts a quote or a list of quotes to the body of the version.
+func (v *apiVersion) mapBody(data any) any {
+	switch data := data.(type) {
+	case *dtos.QuoteDto:
+		return v.mapQuote(data)
+	case []dtos.QuoteDto:
+		return v.mapQuotes(data)
+	default:
+		return data
+	}
+}
+
+// Deprecation announces the removal of the legacy paths. Zero times are
+// left out of the headers.
+type Deprecation struct {
+	DeprecatedAt time.Time
+	Sunset       time.Time
+}
+
+// deprecated adds the RFC 9745 Deprecation and RFC 8594 Sunset headers to
+// the responses of a legacy path, with a link to its versioned successor.
+func (d Deprecation) deprecated(successor *apiVersion, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !d.DeprecatedAt.IsZero() {
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(d.DeprecatedAt.Unix(), 10))
+		}
+		if !d.Sunset.IsZero() {
+			w.Header().Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		w.Header().Add("Link", "<"+successor.prefix+r.URL.Path+`>; rel="successor-version"`)
+
+		handler(w, r)
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"quotes/internal/dtos"
+)
+
+func TestVersionedAndLegacyPaths(t *testing.T) {
+	mockService := &MockQuoteService{}
+	deprecation := Deprecation{
+		DeprecatedAt: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+		Sunset:       time.Date(2027, 4, 18, 0, 0, 0, 0, time.UTC),
+	}
+	router := mux.NewRouter()
+	NewQuoteController(mockService, deprecation).RegisterRoutes(router)
+
+	idBytes := uuid.New()
+	id := pgtype.UUID{Bytes: idBytes, Valid: true}
+	author, text := "author", "text"
+	mockService.On("GetQuoteById", mock.Anything, id).Return(&dtos.QuoteDto{Id: &id, Author: &author, Text: &text, Version: 1}, nil)
+
+	req := httptest.NewRequest("GET", "/v1/quotes/"+idBytes.String(), nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("Deprecation"))
+	assert.Empty(t, rr.Header().Get("Sunset"))
+	versionedBody := rr.Body.String()
+
+	req = httptest.NewRequest("GET", "/quotes/"+idBytes.String(), nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "@1792281600", rr.Header().Get("Deprecation"))
+	assert.Equal(t, "Sun, 18 Apr 2027 00:00:00 GMT", rr.Header().Get("Sunset"))
+	assert.Equal(t, "</v1/quotes/"+idBytes.String()+`>; rel="successor-version"`, rr.Header().Get("Link"))
+	assert.Equal(t, versionedBody, rr.Body.String())
+}
+
+func TestVersionMapsJsonBodies(t *testing.T) {
+	version := &apiVersion{
+		prefix:    "/v9",
+		mapQuote:  func(quote *dtos.QuoteDto) any { return map[string]string{"quote": *quote.Text} },
+		mapQuotes: func(quotes []dtos.QuoteDto) any { return map[string]int{"count": len(quotes)} },
+	}
+
+	text := "text"
+	quote := &dtos.QuoteDto{Text: &text}
+
+	for _, test := range []struct {
+		accept   string
+		data     any
+		expected string
+	}{
+		{"application/json", quote, "{\"quote\":\"text\"}\n"},
+		{"application/json", []dtos.QuoteDto{*quote}, "{\"count\":1}\n"},
+		{"text/plain", quote, "text — \n"},
+	} {
+		req := httptest.NewRequest("GET", "/v9/quotes", nil)
+		req.Header.Set("Accept", test.accept)
+		rr := httptest.NewRecorder()
+
+		withVersion(version, negotiated(func(w http.ResponseWriter, r *http.Request) {
+			writeQuotesResponse(w, r, test.data, http.StatusOK)
+		}))(rr, req)
+
+		assert.Equal(t, test.expected, rr.Body.String())
+	}
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"quotes/internal/dtos"
+	"quotes/internal/models"
+	"quotes/internal/services"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+// WebhookController manages webhook subscriptions. Every route requires an
+// API key when authentication is enabled, reads included, since
+// subscriptions reveal where quote changes are sent.
+type WebhookController struct {
+	service services.WebhookServiceInterface
+	apiKeys []string
+}
+
+func NewWebhookController(service services.WebhookServiceInterface, apiKeys []string) *WebhookController {
+	return &WebhookController{service: service, apiKeys: apiKeys}
+}
+
+func (c *WebhookController) RegisterRoutes(router *mux.Router) {
+	handle := func(path, method string, handler http.HandlerFunc) {
+		path = apiV1.prefix + path
+		router.HandleFunc(path, traceHandler(path, requireApiKey(c.apiKeys, handler))).Methods(method)
+	}
+
+	handle("/webhooks", "GET", c.getWebhooks)
+	handle("/webhooks", "POST", c.createWebhook)
+	handle("/webhooks/{id}", "GET", c.getWebhook)
+	handle("/webhooks/{id}", "PUT", c.updateWebhook)
+	handle("/webhooks/{id}", "DELETE", c.deleteWebhook)
+	handle("/webhooks/{id}/deliveries", "GET", c.getDeliveries)
+	handle("/webhooks/{id}/deliveries/{deliveryId}/retry", "POST", c.retryDelivery)
+}
+
+func (c *WebhookController) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var webhookDto dtos.WebhookDto
+	if !decodeBody(w, r, &webhookDto) {
+		return
+	}
+
+	created, err := c.service.CreateWebhook(r.Context(), webhookDto)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to create webhook")
+		return
+	}
+
+	w.Header().Set("Location", apiV1.prefix+"/webhooks/"+created.Id.String())
+	writeJSONResponse(w, created, http.StatusCreated)
+}
+
+func (c *WebhookController) getWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := c.service.GetWebhooks(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to retrieve webhooks")
+		return
+	}
+
+	writeJSONResponse(w, webhooks, http.StatusOK)
+}
+
+func (c *WebhookController) getWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookId(w, r)
+	if !ok {
+		return
+	}
+
+	webhook, err := c.service.GetWebhook(r.Context(), id)
+	if c.writeError(w, r, err, "Failed to retrieve webhook") {
+		return
+	}
+
+	writeJSONResponse(w, webhook, http.StatusOK)
+}
+
+func (c *WebhookController) updateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookId(w, r)
+	if !ok {
+		return
+	}
+
+	var webhookDto dtos.WebhookDto
+	if !decodeBody(w, r, &webhookDto) {
+		return
+	}
+
+	updated, err := c.service.UpdateWebhook(r.Context(), id, webhookDto)
+	if c.writeError(w, r, err, "Failed to update webhook") {
+		return
+	}
+
+	writeJSONResponse(w, updated, http.StatusOK)
+}
+
+func (c *WebhookController) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookId(w, r)
+	if !ok {
+		return
+	}
+
+	err := c.service.DeleteWebhook(r.Context(), id)
+	if c.writeError(w, r, err, "Failed to delete webhook") {
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getDeliveries returns the delivery log of a subscription. The status
+// query parameter selects pending, succeeded or dead deliveries, the
+// latter being the dead letters; limit bounds how many are returned.
+func (c *WebhookController) getDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookId(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	var fieldErrors []dtos.FieldErrorDto
+
+	status := query.Get("status")
+	if status != "" && !slices.Contains([]string{models.DeliveryPending, models.DeliverySucceeded, models.DeliveryDead}, status) {
+		fieldErrors = append(fieldErrors, dtos.FieldErrorDto{Field: "status", Code: "invalid",
+			Message: "status must be pending, succeeded or dead"})
+	}
+
+	limit := defaultDeliveriesLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxDeliveriesLimit {
+			fieldErrors = append(fieldErrors, dtos.FieldErrorDto{Field: "limit", Code: "out_of_range",
+				Message: "limit must be an integer from 1 to " + strconv.Itoa(maxDeliveriesLimit)})
+		}
+		limit = parsed
+	}
+
+	if len(fieldErrors) > 0 {
+		writeProblem(w, r, problemValidation, "", fieldErrors...)
+		return
+	}
+
+	deliveries, err := c.service.GetDeliveries(r.Context(), id, status, limit)
+	if c.writeError(w, r, err, "Failed to retrieve webhook deliveries") {
+		return
+	}
+
+	writeJSONResponse(w, deliveries, http.StatusOK)
+}
+
+// retryDelivery schedules a dead-lettered delivery again.
+func (c *WebhookController) retryDelivery(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookId(w, r)
+	if !ok {
+		return
+	}
+
+	deliveryId, err := strconv.ParseInt(mux.Vars(r)["deliveryId"], 10, 64)
+	if err != nil {
+		writeProblem(w, r, problemInvalidId, "Delivery ID must be an integer")
+		return
+	}
+
+	err = c.service.RetryDelivery(r.Context(), id, deliveryId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeProblem(w, r, problemNotFound, "Dead-lettered delivery not found")
+		return
+	}
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to retry webhook delivery")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// writeError answers a failed service call, with 404 for a missing
+// subscription, and returns whether err was set.
+func (c *WebhookController) writeError(w http.ResponseWriter, r *http.Request, err error, message string) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, pgx.ErrNoRows):
+		writeProblem(w, r, problemNotFound, "Webhook not found")
+	default:
+		writeServiceError(w, r, err, message)
+	}
+
+	return true
+}
+
+func parseWebhookId(w http.ResponseWriter, r *http.Request) (pgtype.UUID, bool) {
+	id, err := parseUUID(mux.Vars(r)["id"])
+	if err != nil {
+		writeProblem(w, r, problemInvalidId, "Webhook ID must be a UUID")
+		return pgtype.UUID{}, false
+	}
+
+	return id, true
+}
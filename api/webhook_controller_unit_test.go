@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/dtos"
+	"quotes/internal/services"
+)
+
+type MockWebhookService struct {
+	mock.Mock
+}
+
+func (m *MockWebhookService) CreateWebhook(ctx context.Context, dto dtos.WebhookDto) (*dtos.WebhookDto, error) {
+	args := m.Called(ctx, dto)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dtos.WebhookDto), args.Error(1)
+}
+
+func (m *MockWebhookService) UpdateWebhook(ctx context.Context, id pgtype.UUID, dto dtos.WebhookDto) (*dtos.WebhookDto, error) {
+	args := m.Called(ctx, id, dto)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dtos.WebhookDto), args.Error(1)
+}
+
+func (m *MockWebhookService) DeleteWebhook(ctx context.Context, id pgtype.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookService) GetWebhook(ctx context.Context, id pgtype.UUID) (*dtos.WebhookDto, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dtos.WebhookDto), args.Error(1)
+}
+
+func (m *MockWebhookService) GetWebhooks(ctx context.Context) ([]dtos.WebhookDto, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]dtos.WebhookDto), args.Error(1)
+}
+
+func (m *MockWebhookService) GetDeliveries(ctx context.Context, id pgtype.UUID, status string, limit int) ([]dtos.WebhookDeliveryDto, error) {
+	args := m.Called(ctx, id, status, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]dtos.WebhookDeliveryDto), args.Error(1)
+}
+
+func (m *MockWebhookService) RetryDelivery(ctx context.Context, id pgtype.UUID, deliveryId int64) error {
+	args := m.Called(ctx, id, deliveryId)
+	return args.Error(0)
+}
+
+const testWebhookId = "0190b6c8-6a5e-7d3e-9b1a-2f4c8e6d0a11"
+
+func serveWebhooks(service *MockWebhookService, apiKeys []string, method, path, body, authorization string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	NewWebhookController(service, apiKeys).RegisterRoutes(router)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestCreateWebhookReturnsSecret(t *testing.T) {
+	mockService := &MockWebhookService{}
+	id, err := parseUUID(testWebhookId)
+	require.NoError(t, err)
+	active := true
+	input := dtos.WebhookDto{Url: "https://example.com/hook", Events: []string{"quote.created"}}
+	mockService.On("CreateWebhook", mock.Anything, input).Return(&dtos.WebhookDto{
+		Id: &id, Url: input.Url, Events: input.Events, Active: &active, Secret: "generated",
+	}, nil)
+
+	rec := serveWebhooks(mockService, nil, "POST", "/v1/webhooks", `{"url":"https://example.com/hook","events":["quote.created"]}`, "")
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "/v1/webhooks/"+testWebhookId, rec.Header().Get("Location"))
+	var created dtos.WebhookDto
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "generated", created.Secret)
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateWebhookValidationFailure(t *testing.T) {
+	mockService := &MockWebhookService{}
+	mockService.On("CreateWebhook", mock.Anything, mock.Anything).Return(nil, &services.ValidationError{
+		Fields: []dtos.FieldErrorDto{{Field: "url", Code: "invalid_url", Message: "URL must be an absolute http or https URL"}},
+	})
+
+	rec := serveWebhooks(mockService, nil, "POST", "/v1/webhooks", `{"url":"ftp://example.com","events":["quote.created"]}`, "")
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"invalid_url"`)
+}
+
+func TestWebhookRoutesRequireApiKey(t *testing.T) {
+	mockService := &MockWebhookService{}
+	mockService.On("GetWebhooks", mock.Anything).Return([]dtos.WebhookDto{}, nil)
+
+	rec := serveWebhooks(mockService, []string{"secret"}, "GET", "/v1/webhooks", "", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = serveWebhooks(mockService, []string{"secret"}, "GET", "/v1/webhooks", "", "Bearer secret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[]`, rec.Body.String())
+}
+
+func TestGetWebhookNotFound(t *testing.T) {
+	mockService := &MockWebhookService{}
+	mockService.On("GetWebhook", mock.Anything, mock.Anything).Return(nil, pgx.ErrNoRows)
+
+	rec := serveWebhooks(mockService, nil, "GET", "/v1/webhooks/"+testWebhookId, "", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveWebhooks(mockService, nil, "GET", "/v1/webhooks/nope", "", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGetWebhookDeliveries(t *testing.T) {
+	mockService := &MockWebhookService{}
+	id, err := parseUUID(testWebhookId)
+	require.NoError(t, err)
+	mockService.On("GetDeliveries", mock.Anything, id, "dead", 10).Return([]dtos.WebhookDeliveryDto{
+		{Id: 7, EventId: 3, EventType: "quote.deleted", Status: "dead", Attempts: 8, LastError: "unexpected status 500"},
+	}, nil)
+
+	rec := serveWebhooks(mockService, nil, "GET", "/v1/webhooks/"+testWebhookId+"/deliveries?status=dead&limit=10", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var deliveries []dtos.WebhookDeliveryDto
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &deliveries))
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, int64(7), deliveries[0].Id)
+
+	rec = serveWebhooks(mockService, nil, "GET", "/v1/webhooks/"+testWebhookId+"/deliveries?status=failed&limit=0", "", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"field":"status"`)
+	assert.Contains(t, rec.Body.String(), `"field":"limit"`)
+	mockService.AssertExpectations(t)
+}
+
+func TestRetryWebhookDelivery(t *testing.T) {
+	mockService := &MockWebhookService{}
+	id, err := parseUUID(testWebhookId)
+	require.NoError(t, err)
+	mockService.On("RetryDelivery", mock.Anything, id, int64(7)).Return(nil)
+	mockService.On("RetryDelivery", mock.Anything, id, int64(8)).Return(pgx.ErrNoRows)
+
+	rec := serveWebhooks(mockService, nil, "POST", "/v1/webhooks/"+testWebhookId+"/deliveries/7/retry", "", "")
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	rec = serveWebhooks(mockService, nil, "POST", "/v1/webhooks/"+testWebhookId+"/deliveries/8/retry", "", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -2,46 +2,245 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"github.com/gorilla/mux"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
 	"quotes/api"
 	"quotes/internal/config"
 	"quotes/internal/drivers"
+	"quotes/internal/health"
+	"quotes/internal/logging"
+	"quotes/internal/server"
 	"quotes/internal/services"
+	"quotes/internal/tracing"
 )
 
 func main() {
-	cfg, err := config.LoadConfig()
+	args := os.Args[1:]
+
+	var migrateCommand string
+	if len(args) > 0 && args[0] == "migrate" {
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			os.Exit(2)
+		}
+		migrateCommand, args = args[1], args[2:]
+	}
+
+	cfg, err := config.LoadConfig(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
 
-	connString := cfg.DatabaseURL
-	if connString == "" {
-		log.Fatal("Database connection string not set")
+	logger, err := logging.NewLogger(os.Stdout, cfg.LogFormat, cfg.LogLevel)
+	if err != nil {
+		fatal(slog.Default(), "Failed to create logger", err)
 	}
+	slog.SetDefault(logger)
 
 	ctx := context.Background()
 
-	dbpool, err := pgxpool.New(ctx, connString)
+	if migrateCommand != "" {
+		if err := runMigrate(ctx, cfg, logger, migrateCommand); err != nil {
+			fatal(logger, "Migration failed", err)
+		}
+		return
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg)
 	if err != nil {
-		log.Fatal(err)
+		fatal(logger, "Failed to set up tracing", err)
+	}
+
+	store, err := openStorage(ctx, cfg, logger)
+	if err != nil {
+		fatal(logger, "Failed to open storage", err)
+	}
+
+	if cfg.MigrateOnStart && store.migrator != nil {
+		if err := migrateOnStart(ctx, store.migrator, logger); err != nil {
+			store.close()
+			fatal(logger, "Failed to apply migrations", err)
+		}
+	}
+
+	checker := health.NewChecker(cfg.HealthCheckTimeout, store.readinessChecks(cfg)...)
+
+	driver := store.quoteDriver
+	var service services.QuoteServiceInterface = services.NewQuoteService(driver, services.ValidationRules{
+		MaxAuthorLength:         cfg.ValidationMaxAuthorLength,
+		MaxTextLength:           cfg.ValidationMaxTextLength,
+		MaxTags:                 cfg.ValidationMaxTags,
+		MaxTagLength:            cfg.ValidationMaxTagLength,
+		MaxBatchOperations:      cfg.ValidationMaxBatchOperations,
+		NormalizeUnicode:        cfg.ValidationNormalizeUnicode,
+		CollapseWhitespace:      cfg.ValidationCollapseWhitespace,
+		RejectControlCharacters: cfg.ValidationRejectControlCharacters,
+		RejectHtml:              cfg.ValidationRejectHtml,
+	})
+	var cache *services.CachingQuoteService
+	if cfg.CacheEnabled {
+		cache = services.NewCachingQuoteService(service, cfg.CacheSize, cfg.CacheTTL)
+		service = cache
 	}
-	defer dbpool.Close()
+	service = services.NewTracingQuoteService(service)
 
-	driver := drivers.NewQuoteDriver(dbpool)
-	service := services.NewQuoteService(driver)
-	controller := api.NewQuoteController(service)
+	// Both dates were validated when loading the configuration.
+	deprecatedAt, _ := config.ParseDate(cfg.LegacyPathsDeprecatedAt)
+	sunset, _ := config.ParseDate(cfg.LegacyPathsSunset)
+	controller := api.NewQuoteController(service, api.Deprecation{DeprecatedAt: deprecatedAt, Sunset: sunset})
+
+	stopListening := func() {}
+	var events *services.QuoteEventBroker
+	if store.changes != nil {
+		if cache != nil {
+			store.changes.OnChange(func(change drivers.QuoteChange) {
+				if change.Operation == drivers.QuotesResync {
+					cache.InvalidateAll()
+				} else {
+					cache.Invalidate(change.Id)
+				}
+			})
+		}
+		events = services.NewQuoteEventBroker(service, cfg.StreamHistorySize)
+		store.changes.OnChange(events.Publish)
+		controller.SetEventBroker(events, cfg.StreamHeartbeatInterval)
+		stopListening = runInBackground(store.changes.Run)
+	}
+	healthController := api.NewHealthController(checker)
+	var apiKeys []string
+	if cfg.AuthEnabled {
+		apiKeys = cfg.AuthApiKeys
+	}
 
 	router := mux.NewRouter()
+	router.NotFoundHandler = http.HandlerFunc(api.NotFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(api.MethodNotAllowed)
 	controller.RegisterRoutes(router)
+	if cfg.AuthEnabled {
+		api.NewAuthorController(service, apiKeys).RegisterRoutes(router)
+	}
+	healthController.RegisterRoutes(router)
+	api.NewGraphqlController(service, apiKeys).RegisterRoutes(router)
+	rotation := api.NewRotationController(service, api.RotationLimits{
+		MaxConnections:          cfg.WebsocketMaxConnections,
+		MaxConnectionsPerClient: cfg.WebsocketMaxConnectionsPerClient,
+		MinInterval:             cfg.WebsocketMinInterval,
+		DefaultInterval:         cfg.WebsocketDefaultInterval,
+		PingInterval:            cfg.WebsocketPingInterval,
+	}, cfg.CorsAllowedOrigins)
+	rotation.RegisterRoutes(router)
+	api.NewDocsController().RegisterRoutes(router)
+	if cache != nil {
+		api.NewMetricsController(cache).RegisterRoutes(router)
+	}
+	stopRelaying, stopDispatching := func() {}, func() {}
+	if store.outbox != nil {
+		api.NewWebhookController(services.NewWebhookService(store.webhooks), apiKeys).RegisterRoutes(router)
+		relay := services.NewOutboxRelay(store.outbox, outboxSinks(cfg, store, logger), services.OutboxOptions{
+			BatchSize:    cfg.OutboxBatchSize,
+			PollInterval: cfg.OutboxPollInterval,
+			Retention:    cfg.OutboxRetention,
+		}, logger)
+		stopRelaying = runInBackground(relay.Run)
+		dispatcher := services.NewWebhookDispatcher(store.webhooks, services.WebhookOptions{
+			MaxAttempts:         cfg.WebhookMaxAttempts,
+			RetryBaseDelay:      cfg.WebhookRetryBaseDelay,
+			RetryMaxDelay:       cfg.WebhookRetryMaxDelay,
+			Timeout:             cfg.WebhookTimeout,
+			PollInterval:        cfg.WebhookPollInterval,
+			AllowPrivateTargets: cfg.WebhookAllowPrivateTargets,
+		}, logger)
+		stopDispatching = runInBackground(dispatcher.Run)
+	}
 
-	addr := ":" + cfg.Port
-	log.Printf("Server listening on %s", addr)
+	var handler http.Handler = router
+	if len(cfg.DatabaseReplicaURLs) > 0 {
+		handler = api.ReadYourWritesMiddleware(cfg.ReadYourWritesWindow, api.GraphqlPath)(handler)
+	}
+	if cfg.AuthEnabled {
+		handler = api.AuthMiddleware(cfg.AuthApiKeys, api.GraphqlPath)(handler)
+	}
+	handler = api.BodyLimitMiddleware(cfg.MaxBodyBytes)(handler)
+	handler = api.CorsMiddleware(cfg.CorsAllowedOrigins, cfg.CorsAllowedMethods, cfg.CorsAllowedHeaders, cfg.CorsMaxAge)(handler)
+	if cfg.CompressionEnabled {
+		handler = api.CompressionMiddleware(cfg.CompressionMinSize)(handler)
+	}
+	handler = api.RequestIdMiddleware(logger)(api.AccessLogMiddleware(handler))
+
+	srv := server.NewServer(cfg, handler, checker, logger)
+	if events != nil {
+		srv.OnShutdown(events.Close)
+	}
+	srv.OnShutdown(rotation.Close)
+	if cfg.GrpcEnabled {
+		srv.SetGrpcServer(api.NewGrpcServer(service, checker, logger, apiKeys))
+	}
+	err = srv.Run(ctx)
+
+	// Storage is closed only after in-flight requests have drained.
+	stopListening()
+	stopRelaying()
+	stopDispatching()
+	store.close()
 
-	if err := http.ListenAndServe(addr, router); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	flushCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	if err := shutdownTracing(flushCtx); err != nil {
+		logger.Error("Failed to flush traces", "error", err)
 	}
+	cancel()
+
+	if err != nil {
+		fatal(logger, "Server failed", err)
+	}
+
+	logger.Info("Server stopped")
+}
+
+// outboxSinks returns the sinks quote events are relayed to: the webhooks
+// and those configured.
+func outboxSinks(cfg *config.Config, store *storage, logger *slog.Logger) []services.OutboxSink {
+	sinks := []services.OutboxSink{services.NewWebhookSink(store.webhooks)}
+	for _, name := range cfg.OutboxSinks {
+		switch name {
+		case "log":
+			sinks = append(sinks, services.NewLogSink(logger))
+		case "http":
+			sinks = append(sinks, services.NewHttpSink(cfg.OutboxHttpURL, cfg.OutboxHttpTimeout))
+		case "file":
+			sinks = append(sinks, services.NewFileSink(cfg.OutboxFilePath))
+		}
+	}
+
+	return sinks
+}
+
+// runInBackground runs fn in a goroutine until the returned function is
+// called, which waits for fn to return.
+func runInBackground(fn func(context.Context)) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		fn(ctx)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func fatal(logger *slog.Logger, message string, err error) {
+	logger.Error(message, "error", err)
+	os.Exit(1)
 }
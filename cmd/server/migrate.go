@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"quotes/internal/config"
+	"quotes/internal/drivers"
+)
+
+const migrateUsage = "usage: server migrate up|down|status [flags]"
+
+// runMigrate implements the "migrate" subcommand.
+func runMigrate(ctx context.Context, cfg *config.Config, logger *slog.Logger, command string) error {
+	store, err := openStorage(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer store.close()
+
+	migrator := store.migrator
+	if migrator == nil {
+		return errNoMigrations
+	}
+
+	switch command {
+	case "up":
+		results, err := migrator.Up(ctx)
+		for _, result := range results {
+			logger.Info("Applied migration", "version", result.Source.Version, "duration", result.Duration)
+		}
+		if err == nil && len(results) == 0 {
+			logger.Info("No pending migrations")
+		}
+		return err
+	case "down":
+		result, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		logger.Info("Rolled back migration", "version", result.Source.Version, "duration", result.Duration)
+		return nil
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tSTATE\tAPPLIED AT\tFILE")
+		for _, status := range statuses {
+			appliedAt := "-"
+			if !status.AppliedAt.IsZero() {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Source.Version, status.State, appliedAt, status.Source.Path)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q, %s", command, migrateUsage)
+	}
+}
+
+// migrateOnStart applies pending migrations before the server starts.
+func migrateOnStart(ctx context.Context, migrator *drivers.Migrator, logger *slog.Logger) error {
+	results, err := migrator.Up(ctx)
+	for _, result := range results {
+		logger.Info("Applied migration", "version", result.Source.Version, "duration", result.Duration)
+	}
+
+	return err
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"quotes/internal/config"
+	"quotes/internal/drivers"
+	"quotes/internal/health"
+)
+
+// storage bundles the drivers of the backend selected by the scheme of
+// Config.DatabaseURL.
+type storage struct {
+	quoteDriver  drivers.QuoteDriverInterface
+	healthDriver drivers.HealthDriverInterface  // nil for backends without a database server
+	migrator     *drivers.Migrator              // nil for backends without a schema
+	changes      *drivers.QuoteChangeListener   // nil for backends shared by a single process
+	outbox       drivers.OutboxDriverInterface  // nil for backends without an outbox
+	webhooks     drivers.WebhookDriverInterface // nil for backends without an outbox
+	close        func()
+}
+
+func openStorage(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*storage, error) {
+	databaseURL, err := url.Parse(cfg.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch databaseURL.Scheme {
+	case "memory":
+		return &storage{quoteDriver: drivers.NewMemoryQuoteDriver(), close: func() {}}, nil
+	case "postgres", "postgresql":
+		return openPostgresStorage(ctx, cfg, logger)
+	case "sqlite":
+		return openSQLiteStorage(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("unsupported database scheme %q", databaseURL.Scheme)
+	}
+}
+
+func openPostgresStorage(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*storage, error) {
+	dbpool, err := newPool(ctx, cfg, cfg.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database pool: %w", err)
+	}
+
+	healthDriver := drivers.NewHealthDriver(dbpool)
+
+	pingCtx, cancel := context.WithTimeout(ctx, cfg.HealthCheckTimeout)
+	err = healthDriver.Ping(pingCtx)
+	cancel()
+	if err != nil {
+		dbpool.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	migrator, err := drivers.NewPostgresMigrator(dbpool)
+	if err != nil {
+		dbpool.Close()
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	adapter := newAdapter(cfg, dbpool)
+	var quoteDriver drivers.QuoteDriverInterface = drivers.NewQuoteDriver(adapter)
+	closeReplicas := func() {}
+
+	if len(cfg.DatabaseReplicaURLs) > 0 {
+		quoteDriver, closeReplicas, err = openReplicas(ctx, cfg, quoteDriver, logger)
+		if err != nil {
+			migrator.Close()
+			dbpool.Close()
+			return nil, err
+		}
+	}
+
+	return &storage{
+		quoteDriver:  quoteDriver,
+		healthDriver: healthDriver,
+		migrator:     migrator,
+		changes:      drivers.NewQuoteChangeListener(dbpool, logger),
+		outbox:       drivers.NewOutboxDriver(adapter),
+		webhooks:     drivers.NewWebhookDriver(adapter),
+		close: func() {
+			closeReplicas()
+			migrator.Close()
+			dbpool.Close()
+		},
+	}, nil
+}
+
+// openReplicas routes reads of the primary driver to the configured
+// replicas and keeps checking their health in the background.
+func openReplicas(ctx context.Context, cfg *config.Config, primary drivers.QuoteDriverInterface, logger *slog.Logger) (drivers.QuoteDriverInterface, func(), error) {
+	var pools []*pgxpool.Pool
+	var replicas []*drivers.Replica
+
+	for i, replicaURL := range cfg.DatabaseReplicaURLs {
+		pool, err := newPool(ctx, cfg, replicaURL)
+		if err != nil {
+			for _, pool := range pools {
+				pool.Close()
+			}
+			return nil, nil, fmt.Errorf("failed to create pool for replica %d: %w", i+1, err)
+		}
+
+		pools = append(pools, pool)
+		replicas = append(replicas, drivers.NewReplica(
+			"replica-"+strconv.Itoa(i+1),
+			drivers.NewQuoteDriver(newAdapter(cfg, pool)),
+			drivers.NewHealthDriver(pool),
+		))
+	}
+
+	driver := drivers.NewReplicatedQuoteDriver(primary, replicas...)
+
+	checkCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		driver.RunHealthChecks(checkCtx, cfg.ReplicaCheckInterval, cfg.HealthCheckTimeout, logger)
+	}()
+
+	return driver, func() {
+		cancel()
+		<-done
+		for _, pool := range pools {
+			pool.Close()
+		}
+	}, nil
+}
+
+func openSQLiteStorage(ctx context.Context, cfg *config.Config) (*storage, error) {
+	connectCtx, cancel := context.WithTimeout(ctx, cfg.DatabaseConnectTimeout)
+	db, err := drivers.OpenSQLite(connectCtx, drivers.SQLitePath(cfg.DatabaseURL), cfg.DatabaseMaxConns)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	migrator, err := drivers.NewSQLiteMigrator(db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	return &storage{
+		quoteDriver:  drivers.NewSQLiteQuoteDriver(db),
+		healthDriver: drivers.NewSQLiteHealthDriver(db),
+		migrator:     migrator,
+		close:        func() { db.Close() },
+	}, nil
+}
+
+// newAdapter wraps the pool with tracing of every attempt and with retries
+// and a circuit breaker of its own.
+func newAdapter(cfg *config.Config, pool *pgxpool.Pool) drivers.Adapter {
+	policy := drivers.RetryPolicy{
+		Attempts:  cfg.DatabaseRetryAttempts,
+		BaseDelay: cfg.DatabaseRetryBaseDelay,
+		MaxDelay:  cfg.DatabaseRetryMaxDelay,
+	}
+	breaker := drivers.NewCircuitBreaker(cfg.DatabaseBreakerFailures, cfg.DatabaseBreakerCooldown)
+
+	return drivers.NewResilientAdapter(drivers.NewTracingAdapter(pool), policy, breaker)
+}
+
+func newPool(ctx context.Context, cfg *config.Config, databaseURL string) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	poolConfig.MaxConns = int32(cfg.DatabaseMaxConns)
+	poolConfig.MinConns = int32(cfg.DatabaseMinConns)
+	poolConfig.MaxConnLifetime = cfg.DatabaseMaxConnLife
+	poolConfig.MaxConnIdleTime = cfg.DatabaseMaxConnIdle
+	poolConfig.ConnConfig.ConnectTimeout = cfg.DatabaseConnectTimeout
+
+	return pgxpool.NewWithConfig(ctx, poolConfig)
+}
+
+// readinessChecks returns the checks that apply to the storage backend.
+func (s *storage) readinessChecks(cfg *config.Config) []health.Check {
+	var checks []health.Check
+
+	if s.healthDriver != nil {
+		checks = append(checks, health.DatabaseCheck(s.healthDriver))
+	}
+
+	if s.healthDriver != nil && s.migrator != nil {
+		expectedVersion := cfg.MigrationVersion
+		if expectedVersion == 0 {
+			expectedVersion = s.migrator.LatestVersion()
+		}
+		checks = append(checks, health.MigrationCheck(s.healthDriver, expectedVersion))
+	}
+
+	return checks
+}
+
+var errNoMigrations = errors.New("the selected storage backend has no migrations")
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	cfg, err := LoadConfig(nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "8080", cfg.Port)
+	assert.Equal(t, 10, cfg.DatabaseMaxConns)
+	assert.Equal(t, 15*time.Second, cfg.ReadTimeout)
+	assert.Equal(t, "info", cfg.LogLevel)
+	assert.Equal(t, []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}, cfg.CorsAllowedMethods)
+	assert.Empty(t, cfg.CorsAllowedOrigins)
+	assert.False(t, cfg.AuthEnabled)
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+server_port: 7000
+log_level: debug
+read_timeout: 3s
+cors_allowed_origins:
+  - https://a.example
+  - https://b.example
+`)
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("SERVER_PORT", "7001")
+	t.Setenv("DB_CONNECTION_STRING", "postgres://user@db/quotes")
+
+	cfg, err := LoadConfig([]string{"--log-level", "warn"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "7001", cfg.Port)
+	assert.Equal(t, "warn", cfg.LogLevel)
+	assert.Equal(t, 3*time.Second, cfg.ReadTimeout)
+	assert.Equal(t, "postgres://user@db/quotes", cfg.DatabaseURL)
+	assert.Equal(t, []string{"https://a.example", "https://b.example"}, cfg.CorsAllowedOrigins)
+}
+
+func TestLoadConfigEmptyEnvironmentVariableOverridesFile(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+legacy_paths_deprecated_at: "2026-11-01"
+legacy_paths_sunset: "2027-05-01"
+`)
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("LEGACY_PATHS_SUNSET", "")
+
+	cfg, err := LoadConfig(nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "2026-11-01", cfg.LegacyPathsDeprecatedAt)
+	assert.Empty(t, cfg.LegacyPathsSunset)
+}
+
+func TestLoadConfigCanonicalNameWinsOverAlias(t *testing.T) {
+	t.Setenv("PORT", "7000")
+	t.Setenv("SERVER_PORT", "7001")
+
+	cfg, err := LoadConfig(nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "7001", cfg.Port)
+}
+
+func TestLoadConfigTomlFile(t *testing.T) {
+	path := writeFile(t, "config.toml", `
+db_max_conns = 20
+tracing_sample_ratio = 0.5
+auth_enabled = true
+auth_api_keys = ["first", "second"]
+`)
+
+	cfg, err := LoadConfig([]string{"--config", path})
+	require.NoError(t, err)
+
+	assert.Equal(t, 20, cfg.DatabaseMaxConns)
+	assert.Equal(t, 0.5, cfg.TracingSampleRatio)
+	assert.True(t, cfg.AuthEnabled)
+	assert.Equal(t, []string{"first", "second"}, cfg.AuthApiKeys)
+}
+
+func TestLoadConfigListsAllProblems(t *testing.T) {
+	path := writeFile(t, "config.yaml", "unknown_setting: 1\n")
+	t.Setenv("READ_TIMEOUT", "soon")
+	t.Setenv("LOG_LEVEL", "loud")
+	t.Setenv("AUTH_ENABLED", "true")
+
+	_, err := LoadConfig([]string{"--config", path, "--server-port", "99999", "--db-min-conns", "50"})
+	require.Error(t, err)
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.ElementsMatch(t, []string{
+		"unknown_setting (" + path + "): unknown setting",
+		`read_timeout (environment READ_TIMEOUT): "soon" is not a valid duration, expected e.g. 5s or 1m30s`,
+		"db_min_conns: must be between 0 and db_max_conns",
+		`server_port: "99999" is not a valid port`,
+		`log_level: "loud" must be one of debug, info, warn, error`,
+		"auth_api_keys: at least one key is required when auth_enabled is true",
+	}, validationErr.Problems)
+}
+
+func TestLoadConfigDoesNotEchoSecrets(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://user:p@ss@%zz")
+
+	_, err := LoadConfig(nil)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "p@ss")
+}
+
+func TestLoadConfigRejectsUnknownDatabaseScheme(t *testing.T) {
+	t.Setenv("DATABASE_URL", "mysql://localhost/quotes")
+
+	_, err := LoadConfig(nil)
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{`database_url: scheme "mysql" must be one of postgres, postgresql, sqlite, memory`}, validationErr.Problems)
+}
+
+func TestLoadConfigRequiresSettingsOfOutboxSinks(t *testing.T) {
+	_, err := LoadConfig([]string{"--outbox-sinks", "log,http,file,kafka"})
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.ElementsMatch(t, []string{
+		`outbox_sinks: "kafka" must be one of log, http, file`,
+		"outbox_http_url: must be an http or https URL when outbox_sinks includes http",
+		"outbox_file_path: is required when outbox_sinks includes file",
+	}, validationErr.Problems)
+
+	cfg, err := LoadConfig([]string{"--outbox-sinks", "http,file", "--outbox-http-url", "https://example.com/events", "--outbox-file-path", "events.jsonl"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http", "file"}, cfg.OutboxSinks)
+}
+
+func TestLoadConfigRejectsGrpcPortOfHttp(t *testing.T) {
+	_, err := LoadConfig([]string{"--server-port", "9090"})
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{"grpc_port: must differ from server_port"}, validationErr.Problems)
+
+	cfg, err := LoadConfig([]string{"--server-port", "9090", "--grpc-enabled=false"})
+	require.NoError(t, err)
+	assert.False(t, cfg.GrpcEnabled)
+}
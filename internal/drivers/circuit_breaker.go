@@ -0,0 +1,79 @@
+package drivers
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// CircuitBreaker stops calls to the database after a run of consecutive
+// failures. Once the cooldown has passed a single probe call is let through;
+// its success closes the breaker, its failure opens it again.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	state     breakerState
+	openedAt  time.Time
+	now       func() time.Time
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// Allow returns a *CircuitOpenError while calls must fail fast.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		remaining := b.cooldown - b.now().Sub(b.openedAt)
+		if remaining > 0 {
+			return &CircuitOpenError{RetryAfter: remaining}
+		}
+		b.state = breakerHalfOpen
+		return nil
+	case breakerHalfOpen:
+		return &CircuitOpenError{RetryAfter: b.cooldown}
+	default:
+		return nil
+	}
+}
+
+// Record updates the breaker with the outcome of an allowed call. Only
+// errors meaning the database is unreachable count as failures.
+func (b *CircuitBreaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil || !isUnavailableError(err) {
+		b.failures = 0
+		b.state = breakerClosed
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+type CircuitOpenError struct {
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return "database unavailable, retry after " + strconv.Itoa(int(e.RetryAfter.Seconds()+0.999)) + "s"
+}
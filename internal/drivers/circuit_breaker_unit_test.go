@@ -0,0 +1,62 @@
+package drivers
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(2, 10*time.Second)
+	breaker.now = func() time.Time { return now }
+
+	unavailable := &pgconn.PgError{Code: "57P03"}
+
+	require.NoError(t, breaker.Allow())
+	breaker.Record(unavailable)
+	require.NoError(t, breaker.Allow())
+	breaker.Record(unavailable)
+
+	err := breaker.Allow()
+	var openErr *CircuitOpenError
+	require.ErrorAs(t, err, &openErr)
+	assert.Equal(t, 10*time.Second, openErr.RetryAfter)
+}
+
+func TestCircuitBreakerIgnoresQueryErrors(t *testing.T) {
+	breaker := NewCircuitBreaker(1, time.Second)
+
+	breaker.Record(pgx.ErrNoRows)
+	breaker.Record(&pgconn.PgError{Code: "23505"})
+
+	assert.NoError(t, breaker.Allow())
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(1, 10*time.Second)
+	breaker.now = func() time.Time { return now }
+
+	breaker.Record(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+	assert.Error(t, breaker.Allow(), "network errors should open the breaker")
+
+	now = now.Add(10 * time.Second)
+	require.NoError(t, breaker.Allow(), "a probe is allowed after the cooldown")
+	assert.Error(t, breaker.Allow(), "only one probe is allowed at a time")
+
+	breaker.Record(&pgconn.PgError{Code: "08006"})
+	assert.Error(t, breaker.Allow(), "a failed probe opens the breaker again")
+
+	now = now.Add(10 * time.Second)
+	require.NoError(t, breaker.Allow())
+	breaker.Record(nil)
+	assert.NoError(t, breaker.Allow(), "a successful probe closes the breaker")
+	assert.NoError(t, breaker.Allow())
+}
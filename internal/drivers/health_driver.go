@@ -0,0 +1,28 @@
+package drivers
+
+import "context"
+
+type HealthDriver struct {
+	adapter Adapter
+}
+
+func NewHealthDriver(adapter Adapter) *HealthDriver {
+	return &HealthDriver{adapter: adapter}
+}
+
+func (d *HealthDriver) Ping(ctx context.Context) error {
+	_, err := d.adapter.Exec(ctx, queryPing)
+
+	return err
+}
+
+func (d *HealthDriver) GetMigrationVersion(ctx context.Context) (int64, error) {
+	var version int64
+
+	err := d.adapter.QueryRow(ctx, queryGetMigrationVersion).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
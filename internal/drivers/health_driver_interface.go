@@ -0,0 +1,8 @@
+package drivers
+
+import "context"
+
+type HealthDriverInterface interface {
+	Ping(ctx context.Context) error
+	GetMigrationVersion(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,244 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"quotes/internal/models"
+)
+
+// MemoryQuoteDriver keeps quotes in process memory. It is safe for
+// concurrent use and mirrors the semantics of QuoteDriver, including
+// returning pgx.ErrNoRows for missing quotes, so it can replace PostgreSQL
+// in tests and local demos.
+type MemoryQuoteDriver struct {
+	mu     sync.RWMutex
+	quotes map[pgtype.UUID]models.Quote
+	// order holds the ids sorted like PostgreSQL sorts them, which is the
+	// order quotes are listed in.
+	order      []pgtype.UUID
+	modifiedAt time.Time
+}
+
+func NewMemoryQuoteDriver() *MemoryQuoteDriver {
+	return &MemoryQuoteDriver{quotes: make(map[pgtype.UUID]models.Quote), modifiedAt: time.Now()}
+}
+
+func (d *MemoryQuoteDriver) CreateQuote(ctx context.Context, quote *models.Quote) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.quotes[quote.Id]; ok {
+		return &pgconn.PgError{
+			Severity:       "ERROR",
+			Code:           "23505",
+			Message:        `duplicate key value violates unique constraint "quotes_pkey"`,
+			TableName:      "quotes",
+			ConstraintName: "quotes_pkey",
+		}
+	}
+
+	d.modifiedAt = time.Now()
+	quote.Version = 1
+	quote.UpdatedAt = d.modifiedAt
+
+	d.quotes[quote.Id] = cloneQuote(*quote)
+	i, _ := slices.BinarySearchFunc(d.order, quote.Id, compareUuids)
+	d.order = slices.Insert(d.order, i, quote.Id)
+
+	return nil
+}
+
+func (d *MemoryQuoteDriver) UpdateQuote(ctx context.Context, quote *models.Quote, version int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stored, ok := d.quotes[quote.Id]
+	if !ok {
+		return pgx.ErrNoRows
+	}
+	if version != 0 && stored.Version != version {
+		return &VersionConflictError{Id: quote.Id, Version: version}
+	}
+
+	d.modifiedAt = time.Now()
+	quote.Version = stored.Version + 1
+	quote.UpdatedAt = d.modifiedAt
+
+	d.quotes[quote.Id] = cloneQuote(*quote)
+
+	return nil
+}
+
+func (d *MemoryQuoteDriver) DeleteQuote(ctx context.Context, id pgtype.UUID, version int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stored, ok := d.quotes[id]
+	if !ok {
+		if version != 0 {
+			return pgx.ErrNoRows
+		}
+		return nil
+	}
+	if version != 0 && stored.Version != version {
+		return &VersionConflictError{Id: id, Version: version}
+	}
+
+	d.modifiedAt = time.Now()
+	delete(d.quotes, id)
+	i, _ := slices.BinarySearchFunc(d.order, id, compareUuids)
+	d.order = slices.Delete(d.order, i, i+1)
+
+	return nil
+}
+
+func (d *MemoryQuoteDriver) GetAllQuotes(ctx context.Context) ([]models.Quote, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var quotes []models.Quote
+	for _, id := range d.order {
+		quotes = append(quotes, cloneQuote(d.quotes[id]))
+	}
+
+	return quotes, nil
+}
+
+func (d *MemoryQuoteDriver) GetQuotesByAuthor(ctx context.Context, author string) ([]models.Quote, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var quotes []models.Quote
+	for _, id := range d.order {
+		if quote := d.quotes[id]; quote.Author == author {
+			quotes = append(quotes, cloneQuote(quote))
+		}
+	}
+
+	return quotes, nil
+}
+
+func (d *MemoryQuoteDriver) GetQuotesByAuthors(ctx context.Context, authors []string) ([]models.Quote, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var quotes []models.Quote
+	for _, id := range d.order {
+		if quote := d.quotes[id]; slices.Contains(authors, quote.Author) {
+			quotes = append(quotes, cloneQuote(quote))
+		}
+	}
+
+	return quotes, nil
+}
+
+func (d *MemoryQuoteDriver) GetQuotesByTags(ctx context.Context, tags []string) ([]models.Quote, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var quotes []models.Quote
+	for _, id := range d.order {
+		quote := d.quotes[id]
+		if slices.ContainsFunc(quote.Tags, func(tag string) bool { return slices.Contains(tags, tag) }) {
+			quotes = append(quotes, cloneQuote(quote))
+		}
+	}
+
+	return quotes, nil
+}
+
+func (d *MemoryQuoteDriver) GetQuotesPage(ctx context.Context, author string, after pgtype.UUID, limit int) ([]models.Quote, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	start := 0
+	if after.Valid {
+		i, found := slices.BinarySearchFunc(d.order, after, compareUuids)
+		start = i
+		if found {
+			start++
+		}
+	}
+
+	var quotes []models.Quote
+	for _, id := range d.order[start:] {
+		if len(quotes) == limit {
+			break
+		}
+		if quote := d.quotes[id]; author == "" || quote.Author == author {
+			quotes = append(quotes, cloneQuote(quote))
+		}
+	}
+
+	return quotes, nil
+}
+
+func (d *MemoryQuoteDriver) GetRandomQuote(ctx context.Context) (*models.Quote, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if len(d.order) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	quote := cloneQuote(d.quotes[d.order[rand.IntN(len(d.order))]])
+
+	return &quote, nil
+}
+
+func (d *MemoryQuoteDriver) GetQuoteById(ctx context.Context, id pgtype.UUID) (*models.Quote, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	quote, ok := d.quotes[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+
+	quote = cloneQuote(quote)
+	return &quote, nil
+}
+
+func (d *MemoryQuoteDriver) GetLastModified(ctx context.Context) (time.Time, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.modifiedAt, nil
+}
+
+// InTransaction runs fn on a copy of the quotes, which replaces them if fn
+// succeeds. Every other call waits until the transaction ends.
+func (d *MemoryQuoteDriver) InTransaction(ctx context.Context, fn func(tx QuoteDriverInterface) error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	tx := &MemoryQuoteDriver{quotes: maps.Clone(d.quotes), order: slices.Clone(d.order), modifiedAt: d.modifiedAt}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	d.quotes, d.order, d.modifiedAt = tx.quotes, tx.order, tx.modifiedAt
+
+	return nil
+}
+
+// compareUuids orders UUIDs by their bytes, like PostgreSQL.
+func compareUuids(a, b pgtype.UUID) int {
+	return bytes.Compare(a.Bytes[:], b.Bytes[:])
+}
+
+// cloneQuote copies the tags of a quote, so that callers and the driver
+// never share them.
+func cloneQuote(quote models.Quote) models.Quote {
+	quote.Tags = nilIfEmpty(slices.Clone(quote.Tags))
+	return quote
+}
@@ -0,0 +1,46 @@
+package drivers
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/models"
+)
+
+func TestMemoryQuoteDriverConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	driver := NewMemoryQuoteDriver()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			quote := &models.Quote{
+				Id:     pgtype.UUID{Bytes: uuid.New(), Valid: true},
+				Author: "author" + strconv.Itoa(i%5),
+				Text:   "text" + strconv.Itoa(i),
+			}
+			assert.NoError(t, driver.CreateQuote(ctx, quote))
+
+			_, err := driver.GetRandomQuote(ctx)
+			assert.NoError(t, err)
+
+			if i%2 == 0 {
+				assert.NoError(t, driver.DeleteQuote(ctx, quote.Id, 0))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	quotes, err := driver.GetAllQuotes(ctx)
+	require.NoError(t, err)
+	assert.Len(t, quotes, 25)
+}
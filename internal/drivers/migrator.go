@@ -0,0 +1,76 @@
+package drivers
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+	"quotes/migrations"
+)
+
+// Migrator applies the embedded goose migrations.
+type Migrator struct {
+	db       *sql.DB
+	provider *goose.Provider
+}
+
+func NewMigrator(db *sql.DB, dialect goose.Dialect, fsys fs.FS, opts ...goose.ProviderOption) (*Migrator, error) {
+	provider, err := goose.NewProvider(dialect, db, fsys, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, provider: provider}, nil
+}
+
+// NewPostgresMigrator runs the embedded PostgreSQL migrations over the pool.
+// A session-level advisory lock keeps concurrently starting instances from
+// applying the same migration twice.
+func NewPostgresMigrator(pool *pgxpool.Pool) (*Migrator, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+
+	db := stdlib.OpenDBFromPool(pool)
+
+	migrator, err := NewMigrator(db, goose.DialectPostgres, migrations.Postgres, goose.WithSessionLocker(locker))
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return migrator, nil
+}
+
+func (m *Migrator) Up(ctx context.Context) ([]*goose.MigrationResult, error) {
+	return m.provider.Up(ctx)
+}
+
+func (m *Migrator) Down(ctx context.Context) (*goose.MigrationResult, error) {
+	return m.provider.Down(ctx)
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	return m.provider.Status(ctx)
+}
+
+// LatestVersion returns the version of the newest embedded migration.
+func (m *Migrator) LatestVersion() int64 {
+	sources := m.provider.ListSources()
+	if len(sources) == 0 {
+		return 0
+	}
+
+	return sources[len(sources)-1].Version
+}
+
+// Close closes the database handle the migrator was created with. For
+// PostgreSQL that handle wraps the pool, which is left open.
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
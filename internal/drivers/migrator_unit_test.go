@@ -0,0 +1,26 @@
+package drivers
+
+import (
+	"database/sql"
+	"io/fs"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"quotes/migrations"
+)
+
+func TestMigratorLatestVersion(t *testing.T) {
+	db, err := sql.Open("pgx", "postgres://localhost/unused")
+	require.NoError(t, err)
+
+	migrator, err := NewMigrator(db, goose.DialectPostgres, migrations.Postgres)
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	files, err := fs.Glob(migrations.Postgres, "*.sql")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(files)), migrator.LatestVersion())
+}
@@ -0,0 +1,166 @@
+package drivers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"quotes/internal/models"
+)
+
+// Types of the events recorded in the outbox.
+const (
+	OutboxQuoteCreated = "quote.created"
+	OutboxQuoteUpdated = "quote.updated"
+	OutboxQuoteDeleted = "quote.deleted"
+)
+
+// OutboxEventTypes lists every event type, in the order of the lifecycle of
+// a quote.
+var OutboxEventTypes = []string{OutboxQuoteCreated, OutboxQuoteUpdated, OutboxQuoteDeleted}
+
+// outboxQuote is the payload of quote events, in the JSON representation of
+// the API with the version and modification time added.
+type outboxQuote struct {
+	Id        pgtype.UUID `json:"id"`
+	Author    string      `json:"author"`
+	Text      string      `json:"text"`
+	Tags      []string    `json:"tags"`
+	Version   int64       `json:"version"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// insertOutboxEvent records an event about quote in the transaction that
+// changes it, so that the event exists if and only if the change commits.
+func insertOutboxEvent(ctx context.Context, tx pgx.Tx, eventType string, quote *models.Quote) error {
+	payload, err := json.Marshal(outboxQuote{
+		Id:        quote.Id,
+		Author:    quote.Author,
+		Text:      quote.Text,
+		Tags:      nilIfEmpty(quote.Tags),
+		Version:   quote.Version,
+		UpdatedAt: quote.UpdatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, queryInsertOutboxEvent, eventType, quote.Id, payload)
+	return err
+}
+
+// outboxLease bounds how long events are leased to the relay publishing
+// them, which is how long they wait if it stops before finishing.
+const outboxLease = 5 * time.Minute
+
+// OutboxDriverInterface reads the events recorded by CreateQuote, UpdateQuote
+// and DeleteQuote of the QuoteDriver.
+type OutboxDriverInterface interface {
+	// PublishEvents passes up to limit events that sink has not published,
+	// oldest first, to publish and records them published by sink if it
+	// succeeds. The events are leased meanwhile, so that concurrent callers
+	// get other events, but no transaction is held open while publish runs;
+	// if publish fails they are passed again by a later call. Each sink
+	// keeps its own progress, and an event counts as published once every
+	// one of sinks has published it. It returns how many events were
+	// published.
+	PublishEvents(ctx context.Context, sink string, sinks []string, limit int, publish func(ctx context.Context, events []models.OutboxEvent) error) (int, error)
+	// PruneEvents deletes the events published before the given time and
+	// their webhook deliveries, unless a delivery is still pending.
+	PruneEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+type OutboxDriver struct {
+	adapter Adapter
+}
+
+func NewOutboxDriver(adapter Adapter) *OutboxDriver {
+	return &OutboxDriver{adapter: adapter}
+}
+
+func (d *OutboxDriver) PublishEvents(ctx context.Context, sink string, sinks []string, limit int, publish func(ctx context.Context, events []models.OutboxEvent) error) (int, error) {
+	events, err := d.claimEvents(ctx, sink, limit)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+
+	ids := make([]int64, len(events))
+	for i, event := range events {
+		ids[i] = event.Id
+	}
+
+	// Publishing must end before the lease does, or another caller could
+	// claim the events meanwhile.
+	publishCtx, cancel := context.WithTimeout(ctx, outboxLease)
+	err = publish(publishCtx, events)
+	cancel()
+	if err != nil {
+		// The events are released for the next call rather than waiting for
+		// the lease to expire. A failure to release is left to the lease.
+		d.adapter.Exec(context.WithoutCancel(ctx), queryReleaseOutboxEvents, sink, ids)
+		return 0, err
+	}
+
+	err = inTx(ctx, d.adapter, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, queryLockOutboxEvents, ids); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, queryPublishOutboxEvents, sink, ids); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, queryCompleteOutboxEvents, ids, sinks)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(events), nil
+}
+
+// claimEvents leases up to limit events that sink has not published.
+func (d *OutboxDriver) claimEvents(ctx context.Context, sink string, limit int) ([]models.OutboxEvent, error) {
+	rows, err := d.adapter.Query(ctx, queryClaimOutboxEvents, sink, limit, outboxLease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.OutboxEvent
+	for rows.Next() {
+		event := models.OutboxEvent{}
+		if err := rows.Scan(&event.Id, &event.Type, &event.QuoteId, &event.Payload, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (d *OutboxDriver) PruneEvents(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := d.adapter.Exec(ctx, queryPruneOutboxEvents, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// inTx runs f in a transaction of adapter, which is committed if f succeeds
+// and rolled back otherwise.
+func inTx(ctx context.Context, adapter Adapter, f func(tx pgx.Tx) error) error {
+	tx, err := adapter.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := f(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -2,38 +2,223 @@ package drivers
 
 const (
 	queryCreateQuote = `
-	INSERT INTO quotes (id, author, text)
-	VALUES ($1, $2, $3)
+	INSERT INTO quotes (id, author, text, tags)
+	VALUES ($1, $2, $3, COALESCE($4::text[], '{}'))
+	RETURNING version, updated_at
+`
+	queryUpdateQuote = `
+	UPDATE quotes
+	SET author = $2, text = $3, tags = COALESCE($5::text[], '{}'), version = version + 1, updated_at = now()
+	WHERE id = $1 AND ($4::bigint = 0 OR version = $4)
+	RETURNING version, updated_at
 `
 	queryDeleteQuote = `
 	DELETE FROM quotes 
-	WHERE id = $1
+	WHERE id = $1 AND ($2::bigint = 0 OR version = $2)
+	RETURNING author, text, tags, version, updated_at
 `
 	queryGetAllQuotes = `
-	SELECT id, author, text
+	SELECT id, author, text, tags, version, updated_at
 	FROM quotes
+	ORDER BY id
 `
 	queryGetQuoteByAuthor = `
-	SELECT id, text
+	SELECT id, text, tags, version, updated_at
 	FROM quotes
 	WHERE author = $1
+	ORDER BY id
+`
+	queryGetQuotesByAuthors = `
+	SELECT id, author, text, tags, version, updated_at
+	FROM quotes
+	WHERE author = ANY($1)
+	ORDER BY id
+`
+	queryGetQuotesByTags = `
+	SELECT id, author, text, tags, version, updated_at
+	FROM quotes
+	WHERE tags && $1::text[]
+	ORDER BY id
+`
+	queryGetQuotesPage = `
+	SELECT id, author, text, tags, version, updated_at
+	FROM quotes
+	WHERE ($1 = '' OR author = $1) AND ($2::uuid IS NULL OR id > $2)
+	ORDER BY id
+	LIMIT $3
 `
 	queryGetRandomQuote = `
-	SELECT id, author, text
+	SELECT id, author, text, tags, version, updated_at
 	FROM quotes 
 	ORDER BY RANDOM()
 	LIMIT 1
 `
 	queryGetQuoteById = `
-	SELECT author, text
+	SELECT author, text, tags, version, updated_at
+	FROM quotes
+	WHERE id = $1
+`
+	queryGetQuoteVersion = `
+	SELECT version
 	FROM quotes
 	WHERE id = $1
 `
-	createTestSchema = `
-	CREATE TABLE IF NOT EXISTS quotes (
-		id UUID PRIMARY KEY,
-		author TEXT NOT NULL,
-		text TEXT NOT NULL
+	queryGetLastModified = `
+	SELECT GREATEST(
+		(SELECT modified_at FROM quotes_modified),
+		(SELECT MAX(updated_at) FROM quotes),
+		(SELECT MAX(deleted_at) FROM quote_deletions)
 	)
+`
+	queryPing = `
+	SELECT 1
+`
+	queryGetMigrationVersion = `
+	SELECT COALESCE(MAX(version_id), 0)
+	FROM goose_db_version
+	WHERE is_applied
+`
+	queryInsertOutboxEvent = `
+	INSERT INTO outbox_events (event_type, quote_id, payload)
+	VALUES ($1, $2, $3)
+`
+	queryCreateWebhookSubscription = `
+	INSERT INTO webhook_subscriptions (id, url, secret, event_types, active)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING created_at, updated_at
+`
+	queryUpdateWebhookSubscription = `
+	UPDATE webhook_subscriptions
+	SET url = $2, event_types = $3, active = $4, secret = COALESCE(NULLIF($5, ''), secret), updated_at = now()
+	WHERE id = $1
+	RETURNING secret, created_at, updated_at
+`
+	queryDeleteWebhookSubscription = `
+	DELETE FROM webhook_subscriptions
+	WHERE id = $1
+`
+	queryGetWebhookSubscription = `
+	SELECT url, secret, event_types, active, created_at, updated_at
+	FROM webhook_subscriptions
+	WHERE id = $1
+`
+	queryGetWebhookSubscriptions = `
+	SELECT id, url, secret, event_types, active, created_at, updated_at
+	FROM webhook_subscriptions
+	ORDER BY created_at, id
+`
+	// A concurrent claim of the same event waits for the insert of the
+	// other and then finds the event leased, so each event is claimed once.
+	queryClaimOutboxEvents = `
+	WITH candidates AS (
+		SELECT e.id
+		FROM outbox_events e
+		LEFT JOIN outbox_publications p ON p.sink = $1 AND p.event_id = e.id
+		WHERE e.published_at IS NULL AND p.published_at IS NULL
+			AND (p.leased_until IS NULL OR p.leased_until <= now())
+		ORDER BY e.id
+		LIMIT $2
+	), claimed AS (
+		INSERT INTO outbox_publications (sink, event_id, leased_until)
+		SELECT $1, id, now() + $3::double precision * interval '1 second'
+		FROM candidates
+		ON CONFLICT (sink, event_id) DO UPDATE
+		SET leased_until = EXCLUDED.leased_until
+		WHERE outbox_publications.published_at IS NULL
+			AND (outbox_publications.leased_until IS NULL OR outbox_publications.leased_until <= now())
+		RETURNING event_id
+	)
+	SELECT e.id, e.event_type, e.quote_id, e.payload, e.created_at
+	FROM claimed c
+	JOIN outbox_events e ON e.id = c.event_id
+	ORDER BY e.id
+`
+	queryReleaseOutboxEvents = `
+	UPDATE outbox_publications
+	SET leased_until = NULL
+	WHERE sink = $1 AND event_id = ANY($2) AND published_at IS NULL
+`
+	// Events are locked FOR NO KEY UPDATE while they are marked published,
+	// so that sinks finishing the same events at once see each other's
+	// publications, yet deliveries referencing them can still be inserted.
+	queryLockOutboxEvents = `
+	SELECT id
+	FROM outbox_events
+	WHERE id = ANY($1)
+	ORDER BY id
+	FOR NO KEY UPDATE
+`
+	queryPublishOutboxEvents = `
+	UPDATE outbox_publications
+	SET published_at = now(), leased_until = NULL
+	WHERE sink = $1 AND event_id = ANY($2)
+`
+	queryCompleteOutboxEvents = `
+	UPDATE outbox_events e
+	SET published_at = now()
+	WHERE e.id = ANY($1) AND NOT EXISTS (
+		SELECT 1
+		FROM unnest($2::text[]) AS s (sink)
+		WHERE NOT EXISTS (
+			SELECT 1 FROM outbox_publications p
+			WHERE p.event_id = e.id AND p.sink = s.sink AND p.published_at IS NOT NULL
+		)
+	)
+`
+	queryCreateWebhookDeliveries = `
+	INSERT INTO webhook_deliveries (subscription_id, event_id)
+	SELECT s.id, e.id
+	FROM outbox_events e
+	JOIN webhook_subscriptions s ON s.active AND e.event_type = ANY(s.event_types)
+	WHERE e.id = ANY($1)
+	ON CONFLICT DO NOTHING
+`
+	queryClaimWebhookDeliveries = `
+	WITH claimed AS (
+		UPDATE webhook_deliveries
+		SET next_attempt_at = now() + $2::double precision * interval '1 second'
+		WHERE id IN (
+			SELECT d.id
+			FROM webhook_deliveries d
+			JOIN webhook_subscriptions s ON s.id = d.subscription_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= now() AND s.active
+			ORDER BY d.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		RETURNING id, subscription_id, event_id, attempts, created_at
+	)
+	SELECT c.id, c.subscription_id, c.attempts, c.created_at,
+		e.id, e.event_type, e.quote_id, e.payload, e.created_at, s.url, s.secret
+	FROM claimed c
+	JOIN outbox_events e ON e.id = c.event_id
+	JOIN webhook_subscriptions s ON s.id = c.subscription_id
+`
+	queryRecordWebhookDelivery = `
+	UPDATE webhook_deliveries
+	SET status = $2, attempts = $3, next_attempt_at = $4, last_attempt_at = $5, last_status_code = $6, last_error = $7
+	WHERE id = $1
+`
+	queryGetWebhookDeliveries = `
+	SELECT d.id, d.status, d.attempts, d.next_attempt_at, d.last_attempt_at, d.last_status_code, COALESCE(d.last_error, ''), d.created_at,
+		e.id, e.event_type, e.quote_id, e.created_at
+	FROM webhook_deliveries d
+	JOIN outbox_events e ON e.id = d.event_id
+	WHERE d.subscription_id = $1 AND ($2::text = '' OR d.status = $2)
+	ORDER BY d.id DESC
+	LIMIT $3
+`
+	queryRetryWebhookDelivery = `
+	UPDATE webhook_deliveries
+	SET status = 'pending', attempts = 0, next_attempt_at = now()
+	WHERE id = $2 AND subscription_id = $1 AND status = 'dead'
+`
+	queryPruneOutboxEvents = `
+	DELETE FROM outbox_events
+	WHERE published_at < $1
+		AND NOT EXISTS (
+			SELECT 1 FROM webhook_deliveries
+			WHERE event_id = outbox_events.id AND status = 'pending'
+		)
 `
 )
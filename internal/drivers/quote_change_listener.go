@@ -0,0 +1,115 @@
+package drivers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// QuoteChangesChannel is the channel the quotes_notify_change trigger
+// notifies on every row change.
+const QuoteChangesChannel = "quote_changes"
+
+const (
+	QuoteInserted = "INSERT"
+	QuoteUpdated  = "UPDATE"
+	QuoteDeleted  = "DELETE"
+	// QuotesResync is reported after the listener reconnects, when changes
+	// made in the meantime have not been delivered.
+	QuotesResync = "RESYNC"
+)
+
+type QuoteChange struct {
+	Operation string      `json:"operation"`
+	Id        pgtype.UUID `json:"id"`
+	// EventId numbers the changes of all instances in the order they were
+	// made. It is 0 for resyncs.
+	EventId int64 `json:"event_id"`
+}
+
+// QuoteChangeListener delivers the quote changes committed by any instance
+// sharing the database, using PostgreSQL LISTEN/NOTIFY on a dedicated
+// connection.
+type QuoteChangeListener struct {
+	pool       *pgxpool.Pool
+	logger     *slog.Logger
+	handlers   []func(QuoteChange)
+	retryDelay time.Duration
+}
+
+func NewQuoteChangeListener(pool *pgxpool.Pool, logger *slog.Logger) *QuoteChangeListener {
+	return &QuoteChangeListener{pool: pool, logger: logger, retryDelay: time.Second}
+}
+
+// OnChange registers a handler. Handlers must be registered before Run and
+// are called sequentially from its goroutine.
+func (l *QuoteChangeListener) OnChange(handler func(QuoteChange)) {
+	l.handlers = append(l.handlers, handler)
+}
+
+// Run listens until ctx is done, reconnecting after connection failures.
+func (l *QuoteChangeListener) Run(ctx context.Context) {
+	listened := false
+
+	for {
+		err := l.listen(ctx, func() {
+			if listened {
+				l.dispatch(QuoteChange{Operation: QuotesResync})
+			}
+			listened = true
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		l.logger.Warn("Quote change listener disconnected", "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(l.retryDelay):
+		}
+	}
+}
+
+func (l *QuoteChangeListener) listen(ctx context.Context, onListening func()) error {
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The connection keeps listening for its whole life, so it is taken out
+	// of the pool instead of being returned to it.
+	listenConn := conn.Hijack()
+	defer listenConn.Close(context.Background())
+
+	if _, err := listenConn.Exec(ctx, "LISTEN "+QuoteChangesChannel); err != nil {
+		return err
+	}
+	onListening()
+
+	for {
+		notification, err := listenConn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var change QuoteChange
+		if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+			l.logger.Error("Invalid quote change notification", "payload", notification.Payload, "error", err)
+			continue
+		}
+
+		l.dispatch(change)
+	}
+}
+
+func (l *QuoteChangeListener) dispatch(change QuoteChange) {
+	for _, handler := range l.handlers {
+		handler(change)
+	}
+}
@@ -2,8 +2,11 @@ package drivers
 
 import (
 	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"quotes/internal/models"
+	"time"
 )
 
 type QuoteDriver struct {
@@ -14,44 +17,73 @@ func NewQuoteDriver(adapter Adapter) *QuoteDriver {
 	return &QuoteDriver{adapter: adapter}
 }
 
+// CreateQuote, UpdateQuote and DeleteQuote record an outbox event in the
+// transaction of the change.
 func (d *QuoteDriver) CreateQuote(ctx context.Context, quote *models.Quote) error {
-	_, err := d.adapter.Exec(
-		ctx,
-		queryCreateQuote,
-		quote.Id,
-		quote.Author,
-		quote.Text,
-	)
+	return inTx(ctx, d.adapter, func(tx pgx.Tx) error {
+		err := tx.QueryRow(
+			ctx,
+			queryCreateQuote,
+			quote.Id,
+			quote.Author,
+			quote.Text,
+			quote.Tags,
+		).Scan(&quote.Version, &quote.UpdatedAt)
+		if err != nil {
+			return err
+		}
 
-	return err
+		return insertOutboxEvent(ctx, tx, OutboxQuoteCreated, quote)
+	})
 }
 
-func (d *QuoteDriver) DeleteQuote(ctx context.Context, id pgtype.UUID) error {
-	_, err := d.adapter.Exec(ctx, queryDeleteQuote, id)
-
-	return err
-}
+func (d *QuoteDriver) UpdateQuote(ctx context.Context, quote *models.Quote, version int64) error {
+	err := inTx(ctx, d.adapter, func(tx pgx.Tx) error {
+		err := tx.QueryRow(
+			ctx,
+			queryUpdateQuote,
+			quote.Id,
+			quote.Author,
+			quote.Text,
+			version,
+			quote.Tags,
+		).Scan(&quote.Version, &quote.UpdatedAt)
+		if err != nil {
+			return err
+		}
 
-func (d *QuoteDriver) GetAllQuotes(ctx context.Context) ([]models.Quote, error) {
-	rows, err := d.adapter.Query(ctx, queryGetAllQuotes)
-	if err != nil {
-		return nil, err
+		return insertOutboxEvent(ctx, tx, OutboxQuoteUpdated, quote)
+	})
+	if errors.Is(err, pgx.ErrNoRows) && version != 0 {
+		return d.versionConflict(ctx, quote.Id, version)
 	}
-	defer rows.Close()
 
-	var quotes []models.Quote
-	for rows.Next() {
-		quote := models.Quote{}
+	return err
+}
 
-		err = rows.Scan(&quote.Id, &quote.Author, &quote.Text)
+func (d *QuoteDriver) DeleteQuote(ctx context.Context, id pgtype.UUID, version int64) error {
+	err := inTx(ctx, d.adapter, func(tx pgx.Tx) error {
+		deleted := models.Quote{Id: id}
+		err := tx.QueryRow(ctx, queryDeleteQuote, id, version).
+			Scan(&deleted.Author, &deleted.Text, &deleted.Tags, &deleted.Version, &deleted.UpdatedAt)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		quotes = append(quotes, quote)
+		return insertOutboxEvent(ctx, tx, OutboxQuoteDeleted, &deleted)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		if version != 0 {
+			return d.versionConflict(ctx, id, version)
+		}
+		return nil
 	}
 
-	return quotes, nil
+	return err
+}
+
+func (d *QuoteDriver) GetAllQuotes(ctx context.Context) ([]models.Quote, error) {
+	return d.queryQuotes(ctx, queryGetAllQuotes)
 }
 
 func (d *QuoteDriver) GetQuotesByAuthor(ctx context.Context, author string) ([]models.Quote, error) {
@@ -65,35 +97,112 @@ func (d *QuoteDriver) GetQuotesByAuthor(ctx context.Context, author string) ([]m
 	for rows.Next() {
 		quote := models.Quote{Author: author}
 
-		err = rows.Scan(&quote.Id, &quote.Text)
+		err = rows.Scan(&quote.Id, &quote.Text, &quote.Tags, &quote.Version, &quote.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
 
+		quote.Tags = nilIfEmpty(quote.Tags)
 		quotes = append(quotes, quote)
 	}
 
-	return quotes, nil
+	return quotes, rows.Err()
+}
+
+func (d *QuoteDriver) GetQuotesByAuthors(ctx context.Context, authors []string) ([]models.Quote, error) {
+	return d.queryQuotes(ctx, queryGetQuotesByAuthors, authors)
+}
+
+func (d *QuoteDriver) GetQuotesByTags(ctx context.Context, tags []string) ([]models.Quote, error) {
+	return d.queryQuotes(ctx, queryGetQuotesByTags, tags)
+}
+
+func (d *QuoteDriver) GetQuotesPage(ctx context.Context, author string, after pgtype.UUID, limit int) ([]models.Quote, error) {
+	return d.queryQuotes(ctx, queryGetQuotesPage, author, after, limit)
 }
 
 func (d *QuoteDriver) GetRandomQuote(ctx context.Context) (*models.Quote, error) {
 	quote := models.Quote{}
 
-	err := d.adapter.QueryRow(ctx, queryGetRandomQuote).Scan(&quote.Id, &quote.Author, &quote.Text)
+	err := d.adapter.QueryRow(ctx, queryGetRandomQuote).Scan(&quote.Id, &quote.Author, &quote.Text, &quote.Tags, &quote.Version, &quote.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 
+	quote.Tags = nilIfEmpty(quote.Tags)
+
 	return &quote, nil
 }
 
 func (d *QuoteDriver) GetQuoteById(ctx context.Context, id pgtype.UUID) (*models.Quote, error) {
 	quote := models.Quote{Id: id}
 
-	err := d.adapter.QueryRow(ctx, queryGetQuoteById, id).Scan(&quote.Author, &quote.Text)
+	err := d.adapter.QueryRow(ctx, queryGetQuoteById, id).Scan(&quote.Author, &quote.Text, &quote.Tags, &quote.Version, &quote.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 
+	quote.Tags = nilIfEmpty(quote.Tags)
+
 	return &quote, nil
 }
+
+func (d *QuoteDriver) GetLastModified(ctx context.Context) (time.Time, error) {
+	var modifiedAt time.Time
+	err := d.adapter.QueryRow(ctx, queryGetLastModified).Scan(&modifiedAt)
+
+	return modifiedAt, err
+}
+
+// InTransaction runs fn with a driver on a transaction, in which each change
+// runs in a savepoint of its own.
+func (d *QuoteDriver) InTransaction(ctx context.Context, fn func(tx QuoteDriverInterface) error) error {
+	return inTx(ctx, d.adapter, func(tx pgx.Tx) error {
+		return fn(NewQuoteDriver(tx))
+	})
+}
+
+// queryQuotes runs a query selecting every column of quotes.
+func (d *QuoteDriver) queryQuotes(ctx context.Context, query string, args ...any) ([]models.Quote, error) {
+	rows, err := d.adapter.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var quotes []models.Quote
+	for rows.Next() {
+		quote := models.Quote{}
+
+		err = rows.Scan(&quote.Id, &quote.Author, &quote.Text, &quote.Tags, &quote.Version, &quote.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		quote.Tags = nilIfEmpty(quote.Tags)
+		quotes = append(quotes, quote)
+	}
+
+	return quotes, rows.Err()
+}
+
+// nilIfEmpty returns nil for quotes without tags, which are read back as
+// empty arrays, so that every driver returns what was stored.
+func nilIfEmpty(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	return tags
+}
+
+// versionConflict tells a missing quote from one at another version after a
+// conditional statement matched no row.
+func (d *QuoteDriver) versionConflict(ctx context.Context, id pgtype.UUID, version int64) error {
+	var current int64
+	if err := d.adapter.QueryRow(ctx, queryGetQuoteVersion, id).Scan(&current); err != nil {
+		return err
+	}
+
+	return &VersionConflictError{Id: id, Version: version}
+}
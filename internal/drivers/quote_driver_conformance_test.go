@@ -0,0 +1,370 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/models"
+)
+
+// runQuoteDriverConformance checks that a QuoteDriverInterface implementation
+// behaves like the PostgreSQL driver. newDriver must return a driver backed
+// by empty storage.
+func runQuoteDriverConformance(t *testing.T, newDriver func(t *testing.T) QuoteDriverInterface) {
+	ctx := context.Background()
+
+	newQuote := func(author, text string) *models.Quote {
+		return &models.Quote{
+			Id:     pgtype.UUID{Bytes: uuid.New(), Valid: true},
+			Author: author,
+			Text:   text,
+		}
+	}
+
+	// sortedById lists quotes in the order the drivers list them.
+	sortedById := func(quotes ...*models.Quote) []models.Quote {
+		sorted := make([]models.Quote, len(quotes))
+		for i, quote := range quotes {
+			sorted[i] = *quote
+		}
+		slices.SortFunc(sorted, func(a, b models.Quote) int { return compareUuids(a.Id, b.Id) })
+		return sorted
+	}
+
+	t.Run("CreateQuote and GetQuoteById", func(t *testing.T) {
+		driver := newDriver(t)
+		quote := newQuote("author", "text")
+
+		require.NoError(t, driver.CreateQuote(ctx, quote))
+
+		found, err := driver.GetQuoteById(ctx, quote.Id)
+		require.NoError(t, err)
+		assert.Equal(t, quote, found)
+	})
+
+	t.Run("CreateQuote with duplicate id", func(t *testing.T) {
+		driver := newDriver(t)
+		quote := newQuote("author", "text")
+
+		require.NoError(t, driver.CreateQuote(ctx, quote))
+		assert.Error(t, driver.CreateQuote(ctx, quote))
+	})
+
+	t.Run("GetQuoteById with missing id", func(t *testing.T) {
+		driver := newDriver(t)
+
+		quote, err := driver.GetQuoteById(ctx, pgtype.UUID{Bytes: uuid.New(), Valid: true})
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.Nil(t, quote)
+	})
+
+	t.Run("GetAllQuotes", func(t *testing.T) {
+		driver := newDriver(t)
+
+		quotes, err := driver.GetAllQuotes(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, quotes)
+
+		first := newQuote("author0", "text0")
+		second := newQuote("author1", "text1")
+		require.NoError(t, driver.CreateQuote(ctx, first))
+		require.NoError(t, driver.CreateQuote(ctx, second))
+
+		quotes, err = driver.GetAllQuotes(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, sortedById(first, second), quotes)
+	})
+
+	t.Run("GetQuotesByAuthor", func(t *testing.T) {
+		driver := newDriver(t)
+
+		first := newQuote("author", "text0")
+		other := newQuote("other", "text1")
+		second := newQuote("author", "text2")
+		for _, quote := range []*models.Quote{first, other, second} {
+			require.NoError(t, driver.CreateQuote(ctx, quote))
+		}
+
+		quotes, err := driver.GetQuotesByAuthor(ctx, "author")
+		require.NoError(t, err)
+		assert.Equal(t, sortedById(first, second), quotes)
+
+		quotes, err = driver.GetQuotesByAuthor(ctx, "Author")
+		require.NoError(t, err)
+		assert.Empty(t, quotes)
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		driver := newDriver(t)
+		quote := newQuote("author", "text")
+		quote.Tags = []string{"wisdom", "life"}
+		require.NoError(t, driver.CreateQuote(ctx, quote))
+
+		found, err := driver.GetQuoteById(ctx, quote.Id)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"wisdom", "life"}, found.Tags)
+
+		updated := &models.Quote{Id: quote.Id, Author: "author", Text: "text", Tags: []string{"life"}}
+		require.NoError(t, driver.UpdateQuote(ctx, updated, 0))
+
+		quotes, err := driver.GetAllQuotes(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Quote{*updated}, quotes)
+
+		untagged := &models.Quote{Id: quote.Id, Author: "author", Text: "text"}
+		require.NoError(t, driver.UpdateQuote(ctx, untagged, 0))
+
+		found, err = driver.GetQuoteById(ctx, quote.Id)
+		require.NoError(t, err)
+		assert.Nil(t, found.Tags)
+	})
+
+	t.Run("GetQuotesByAuthors", func(t *testing.T) {
+		driver := newDriver(t)
+
+		first := newQuote("first", "text0")
+		second := newQuote("second", "text1")
+		other := newQuote("other", "text2")
+		for _, quote := range []*models.Quote{first, second, other} {
+			require.NoError(t, driver.CreateQuote(ctx, quote))
+		}
+
+		quotes, err := driver.GetQuotesByAuthors(ctx, []string{"first", "second", "missing"})
+		require.NoError(t, err)
+		assert.Equal(t, sortedById(first, second), quotes)
+
+		quotes, err = driver.GetQuotesByAuthors(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, quotes)
+	})
+
+	t.Run("GetQuotesByTags", func(t *testing.T) {
+		driver := newDriver(t)
+
+		wisdom := newQuote("author", "text0")
+		wisdom.Tags = []string{"wisdom"}
+		both := newQuote("author", "text1")
+		both.Tags = []string{"life", "wisdom"}
+		life := newQuote("author", "text2")
+		life.Tags = []string{"life"}
+		untagged := newQuote("author", "text3")
+		for _, quote := range []*models.Quote{wisdom, both, life, untagged} {
+			require.NoError(t, driver.CreateQuote(ctx, quote))
+		}
+
+		quotes, err := driver.GetQuotesByTags(ctx, []string{"wisdom"})
+		require.NoError(t, err)
+		assert.Equal(t, sortedById(wisdom, both), quotes)
+
+		quotes, err = driver.GetQuotesByTags(ctx, []string{"wisdom", "life"})
+		require.NoError(t, err)
+		assert.Equal(t, sortedById(wisdom, both, life), quotes)
+
+		quotes, err = driver.GetQuotesByTags(ctx, []string{"Wisdom"})
+		require.NoError(t, err)
+		assert.Empty(t, quotes)
+	})
+
+	t.Run("GetQuotesPage", func(t *testing.T) {
+		driver := newDriver(t)
+
+		var quotes []models.Quote
+		for i := range 5 {
+			quote := newQuote("author", "text"+strconv.Itoa(i))
+			if i == 2 {
+				quote.Author = "other"
+			}
+			require.NoError(t, driver.CreateQuote(ctx, quote))
+			quotes = append(quotes, *quote)
+		}
+		slices.SortFunc(quotes, func(a, b models.Quote) int { return compareUuids(a.Id, b.Id) })
+
+		page, err := driver.GetQuotesPage(ctx, "", pgtype.UUID{}, 2)
+		require.NoError(t, err)
+		assert.Equal(t, quotes[:2], page)
+
+		// A deleted quote still marks where the next page starts.
+		require.NoError(t, driver.DeleteQuote(ctx, quotes[1].Id, 0))
+		page, err = driver.GetQuotesPage(ctx, "", quotes[1].Id, 10)
+		require.NoError(t, err)
+		assert.Equal(t, quotes[2:], page)
+
+		byAuthor := slices.DeleteFunc(slices.Clone(quotes[2:]), func(quote models.Quote) bool { return quote.Author != "author" })
+		page, err = driver.GetQuotesPage(ctx, "author", quotes[0].Id, 10)
+		require.NoError(t, err)
+		assert.Equal(t, byAuthor, page)
+	})
+
+	t.Run("GetRandomQuote", func(t *testing.T) {
+		driver := newDriver(t)
+
+		_, err := driver.GetRandomQuote(ctx)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+		quote := newQuote("author", "text")
+		require.NoError(t, driver.CreateQuote(ctx, quote))
+
+		random, err := driver.GetRandomQuote(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, quote, random)
+	})
+
+	t.Run("DeleteQuote", func(t *testing.T) {
+		driver := newDriver(t)
+
+		kept := newQuote("author", "text0")
+		deleted := newQuote("author", "text1")
+		require.NoError(t, driver.CreateQuote(ctx, kept))
+		require.NoError(t, driver.CreateQuote(ctx, deleted))
+
+		require.NoError(t, driver.DeleteQuote(ctx, deleted.Id, 0))
+
+		_, err := driver.GetQuoteById(ctx, deleted.Id)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+		quotes, err := driver.GetAllQuotes(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Quote{*kept}, quotes)
+
+		assert.NoError(t, driver.DeleteQuote(ctx, deleted.Id, 0))
+	})
+
+	t.Run("UpdateQuote", func(t *testing.T) {
+		driver := newDriver(t)
+		quote := newQuote("author", "text")
+		require.NoError(t, driver.CreateQuote(ctx, quote))
+		assert.Equal(t, int64(1), quote.Version)
+
+		updated := &models.Quote{Id: quote.Id, Author: "new author", Text: "new text"}
+		require.NoError(t, driver.UpdateQuote(ctx, updated, quote.Version))
+		assert.Equal(t, int64(2), updated.Version)
+		assert.False(t, updated.UpdatedAt.Before(quote.UpdatedAt))
+
+		found, err := driver.GetQuoteById(ctx, quote.Id)
+		require.NoError(t, err)
+		assert.Equal(t, updated, found)
+
+		unconditional := &models.Quote{Id: quote.Id, Author: "author", Text: "text"}
+		require.NoError(t, driver.UpdateQuote(ctx, unconditional, 0))
+		assert.Equal(t, int64(3), unconditional.Version)
+
+		missing := newQuote("author", "text")
+		assert.ErrorIs(t, driver.UpdateQuote(ctx, missing, 0), pgx.ErrNoRows)
+		assert.ErrorIs(t, driver.UpdateQuote(ctx, missing, 1), pgx.ErrNoRows)
+	})
+
+	t.Run("UpdateQuote and DeleteQuote with stale version", func(t *testing.T) {
+		driver := newDriver(t)
+		quote := newQuote("author", "text")
+		require.NoError(t, driver.CreateQuote(ctx, quote))
+		require.NoError(t, driver.UpdateQuote(ctx, &models.Quote{Id: quote.Id, Author: "author", Text: "edited"}, 1))
+
+		var conflictErr *VersionConflictError
+		assert.ErrorAs(t, driver.UpdateQuote(ctx, &models.Quote{Id: quote.Id, Author: "author", Text: "text"}, 1), &conflictErr)
+		assert.ErrorAs(t, driver.DeleteQuote(ctx, quote.Id, 1), &conflictErr)
+
+		found, err := driver.GetQuoteById(ctx, quote.Id)
+		require.NoError(t, err)
+		assert.Equal(t, "edited", found.Text)
+
+		require.NoError(t, driver.DeleteQuote(ctx, quote.Id, 2))
+		assert.ErrorIs(t, driver.DeleteQuote(ctx, quote.Id, 2), pgx.ErrNoRows)
+	})
+
+	t.Run("GetLastModified", func(t *testing.T) {
+		driver := newDriver(t)
+		quote := newQuote("author", "text")
+		require.NoError(t, driver.CreateQuote(ctx, quote))
+
+		created, err := driver.GetLastModified(ctx)
+		require.NoError(t, err)
+		assert.False(t, created.IsZero())
+
+		time.Sleep(2 * time.Millisecond)
+		require.NoError(t, driver.DeleteQuote(ctx, quote.Id, 0))
+
+		deleted, err := driver.GetLastModified(ctx)
+		require.NoError(t, err)
+		assert.True(t, deleted.After(created), "deletions should count as modifications")
+	})
+
+	t.Run("InTransaction", func(t *testing.T) {
+		driver := newDriver(t)
+		kept := newQuote("author", "kept")
+		require.NoError(t, driver.CreateQuote(ctx, kept))
+
+		committed := newQuote("author", "committed")
+		require.NoError(t, driver.InTransaction(ctx, func(tx QuoteDriverInterface) error {
+			require.NoError(t, tx.CreateQuote(ctx, committed))
+
+			// A failed change leaves the transaction usable.
+			var conflictErr *VersionConflictError
+			assert.ErrorAs(t, tx.DeleteQuote(ctx, kept.Id, 5), &conflictErr)
+
+			found, err := tx.GetQuoteById(ctx, committed.Id)
+			require.NoError(t, err)
+			assert.Equal(t, committed, found)
+
+			return tx.UpdateQuote(ctx, kept, kept.Version)
+		}))
+
+		failure := errors.New("failure")
+		rolledBack := newQuote("author", "rolled back")
+		assert.ErrorIs(t, driver.InTransaction(ctx, func(tx QuoteDriverInterface) error {
+			require.NoError(t, tx.CreateQuote(ctx, rolledBack))
+			require.NoError(t, tx.DeleteQuote(ctx, kept.Id, 0))
+			return failure
+		}), failure)
+
+		quotes, err := driver.GetAllQuotes(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, sortedById(kept, committed), quotes)
+		assert.Equal(t, int64(2), kept.Version)
+	})
+}
+
+func TestMemoryQuoteDriverConformance(t *testing.T) {
+	runQuoteDriverConformance(t, func(t *testing.T) QuoteDriverInterface {
+		return NewMemoryQuoteDriver()
+	})
+}
+
+func TestPostgresQuoteDriverConformance(t *testing.T) {
+	pool, cleanup := setupPostgresContainer(t)
+	defer cleanup()
+
+	runQuoteDriverConformance(t, func(t *testing.T) QuoteDriverInterface {
+		_, err := pool.Exec(context.Background(), "TRUNCATE quotes")
+		require.NoError(t, err)
+
+		return NewQuoteDriver(pool)
+	})
+}
+
+func TestSQLiteQuoteDriverConformance(t *testing.T) {
+	runQuoteDriverConformance(t, func(t *testing.T) QuoteDriverInterface {
+		ctx := context.Background()
+
+		db, err := OpenSQLite(ctx, filepath.Join(t.TempDir(), "quotes.db"), 4)
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		migrator, err := NewSQLiteMigrator(db)
+		require.NoError(t, err)
+
+		_, err = migrator.Up(ctx)
+		require.NoError(t, err)
+
+		return NewSQLiteQuoteDriver(db)
+	})
+}
@@ -8,10 +8,13 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pressly/goose/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"io"
+	"log/slog"
 	"quotes/internal/models"
 	"slices"
 	"strconv"
@@ -21,6 +24,8 @@ import (
 )
 
 func setupPostgresContainer(t *testing.T) (*pgxpool.Pool, func()) {
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
 	ctx := context.Background()
 
 	pgPort := "5432/tcp"
@@ -80,9 +85,13 @@ func setupPostgresContainer(t *testing.T) (*pgxpool.Pool, func()) {
 }
 
 func setupSchema(ctx context.Context, pool *pgxpool.Pool) error {
-	schema := createTestSchema
+	migrator, err := NewPostgresMigrator(pool)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
 
-	_, err := pool.Exec(ctx, schema)
+	_, err = migrator.Up(ctx)
 	return err
 }
 
@@ -178,10 +187,41 @@ func TestDeleteQuote(t *testing.T) {
 	require.NoError(t, err)
 	require.NotEmpty(t, quoteIds)
 
-	err = driver.DeleteQuote(ctx, quoteIds[0])
+	err = driver.DeleteQuote(ctx, quoteIds[0], 0)
 	require.NoError(t, err)
 }
 
+func TestWritersDoNotWaitForEachOther(t *testing.T) {
+	pool, cleanup := setupPostgresContainer(t)
+	defer cleanup()
+
+	driver := NewQuoteDriver(pool)
+	ctx := context.Background()
+
+	quoteIds, err := createTestData(ctx, pool)
+	require.NoError(t, err)
+
+	tx, err := pool.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+	_, err = tx.Exec(ctx, "UPDATE quotes SET text = 'changed' WHERE id = $1", quoteIds[0])
+	require.NoError(t, err)
+
+	// With the transaction above still open, changes to other quotes must
+	// not wait for it.
+	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	require.NoError(t, driver.DeleteQuote(timeoutCtx, quoteIds[1], 0))
+
+	deletedAt, err := driver.GetLastModified(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, tx.Commit(ctx))
+	modifiedAt, err := driver.GetLastModified(ctx)
+	require.NoError(t, err)
+	assert.False(t, modifiedAt.Before(deletedAt))
+}
+
 func TestGetAllQuotes(t *testing.T) {
 	pool, cleanup := setupPostgresContainer(t)
 	defer cleanup()
@@ -196,9 +236,15 @@ func TestGetAllQuotes(t *testing.T) {
 	quotes, err := driver.GetAllQuotes(ctx)
 	require.NoError(t, err)
 	require.Len(t, quotes, len(quoteIds))
-	require.Equal(t, quotes[0].Id, quoteIds[0])
-	require.Equal(t, quotes[1].Author, "author1")
-	require.Equal(t, quotes[2].Text, "text2")
+
+	// Quotes are listed in the order of their ids.
+	sorted := slices.SortedFunc(slices.Values(quoteIds), compareUuids)
+	for i, quote := range quotes {
+		require.Equal(t, sorted[i], quote.Id)
+		index := slices.Index(quoteIds, quote.Id)
+		require.Equal(t, "author"+strconv.Itoa(index), quote.Author)
+		require.Equal(t, "text"+strconv.Itoa(index), quote.Text)
+	}
 }
 
 func TestGetQuotesByAuthor(t *testing.T) {
@@ -238,3 +284,76 @@ func TestGetRandomQuote(t *testing.T) {
 	require.True(t, strings.HasPrefix(quote.Author, "author"))
 	require.True(t, strings.HasPrefix(quote.Text, "text"))
 }
+
+func TestMigrations(t *testing.T) {
+	pool, cleanup := setupPostgresContainer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	migrator, err := NewPostgresMigrator(pool)
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	version, err := NewHealthDriver(pool).GetMigrationVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, migrator.LatestVersion(), version)
+
+	_, err = migrator.Down(ctx)
+	require.NoError(t, err)
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, statuses)
+	assert.Equal(t, goose.StatePending, statuses[len(statuses)-1].State)
+
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+}
+
+func TestQuoteChangeListener(t *testing.T) {
+	pool, cleanup := setupPostgresContainer(t)
+	defer cleanup()
+
+	driver := NewQuoteDriver(pool)
+	listener := NewQuoteChangeListener(pool, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	changes := make(chan QuoteChange, 10)
+	listener.OnChange(func(change QuoteChange) { changes <- change })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go listener.Run(ctx)
+
+	// Changes are only delivered once the listener has issued LISTEN, which
+	// stays the last statement of its connection while it waits.
+	require.Eventually(t, func() bool {
+		var listening bool
+		err := pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_stat_activity WHERE query = $1)",
+			"LISTEN "+QuoteChangesChannel).Scan(&listening)
+		return err == nil && listening
+	}, 10*time.Second, 50*time.Millisecond)
+
+	quote := &models.Quote{Id: pgtype.UUID{Bytes: uuid.New(), Valid: true}, Author: "author", Text: "text"}
+	require.NoError(t, driver.CreateQuote(ctx, quote))
+	require.NoError(t, driver.DeleteQuote(ctx, quote.Id, 0))
+
+	nextChange := func() QuoteChange {
+		for {
+			select {
+			case change := <-changes:
+				if change.Id == quote.Id {
+					return change
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no notification for the quote")
+			}
+		}
+	}
+
+	inserted := nextChange()
+	assert.Equal(t, QuoteInserted, inserted.Operation)
+	deleted := nextChange()
+	assert.Equal(t, QuoteDeleted, deleted.Operation)
+	assert.Greater(t, deleted.EventId, inserted.EventId)
+}
@@ -2,15 +2,52 @@ package drivers
 
 import (
 	"context"
+	"strconv"
+	"time"
+
 	"github.com/jackc/pgx/v5/pgtype"
 	"quotes/internal/models"
 )
 
+// QuoteDriverInterface stores quotes. CreateQuote and UpdateQuote fill in the
+// Version and UpdatedAt of the quote they are given. UpdateQuote and
+// DeleteQuote apply only if the stored quote has the given version, or
+// unconditionally if it is 0, and return a *VersionConflictError otherwise.
+// Lists of quotes are in the order of their ids.
 type QuoteDriverInterface interface {
 	CreateQuote(ctx context.Context, quote *models.Quote) error
-	DeleteQuote(ctx context.Context, id pgtype.UUID) error
+	UpdateQuote(ctx context.Context, quote *models.Quote, version int64) error
+	DeleteQuote(ctx context.Context, id pgtype.UUID, version int64) error
 	GetAllQuotes(ctx context.Context) ([]models.Quote, error)
 	GetQuotesByAuthor(ctx context.Context, author string) ([]models.Quote, error)
+	// GetQuotesByAuthors returns the quotes of any of the given authors and
+	// GetQuotesByTags the quotes having any of the given tags, so that the
+	// quotes of many authors or tags are loaded with one query.
+	GetQuotesByAuthors(ctx context.Context, authors []string) ([]models.Quote, error)
+	GetQuotesByTags(ctx context.Context, tags []string) ([]models.Quote, error)
+	// GetQuotesPage returns up to limit quotes in the order of their ids,
+	// those of author only unless it is empty, following the quote with id
+	// after, or from the first if after is not valid. Pages are keyed by id
+	// rather than offset, so none are skipped or repeated while quotes are
+	// created and deleted between them.
+	GetQuotesPage(ctx context.Context, author string, after pgtype.UUID, limit int) ([]models.Quote, error)
 	GetRandomQuote(ctx context.Context) (*models.Quote, error)
 	GetQuoteById(ctx context.Context, id pgtype.UUID) (*models.Quote, error)
+	// GetLastModified returns the time of the latest change to any quote,
+	// deletions included.
+	GetLastModified(ctx context.Context) (time.Time, error)
+	// InTransaction calls fn with a driver whose changes are committed
+	// together if fn returns nil and discarded otherwise. A change failing
+	// within fn does not undo the changes before it, so fn may go on after
+	// handling the error. fn must not call InTransaction on its driver.
+	InTransaction(ctx context.Context, fn func(tx QuoteDriverInterface) error) error
+}
+
+type VersionConflictError struct {
+	Id      pgtype.UUID
+	Version int64
+}
+
+func (e *VersionConflictError) Error() string {
+	return "quote " + e.Id.String() + " is not at version " + strconv.FormatInt(e.Version, 10)
 }
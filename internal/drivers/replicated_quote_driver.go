@@ -0,0 +1,200 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"quotes/internal/models"
+)
+
+type primaryKey struct{}
+
+// WithPrimary makes every read issued with the returned context go to the
+// primary, e.g. right after the client has written and replicas may lag.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// UsePrimary reports whether reads for ctx must go to the primary.
+func UsePrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+
+	return primary
+}
+
+// Replica is a read-only copy of the primary database together with its
+// last known health.
+type Replica struct {
+	name    string
+	driver  QuoteDriverInterface
+	health  HealthDriverInterface
+	healthy atomic.Bool
+}
+
+func NewReplica(name string, driver QuoteDriverInterface, health HealthDriverInterface) *Replica {
+	replica := &Replica{name: name, driver: driver, health: health}
+	replica.healthy.Store(true)
+
+	return replica
+}
+
+// ReplicatedQuoteDriver sends writes to the primary and spreads reads across
+// healthy replicas in round-robin order. Reads fall back to the primary when
+// no replica is healthy, when a replica fails to answer, or when the context
+// was marked with WithPrimary.
+type ReplicatedQuoteDriver struct {
+	primary  QuoteDriverInterface
+	replicas []*Replica
+	next     atomic.Uint64
+}
+
+func NewReplicatedQuoteDriver(primary QuoteDriverInterface, replicas ...*Replica) *ReplicatedQuoteDriver {
+	return &ReplicatedQuoteDriver{primary: primary, replicas: replicas}
+}
+
+func (d *ReplicatedQuoteDriver) CreateQuote(ctx context.Context, quote *models.Quote) error {
+	return d.primary.CreateQuote(ctx, quote)
+}
+
+func (d *ReplicatedQuoteDriver) UpdateQuote(ctx context.Context, quote *models.Quote, version int64) error {
+	return d.primary.UpdateQuote(ctx, quote, version)
+}
+
+func (d *ReplicatedQuoteDriver) DeleteQuote(ctx context.Context, id pgtype.UUID, version int64) error {
+	return d.primary.DeleteQuote(ctx, id, version)
+}
+
+func (d *ReplicatedQuoteDriver) GetAllQuotes(ctx context.Context) ([]models.Quote, error) {
+	return readFrom(ctx, d, func(driver QuoteDriverInterface) ([]models.Quote, error) {
+		return driver.GetAllQuotes(ctx)
+	})
+}
+
+func (d *ReplicatedQuoteDriver) GetQuotesByAuthor(ctx context.Context, author string) ([]models.Quote, error) {
+	return readFrom(ctx, d, func(driver QuoteDriverInterface) ([]models.Quote, error) {
+		return driver.GetQuotesByAuthor(ctx, author)
+	})
+}
+
+func (d *ReplicatedQuoteDriver) GetQuotesByAuthors(ctx context.Context, authors []string) ([]models.Quote, error) {
+	return readFrom(ctx, d, func(driver QuoteDriverInterface) ([]models.Quote, error) {
+		return driver.GetQuotesByAuthors(ctx, authors)
+	})
+}
+
+func (d *ReplicatedQuoteDriver) GetQuotesByTags(ctx context.Context, tags []string) ([]models.Quote, error) {
+	return readFrom(ctx, d, func(driver QuoteDriverInterface) ([]models.Quote, error) {
+		return driver.GetQuotesByTags(ctx, tags)
+	})
+}
+
+func (d *ReplicatedQuoteDriver) GetQuotesPage(ctx context.Context, author string, after pgtype.UUID, limit int) ([]models.Quote, error) {
+	return readFrom(ctx, d, func(driver QuoteDriverInterface) ([]models.Quote, error) {
+		return driver.GetQuotesPage(ctx, author, after, limit)
+	})
+}
+
+func (d *ReplicatedQuoteDriver) GetRandomQuote(ctx context.Context) (*models.Quote, error) {
+	return readFrom(ctx, d, func(driver QuoteDriverInterface) (*models.Quote, error) {
+		return driver.GetRandomQuote(ctx)
+	})
+}
+
+func (d *ReplicatedQuoteDriver) GetQuoteById(ctx context.Context, id pgtype.UUID) (*models.Quote, error) {
+	return readFrom(ctx, d, func(driver QuoteDriverInterface) (*models.Quote, error) {
+		return driver.GetQuoteById(ctx, id)
+	})
+}
+
+func (d *ReplicatedQuoteDriver) GetLastModified(ctx context.Context) (time.Time, error) {
+	return readFrom(ctx, d, func(driver QuoteDriverInterface) (time.Time, error) {
+		return driver.GetLastModified(ctx)
+	})
+}
+
+func (d *ReplicatedQuoteDriver) InTransaction(ctx context.Context, fn func(tx QuoteDriverInterface) error) error {
+	return d.primary.InTransaction(ctx, fn)
+}
+
+// RunHealthChecks pings every replica at the given interval until ctx is
+// cancelled, taking unreachable replicas out of rotation and adding them
+// back once they respond again.
+func (d *ReplicatedQuoteDriver) RunHealthChecks(ctx context.Context, interval, timeout time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, replica := range d.replicas {
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			err := replica.health.Ping(checkCtx)
+			cancel()
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			healthy := err == nil
+			if replica.healthy.Swap(healthy) != healthy {
+				if healthy {
+					logger.Info("Replica is healthy again", "replica", replica.name)
+				} else {
+					logger.Warn("Replica is unhealthy, routing its reads to the primary", "replica", replica.name, "error", err)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *ReplicatedQuoteDriver) pickReplica() *Replica {
+	for range d.replicas {
+		replica := d.replicas[d.next.Add(1)%uint64(len(d.replicas))]
+		if replica.healthy.Load() {
+			return replica
+		}
+	}
+
+	return nil
+}
+
+func readFrom[T any](ctx context.Context, d *ReplicatedQuoteDriver, read func(QuoteDriverInterface) (T, error)) (T, error) {
+	if UsePrimary(ctx) {
+		return read(d.primary)
+	}
+
+	replica := d.pickReplica()
+	if replica == nil {
+		return read(d.primary)
+	}
+
+	result, err := read(replica.driver)
+	if err != nil && isConnectionError(ctx, err) {
+		replica.healthy.Store(false)
+		return read(d.primary)
+	}
+
+	return result, err
+}
+
+// isConnectionError reports whether err means the database could not be
+// reached, as opposed to an answer such as "no rows" or an SQL error.
+func isConnectionError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, pgx.ErrNoRows) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+
+	return !errors.As(err, &pgErr)
+}
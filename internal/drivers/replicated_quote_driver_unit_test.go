@@ -0,0 +1,108 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/models"
+)
+
+type stubHealthDriver struct {
+	err error
+}
+
+func (d *stubHealthDriver) Ping(ctx context.Context) error {
+	return d.err
+}
+
+func (d *stubHealthDriver) GetMigrationVersion(ctx context.Context) (int64, error) {
+	return 0, d.err
+}
+
+// unreachableQuoteDriver fails every call as if the server were down.
+type unreachableQuoteDriver struct {
+	MemoryQuoteDriver
+}
+
+func (d *unreachableQuoteDriver) GetAllQuotes(ctx context.Context) ([]models.Quote, error) {
+	return nil, errors.New("dial tcp: connection refused")
+}
+
+func newTestQuote(author string) *models.Quote {
+	return &models.Quote{Id: pgtype.UUID{Bytes: uuid.New(), Valid: true}, Author: author, Text: "text"}
+}
+
+func TestReplicatedQuoteDriverRouting(t *testing.T) {
+	ctx := context.Background()
+	primary := NewMemoryQuoteDriver()
+	replicaDriver := NewMemoryQuoteDriver()
+	replica := NewReplica("replica-1", replicaDriver, &stubHealthDriver{})
+	driver := NewReplicatedQuoteDriver(primary, replica)
+
+	written := newTestQuote("primary")
+	require.NoError(t, driver.CreateQuote(ctx, written))
+	replicated := newTestQuote("replica")
+	require.NoError(t, replicaDriver.CreateQuote(ctx, replicated))
+
+	t.Run("reads go to the replica", func(t *testing.T) {
+		quotes, err := driver.GetAllQuotes(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Quote{*replicated}, quotes)
+	})
+
+	t.Run("reads marked with WithPrimary go to the primary", func(t *testing.T) {
+		quote, err := driver.GetQuoteById(WithPrimary(ctx), written.Id)
+		require.NoError(t, err)
+		assert.Equal(t, written, quote)
+	})
+
+	t.Run("unhealthy replica is skipped", func(t *testing.T) {
+		replica.healthy.Store(false)
+		defer replica.healthy.Store(true)
+
+		quotes, err := driver.GetQuotesByAuthor(ctx, "primary")
+		require.NoError(t, err)
+		assert.Equal(t, []models.Quote{*written}, quotes)
+	})
+}
+
+func TestReplicatedQuoteDriverFallsBackOnConnectionError(t *testing.T) {
+	ctx := context.Background()
+	primary := NewMemoryQuoteDriver()
+	replica := NewReplica("replica-1", &unreachableQuoteDriver{MemoryQuoteDriver: *NewMemoryQuoteDriver()}, &stubHealthDriver{})
+	driver := NewReplicatedQuoteDriver(primary, replica)
+
+	quote := newTestQuote("author")
+	require.NoError(t, primary.CreateQuote(ctx, quote))
+
+	quotes, err := driver.GetAllQuotes(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Quote{*quote}, quotes)
+	assert.False(t, replica.healthy.Load())
+}
+
+func TestReplicatedQuoteDriverHealthChecks(t *testing.T) {
+	health := &stubHealthDriver{err: errors.New("connection refused")}
+	replica := NewReplica("replica-1", NewMemoryQuoteDriver(), health)
+	driver := NewReplicatedQuoteDriver(NewMemoryQuoteDriver(), replica)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		driver.RunHealthChecks(ctx, time.Millisecond, time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	}()
+
+	assert.Eventually(t, func() bool { return !replica.healthy.Load() }, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,223 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// retryableCodes are SQLSTATE codes with which the server rolls back a
+// statement that conflicted with concurrent transactions. Only statements
+// outside transactions are retried, so the rolled back statement is the
+// whole of what is executed again.
+var retryableCodes = []string{
+	"40001", // serialization_failure
+	"40P01", // deadlock_detected
+}
+
+// interruptedCodes are SQLSTATE codes, besides the 08 connection exceptions,
+// with which the server drops a connection while it shuts down or starts.
+// The statement may have been committed before its result was lost, so only
+// queries, which must be safe to repeat, are retried after them.
+var interruptedCodes = []string{
+	"57P01", // admin_shutdown
+	"57P02", // crash_shutdown
+	"57P03", // cannot_connect_now
+}
+
+type RetryPolicy struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// ResilientAdapter wraps an Adapter, retrying transient errors with
+// jittered exponential backoff and failing fast through a CircuitBreaker
+// while the database is down. Statements inside transactions are not
+// retried, since the whole transaction would have to be replayed. Query and
+// QueryRow are also retried when the connection is lost, so the statements
+// sent through them outside transactions must be safe to repeat: reads, or
+// claims whose repetition at worst leaves rows leased until they expire.
+type ResilientAdapter struct {
+	adapter Adapter
+	policy  RetryPolicy
+	breaker *CircuitBreaker
+}
+
+func NewResilientAdapter(adapter Adapter, policy RetryPolicy, breaker *CircuitBreaker) *ResilientAdapter {
+	return &ResilientAdapter{adapter: adapter, policy: policy, breaker: breaker}
+}
+
+func (a *ResilientAdapter) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	return retry(ctx, a, isRetryableError, func() (pgconn.CommandTag, error) {
+		return a.adapter.Exec(ctx, sql, arguments...)
+	})
+}
+
+// Query reads the first row before returning, since pgx reports the errors
+// of the statement only once its rows are read; up to then it can be
+// retried. A later error is still counted by the circuit breaker.
+func (a *ResilientAdapter) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return retry(ctx, a, isRetryableQueryError, func() (pgx.Rows, error) {
+		rows, err := a.adapter.Query(ctx, sql, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		if rows.Next() {
+			return &resilientRows{Rows: rows, breaker: a.breaker, peeked: true}, nil
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		return &resilientRows{Rows: rows, breaker: a.breaker}, nil
+	})
+}
+
+func (a *ResilientAdapter) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return &resilientRow{ctx: ctx, adapter: a, sql: sql, args: args}
+}
+
+func (a *ResilientAdapter) Begin(ctx context.Context) (pgx.Tx, error) {
+	return retry(ctx, a, isRetryableError, func() (pgx.Tx, error) {
+		return a.adapter.Begin(ctx)
+	})
+}
+
+// resilientRow defers the query to Scan, where pgx reports its errors.
+type resilientRow struct {
+	ctx     context.Context
+	adapter *ResilientAdapter
+	sql     string
+	args    []any
+}
+
+func (r *resilientRow) Scan(dest ...any) error {
+	_, err := retry(r.ctx, r.adapter, isRetryableQueryError, func() (struct{}, error) {
+		return struct{}{}, r.adapter.adapter.QueryRow(r.ctx, r.sql, r.args...).Scan(dest...)
+	})
+
+	return err
+}
+
+// resilientRows are the rows of a query whose first row Query has already
+// read, and which count the error they end with in the circuit breaker.
+type resilientRows struct {
+	pgx.Rows
+	breaker *CircuitBreaker
+	peeked  bool
+}
+
+func (r *resilientRows) Next() bool {
+	if r.peeked {
+		r.peeked = false
+		return true
+	}
+
+	if r.Rows.Next() {
+		return true
+	}
+	if err := r.Rows.Err(); err != nil {
+		r.breaker.Record(err)
+	}
+
+	return false
+}
+
+func retry[T any](ctx context.Context, a *ResilientAdapter, retryable func(error) bool, call func() (T, error)) (T, error) {
+	var result T
+	var err error
+
+	for attempt := 0; ; attempt++ {
+		if err := a.breaker.Allow(); err != nil {
+			return result, err
+		}
+
+		result, err = call()
+		a.breaker.Record(err)
+
+		if err == nil || !retryable(err) || attempt+1 >= a.policy.Attempts {
+			return result, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return result, err
+		case <-time.After(a.policy.backoff(attempt)):
+		}
+	}
+}
+
+// backoff returns a random delay up to BaseDelay*2^attempt, capped at
+// MaxDelay ("full jitter").
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	ceiling := p.BaseDelay << attempt
+	if ceiling <= 0 || ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+
+	return rand.N(ceiling) + 1
+}
+
+// isRetryableError reports whether the failed statement can be executed
+// again without risking it being applied twice: it was never sent, because
+// no connection could be made or pgx failed before sending, or the server
+// rolled it back. A connection lost while the statement ran, such as with
+// the 08 and 57P0 codes, is not retried, since the statement may have been
+// committed before the result was lost.
+func isRetryableError(err error) bool {
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) || pgconn.SafeToRetry(err) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && slices.Contains(retryableCodes, pgErr.Code)
+}
+
+// isRetryableQueryError reports whether a failed query can be executed
+// again, which besides the errors isRetryableError accepts includes losing
+// the connection while the query ran.
+func isRetryableQueryError(err error) bool {
+	if isRetryableError(err) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return slices.Contains(interruptedCodes, pgErr.Code) || strings.HasPrefix(pgErr.Code, "08")
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && !netErr.Timeout()
+}
+
+// isUnavailableError reports whether err means the database could not serve
+// the request at all, which is what the circuit breaker counts.
+func isUnavailableError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return slices.Contains(interruptedCodes, pgErr.Code) || strings.HasPrefix(pgErr.Code, "08")
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+
+	return errors.As(err, &connectErr) || errors.As(err, &netErr) || pgconn.SafeToRetry(err) || pgconn.Timeout(err)
+}
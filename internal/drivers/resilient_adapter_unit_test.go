@@ -0,0 +1,157 @@
+package drivers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyAdapter fails Exec with the queued errors before succeeding.
+type flakyAdapter struct {
+	stubAdapter
+	errs    []error
+	calls   int
+	rows    int
+	rowsErr error
+}
+
+func (a *flakyAdapter) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	a.calls++
+	if len(a.errs) > 0 {
+		err := a.errs[0]
+		a.errs = a.errs[1:]
+		return pgconn.CommandTag{}, err
+	}
+
+	return pgconn.NewCommandTag("DELETE 1"), nil
+}
+
+// flakyRows are the rows of a query that fails with err after rows rows.
+type flakyRows struct {
+	pgx.Rows
+	rows int
+	err  error
+}
+
+func (r *flakyRows) Next() bool {
+	if r.rows == 0 {
+		return false
+	}
+	r.rows--
+	return true
+}
+
+func (r *flakyRows) Err() error {
+	if r.rows == 0 {
+		return r.err
+	}
+	return nil
+}
+
+func (r *flakyRows) Close() {}
+
+// flakyAdapter fails Query with the queued errors, which show up when the
+// first row is read, before returning rows rows that end with rowsErr.
+func (a *flakyAdapter) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	a.calls++
+	if len(a.errs) > 0 {
+		err := a.errs[0]
+		a.errs = a.errs[1:]
+		return &flakyRows{err: err}, nil
+	}
+
+	return &flakyRows{rows: a.rows, err: a.rowsErr}, nil
+}
+
+var testRetryPolicy = RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestResilientAdapterRetriesTransientErrors(t *testing.T) {
+	flaky := &flakyAdapter{errs: []error{&pgconn.PgError{Code: "40P01"}, &pgconn.PgError{Code: "40001"}}}
+	adapter := NewResilientAdapter(flaky, testRetryPolicy, NewCircuitBreaker(5, time.Second))
+
+	_, err := adapter.Exec(context.Background(), queryDeleteQuote)
+	require.NoError(t, err)
+	assert.Equal(t, 3, flaky.calls)
+}
+
+func TestResilientAdapterDoesNotRetryOtherErrors(t *testing.T) {
+	// A lost connection may have lost the result of a committed change.
+	for _, code := range []string{"23505", "08006", "57P01"} {
+		flaky := &flakyAdapter{errs: []error{&pgconn.PgError{Code: code}}}
+		adapter := NewResilientAdapter(flaky, testRetryPolicy, NewCircuitBreaker(5, time.Second))
+
+		_, err := adapter.Exec(context.Background(), queryDeleteQuote)
+		require.Error(t, err)
+		assert.Equal(t, 1, flaky.calls, code)
+	}
+}
+
+func TestResilientAdapterGivesUpAfterAttempts(t *testing.T) {
+	conflict := &pgconn.PgError{Code: "40001"}
+	flaky := &flakyAdapter{errs: []error{conflict, conflict, conflict, conflict}}
+	adapter := NewResilientAdapter(flaky, testRetryPolicy, NewCircuitBreaker(5, time.Second))
+
+	_, err := adapter.Exec(context.Background(), queryDeleteQuote)
+	assert.ErrorIs(t, err, conflict)
+	assert.Equal(t, 3, flaky.calls)
+}
+
+func TestResilientAdapterFailsFastWhenOpen(t *testing.T) {
+	unavailable := &pgconn.PgError{Code: "08006"}
+	flaky := &flakyAdapter{errs: []error{unavailable, unavailable, unavailable}}
+	adapter := NewResilientAdapter(flaky, testRetryPolicy, NewCircuitBreaker(2, time.Minute))
+
+	for range 2 {
+		_, err := adapter.Exec(context.Background(), queryDeleteQuote)
+		require.ErrorIs(t, err, unavailable)
+	}
+	assert.Equal(t, 2, flaky.calls)
+
+	_, err := adapter.Exec(context.Background(), queryDeleteQuote)
+	var openErr *CircuitOpenError
+	require.ErrorAs(t, err, &openErr)
+	assert.Equal(t, 2, flaky.calls, "no call should reach the database while the breaker is open")
+}
+
+func TestResilientAdapterRetriesInterruptedQueries(t *testing.T) {
+	flaky := &flakyAdapter{
+		errs: []error{&pgconn.PgError{Code: "57P01"}, &pgconn.PgError{Code: "08006"}},
+		rows: 2,
+	}
+	adapter := NewResilientAdapter(flaky, testRetryPolicy, NewCircuitBreaker(5, time.Second))
+
+	rows, err := adapter.Query(context.Background(), queryGetAllQuotes)
+	require.NoError(t, err)
+	defer rows.Close()
+	assert.Equal(t, 3, flaky.calls)
+
+	read := 0
+	for rows.Next() {
+		read++
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, 2, read, "the row read before returning should not be lost")
+}
+
+func TestResilientAdapterCountsLaterQueryErrors(t *testing.T) {
+	unavailable := &pgconn.PgError{Code: "57P01"}
+	flaky := &flakyAdapter{rows: 1, rowsErr: unavailable}
+	adapter := NewResilientAdapter(flaky, testRetryPolicy, NewCircuitBreaker(1, time.Minute))
+
+	rows, err := adapter.Query(context.Background(), queryGetAllQuotes)
+	require.NoError(t, err)
+	for rows.Next() {
+	}
+	assert.ErrorIs(t, rows.Err(), unavailable)
+	rows.Close()
+
+	_, err = adapter.Query(context.Background(), queryGetAllQuotes)
+	var openErr *CircuitOpenError
+	assert.ErrorAs(t, err, &openErr)
+	assert.Equal(t, 1, flaky.calls)
+}
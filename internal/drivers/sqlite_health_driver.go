@@ -0,0 +1,29 @@
+package drivers
+
+import (
+	"context"
+	"database/sql"
+)
+
+type SQLiteHealthDriver struct {
+	db *sql.DB
+}
+
+func NewSQLiteHealthDriver(db *sql.DB) *SQLiteHealthDriver {
+	return &SQLiteHealthDriver{db: db}
+}
+
+func (d *SQLiteHealthDriver) Ping(ctx context.Context) error {
+	return d.db.PingContext(ctx)
+}
+
+func (d *SQLiteHealthDriver) GetMigrationVersion(ctx context.Context) (int64, error) {
+	var version int64
+
+	err := d.db.QueryRowContext(ctx, queryGetMigrationVersion).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
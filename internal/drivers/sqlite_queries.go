@@ -0,0 +1,72 @@
+package drivers
+
+const (
+	querySQLiteCreateQuote = `
+	INSERT INTO quotes (id, author, text, tags, updated_at)
+	VALUES (?, ?, ?, ?, ?)
+	RETURNING version
+`
+	querySQLiteUpdateQuote = `
+	UPDATE quotes
+	SET author = ?2, text = ?3, tags = ?6, version = version + 1, updated_at = ?4
+	WHERE id = ?1 AND (?5 = 0 OR version = ?5)
+	RETURNING version
+`
+	querySQLiteDeleteQuote = `
+	DELETE FROM quotes
+	WHERE id = ?1 AND (?2 = 0 OR version = ?2)
+`
+	querySQLiteGetAllQuotes = `
+	SELECT id, author, text, tags, version, updated_at
+	FROM quotes
+	ORDER BY id
+`
+	querySQLiteGetQuoteByAuthor = `
+	SELECT id, text, tags, version, updated_at
+	FROM quotes
+	WHERE author = ?
+	ORDER BY id
+`
+	querySQLiteGetQuotesByAuthors = `
+	SELECT id, author, text, tags, version, updated_at
+	FROM quotes
+	WHERE author IN (SELECT value FROM json_each(?))
+	ORDER BY id
+`
+	querySQLiteGetQuotesByTags = `
+	SELECT id, author, text, tags, version, updated_at
+	FROM quotes
+	WHERE EXISTS (
+		SELECT 1 FROM json_each(quotes.tags)
+		WHERE value IN (SELECT value FROM json_each(?))
+	)
+	ORDER BY id
+`
+	querySQLiteGetQuotesPage = `
+	SELECT id, author, text, tags, version, updated_at
+	FROM quotes
+	WHERE (?1 = '' OR author = ?1) AND id > ?2
+	ORDER BY id
+	LIMIT ?3
+`
+	querySQLiteGetRandomQuote = `
+	SELECT id, author, text, tags, version, updated_at
+	FROM quotes
+	ORDER BY RANDOM()
+	LIMIT 1
+`
+	querySQLiteGetQuoteById = `
+	SELECT author, text, tags, version, updated_at
+	FROM quotes
+	WHERE id = ?
+`
+	querySQLiteGetQuoteVersion = `
+	SELECT version
+	FROM quotes
+	WHERE id = ?
+`
+	querySQLiteGetLastModified = `
+	SELECT modified_at
+	FROM quotes_modified
+`
+)
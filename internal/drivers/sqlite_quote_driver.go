@@ -0,0 +1,320 @@
+package drivers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pressly/goose/v3"
+	_ "modernc.org/sqlite"
+	"quotes/internal/models"
+	"quotes/migrations"
+)
+
+// OpenSQLite opens the SQLite database at path, creating it if necessary.
+// ":memory:" opens a private in-memory database limited to one connection.
+func OpenSQLite(ctx context.Context, path string, maxConns int) (*sql.DB, error) {
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"
+	if path == ":memory:" {
+		maxConns = 1
+	} else {
+		dsn += "&_pragma=journal_mode(WAL)"
+	}
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(maxConns)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// SQLitePath extracts the database file path from a sqlite:// URL, e.g.
+// sqlite://quotes.db, sqlite:///var/lib/quotes.db or sqlite://:memory:.
+func SQLitePath(databaseURL string) string {
+	path := strings.TrimPrefix(databaseURL, "sqlite://")
+	path, _, _ = strings.Cut(path, "?")
+
+	return path
+}
+
+func NewSQLiteMigrator(db *sql.DB) (*Migrator, error) {
+	return NewMigrator(db, goose.DialectSQLite3, migrations.SQLite)
+}
+
+// sqliteQuerier runs statements on the database or in a transaction.
+type sqliteQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type SQLiteQuoteDriver struct {
+	db sqliteQuerier
+}
+
+func NewSQLiteQuoteDriver(db *sql.DB) *SQLiteQuoteDriver {
+	return &SQLiteQuoteDriver{db: db}
+}
+
+func (d *SQLiteQuoteDriver) CreateQuote(ctx context.Context, quote *models.Quote) error {
+	updatedAt := sqliteNow()
+
+	err := d.db.QueryRowContext(
+		ctx,
+		querySQLiteCreateQuote,
+		quote.Id.String(),
+		quote.Author,
+		quote.Text,
+		encodeSQLiteTags(quote.Tags),
+		updatedAt.UnixMicro(),
+	).Scan(&quote.Version)
+	if err != nil {
+		return err
+	}
+
+	quote.UpdatedAt = updatedAt
+
+	return nil
+}
+
+func (d *SQLiteQuoteDriver) UpdateQuote(ctx context.Context, quote *models.Quote, version int64) error {
+	updatedAt := sqliteNow()
+
+	err := d.db.QueryRowContext(
+		ctx,
+		querySQLiteUpdateQuote,
+		quote.Id.String(),
+		quote.Author,
+		quote.Text,
+		updatedAt.UnixMicro(),
+		version,
+		encodeSQLiteTags(quote.Tags),
+	).Scan(&quote.Version)
+	if errors.Is(err, sql.ErrNoRows) && version != 0 {
+		return d.versionConflict(ctx, quote.Id, version)
+	}
+	if err != nil {
+		return translateSQLiteError(err)
+	}
+
+	quote.UpdatedAt = updatedAt
+
+	return nil
+}
+
+func (d *SQLiteQuoteDriver) DeleteQuote(ctx context.Context, id pgtype.UUID, version int64) error {
+	result, err := d.db.ExecContext(ctx, querySQLiteDeleteQuote, id.String(), version)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 && version != 0 {
+		return d.versionConflict(ctx, id, version)
+	}
+
+	return nil
+}
+
+func (d *SQLiteQuoteDriver) GetAllQuotes(ctx context.Context) ([]models.Quote, error) {
+	return d.queryQuotes(ctx, querySQLiteGetAllQuotes)
+}
+
+func (d *SQLiteQuoteDriver) GetQuotesByAuthor(ctx context.Context, author string) ([]models.Quote, error) {
+	rows, err := d.db.QueryContext(ctx, querySQLiteGetQuoteByAuthor, author)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var quotes []models.Quote
+	for rows.Next() {
+		quote := models.Quote{Author: author}
+		var tags string
+		var updatedAt int64
+
+		err = rows.Scan(&quote.Id, &quote.Text, &tags, &quote.Version, &updatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if quote.Tags, err = decodeSQLiteTags(tags); err != nil {
+			return nil, err
+		}
+		quote.UpdatedAt = time.UnixMicro(updatedAt)
+		quotes = append(quotes, quote)
+	}
+
+	return quotes, rows.Err()
+}
+
+func (d *SQLiteQuoteDriver) GetQuotesByAuthors(ctx context.Context, authors []string) ([]models.Quote, error) {
+	return d.queryQuotes(ctx, querySQLiteGetQuotesByAuthors, encodeSQLiteTags(authors))
+}
+
+func (d *SQLiteQuoteDriver) GetQuotesByTags(ctx context.Context, tags []string) ([]models.Quote, error) {
+	return d.queryQuotes(ctx, querySQLiteGetQuotesByTags, encodeSQLiteTags(tags))
+}
+
+// GetQuotesPage compares ids as text, which orders them like PostgreSQL
+// orders UUIDs, since they are stored in lower case.
+func (d *SQLiteQuoteDriver) GetQuotesPage(ctx context.Context, author string, after pgtype.UUID, limit int) ([]models.Quote, error) {
+	return d.queryQuotes(ctx, querySQLiteGetQuotesPage, author, after.String(), limit)
+}
+
+func (d *SQLiteQuoteDriver) GetRandomQuote(ctx context.Context) (*models.Quote, error) {
+	quote := models.Quote{}
+	var tags string
+	var updatedAt int64
+
+	err := d.db.QueryRowContext(ctx, querySQLiteGetRandomQuote).Scan(&quote.Id, &quote.Author, &quote.Text, &tags, &quote.Version, &updatedAt)
+	if err != nil {
+		return nil, translateSQLiteError(err)
+	}
+
+	if quote.Tags, err = decodeSQLiteTags(tags); err != nil {
+		return nil, err
+	}
+	quote.UpdatedAt = time.UnixMicro(updatedAt)
+
+	return &quote, nil
+}
+
+func (d *SQLiteQuoteDriver) GetQuoteById(ctx context.Context, id pgtype.UUID) (*models.Quote, error) {
+	quote := models.Quote{Id: id}
+	var tags string
+	var updatedAt int64
+
+	err := d.db.QueryRowContext(ctx, querySQLiteGetQuoteById, id.String()).Scan(&quote.Author, &quote.Text, &tags, &quote.Version, &updatedAt)
+	if err != nil {
+		return nil, translateSQLiteError(err)
+	}
+
+	if quote.Tags, err = decodeSQLiteTags(tags); err != nil {
+		return nil, err
+	}
+
+	quote.UpdatedAt = time.UnixMicro(updatedAt)
+
+	return &quote, nil
+}
+
+func (d *SQLiteQuoteDriver) GetLastModified(ctx context.Context) (time.Time, error) {
+	var modifiedAt int64
+	err := d.db.QueryRowContext(ctx, querySQLiteGetLastModified).Scan(&modifiedAt)
+	if err != nil {
+		return time.Time{}, translateSQLiteError(err)
+	}
+
+	return time.UnixMicro(modifiedAt), nil
+}
+
+// InTransaction runs fn with a driver on a transaction. Each change is a
+// single statement, so a failed change leaves the transaction usable.
+func (d *SQLiteQuoteDriver) InTransaction(ctx context.Context, fn func(tx QuoteDriverInterface) error) error {
+	db, ok := d.db.(*sql.DB)
+	if !ok {
+		return errors.New("sqlite: transactions cannot be nested")
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&SQLiteQuoteDriver{db: tx}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (d *SQLiteQuoteDriver) versionConflict(ctx context.Context, id pgtype.UUID, version int64) error {
+	var current int64
+	if err := d.db.QueryRowContext(ctx, querySQLiteGetQuoteVersion, id.String()).Scan(&current); err != nil {
+		return translateSQLiteError(err)
+	}
+
+	return &VersionConflictError{Id: id, Version: version}
+}
+
+// queryQuotes runs a query selecting every column of quotes.
+func (d *SQLiteQuoteDriver) queryQuotes(ctx context.Context, query string, args ...any) ([]models.Quote, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var quotes []models.Quote
+	for rows.Next() {
+		quote := models.Quote{}
+		var tags string
+		var updatedAt int64
+
+		err = rows.Scan(&quote.Id, &quote.Author, &quote.Text, &tags, &quote.Version, &updatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if quote.Tags, err = decodeSQLiteTags(tags); err != nil {
+			return nil, err
+		}
+		quote.UpdatedAt = time.UnixMicro(updatedAt)
+		quotes = append(quotes, quote)
+	}
+
+	return quotes, rows.Err()
+}
+
+// encodeSQLiteTags encodes a list of strings as the JSON array it is stored
+// or queried with.
+func encodeSQLiteTags(values []string) string {
+	if values == nil {
+		values = []string{}
+	}
+
+	encoded, _ := json.Marshal(values)
+	return string(encoded)
+}
+
+func decodeSQLiteTags(encoded string) ([]string, error) {
+	var tags []string
+	if err := json.Unmarshal([]byte(encoded), &tags); err != nil {
+		return nil, err
+	}
+
+	return nilIfEmpty(tags), nil
+}
+
+// sqliteNow returns the current time at the microsecond precision the
+// timestamps are stored with.
+func sqliteNow() time.Time {
+	return time.UnixMicro(time.Now().UnixMicro())
+}
+
+// translateSQLiteError maps database/sql errors onto the pgx errors returned
+// by the PostgreSQL driver so callers handle every backend the same way.
+func translateSQLiteError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return pgx.ErrNoRows
+	}
+
+	return err
+}
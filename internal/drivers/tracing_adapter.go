@@ -0,0 +1,189 @@
+package drivers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"quotes/internal/tracing"
+)
+
+// TracingAdapter wraps an Adapter and records a client span for every SQL
+// statement, including statements executed inside transactions.
+type TracingAdapter struct {
+	adapter Adapter
+}
+
+func NewTracingAdapter(adapter Adapter) *TracingAdapter {
+	return &TracingAdapter{adapter: adapter}
+}
+
+func (a *TracingAdapter) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	return tracedExec(ctx, a.adapter, sql, arguments...)
+}
+
+func (a *TracingAdapter) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return tracedQuery(ctx, a.adapter, sql, args...)
+}
+
+func (a *TracingAdapter) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return tracedQueryRow(ctx, a.adapter, sql, args...)
+}
+
+func (a *TracingAdapter) Begin(ctx context.Context) (pgx.Tx, error) {
+	ctx, span := startQuerySpan(ctx, "BEGIN")
+	defer span.End()
+
+	tx, err := a.adapter.Begin(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return &tracingTx{Tx: tx}, nil
+}
+
+type tracingTx struct {
+	pgx.Tx
+}
+
+func (t *tracingTx) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	return tracedExec(ctx, t.Tx, sql, arguments...)
+}
+
+func (t *tracingTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return tracedQuery(ctx, t.Tx, sql, args...)
+}
+
+func (t *tracingTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return tracedQueryRow(ctx, t.Tx, sql, args...)
+}
+
+func (t *tracingTx) Commit(ctx context.Context) error {
+	ctx, span := startQuerySpan(ctx, "COMMIT")
+	defer span.End()
+
+	err := t.Tx.Commit(ctx)
+	tracing.RecordError(span, err)
+
+	return err
+}
+
+func (t *tracingTx) Rollback(ctx context.Context) error {
+	ctx, span := startQuerySpan(ctx, "ROLLBACK")
+	defer span.End()
+
+	err := t.Tx.Rollback(ctx)
+	if err != pgx.ErrTxClosed {
+		tracing.RecordError(span, err)
+	}
+
+	return err
+}
+
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func tracedExec(ctx context.Context, q querier, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	ctx, span := startQuerySpan(ctx, sql)
+	defer span.End()
+
+	tag, err := q.Exec(ctx, sql, arguments...)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return tag, err
+	}
+
+	span.SetAttributes(attribute.Int64("db.rows_affected", tag.RowsAffected()))
+
+	return tag, nil
+}
+
+func tracedQuery(ctx context.Context, q querier, sql string, args ...any) (pgx.Rows, error) {
+	ctx, span := startQuerySpan(ctx, sql)
+
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		tracing.RecordError(span, err)
+		span.End()
+		return nil, err
+	}
+
+	return &tracingRows{Rows: rows, span: span}, nil
+}
+
+func tracedQueryRow(ctx context.Context, q querier, sql string, args ...any) pgx.Row {
+	ctx, span := startQuerySpan(ctx, sql)
+
+	return &tracingRow{row: q.QueryRow(ctx, sql, args...), span: span}
+}
+
+// tracingRows ends the query span once the result set has been consumed.
+type tracingRows struct {
+	pgx.Rows
+	span  trace.Span
+	ended bool
+}
+
+func (r *tracingRows) Close() {
+	r.Rows.Close()
+
+	if !r.ended {
+		r.ended = true
+		tracing.RecordError(r.span, r.Rows.Err())
+		r.span.End()
+	}
+}
+
+func (r *tracingRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+
+	r.Close()
+
+	return false
+}
+
+type tracingRow struct {
+	row  pgx.Row
+	span trace.Span
+}
+
+func (r *tracingRow) Scan(dest ...any) error {
+	defer r.span.End()
+
+	err := r.row.Scan(dest...)
+	if err != pgx.ErrNoRows {
+		tracing.RecordError(r.span, err)
+	}
+
+	return err
+}
+
+func startQuerySpan(ctx context.Context, sql string) (context.Context, trace.Span) {
+	statement := strings.Join(strings.Fields(sql), " ")
+
+	return tracing.Tracer().Start(ctx, spanName(statement),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBQueryText(statement),
+		),
+	)
+}
+
+// spanName uses the leading SQL keyword, e.g. "SELECT", to keep span names
+// low-cardinality.
+func spanName(statement string) string {
+	operation, _, _ := strings.Cut(statement, " ")
+
+	return "db." + strings.ToUpper(operation)
+}
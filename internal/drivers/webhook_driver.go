@@ -0,0 +1,218 @@
+package drivers
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"quotes/internal/models"
+)
+
+type WebhookDriver struct {
+	adapter Adapter
+}
+
+func NewWebhookDriver(adapter Adapter) *WebhookDriver {
+	return &WebhookDriver{adapter: adapter}
+}
+
+// CreateSubscription and UpdateSubscription run in transactions of their
+// own, which the ResilientAdapter does not retry, because it retries queries
+// after a lost connection and these change the subscription.
+func (d *WebhookDriver) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return inTx(ctx, d.adapter, func(tx pgx.Tx) error {
+		return tx.QueryRow(
+			ctx,
+			queryCreateWebhookSubscription,
+			subscription.Id,
+			subscription.Url,
+			subscription.Secret,
+			subscription.EventTypes,
+			subscription.Active,
+		).Scan(&subscription.CreatedAt, &subscription.UpdatedAt)
+	})
+}
+
+func (d *WebhookDriver) UpdateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return inTx(ctx, d.adapter, func(tx pgx.Tx) error {
+		return tx.QueryRow(
+			ctx,
+			queryUpdateWebhookSubscription,
+			subscription.Id,
+			subscription.Url,
+			subscription.EventTypes,
+			subscription.Active,
+			subscription.Secret,
+		).Scan(&subscription.Secret, &subscription.CreatedAt, &subscription.UpdatedAt)
+	})
+}
+
+func (d *WebhookDriver) DeleteSubscription(ctx context.Context, id pgtype.UUID) error {
+	tag, err := d.adapter.Exec(ctx, queryDeleteWebhookSubscription, id)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+func (d *WebhookDriver) GetSubscription(ctx context.Context, id pgtype.UUID) (*models.WebhookSubscription, error) {
+	subscription := models.WebhookSubscription{Id: id}
+
+	err := d.adapter.QueryRow(ctx, queryGetWebhookSubscription, id).Scan(
+		&subscription.Url,
+		&subscription.Secret,
+		&subscription.EventTypes,
+		&subscription.Active,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+func (d *WebhookDriver) GetSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	rows, err := d.adapter.Query(ctx, queryGetWebhookSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []models.WebhookSubscription
+	for rows.Next() {
+		subscription := models.WebhookSubscription{}
+
+		err = rows.Scan(
+			&subscription.Id,
+			&subscription.Url,
+			&subscription.Secret,
+			&subscription.EventTypes,
+			&subscription.Active,
+			&subscription.CreatedAt,
+			&subscription.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func (d *WebhookDriver) CreateDeliveries(ctx context.Context, eventIds []int64) error {
+	_, err := d.adapter.Exec(ctx, queryCreateWebhookDeliveries, eventIds)
+	return err
+}
+
+func (d *WebhookDriver) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	rows, err := d.adapter.Query(ctx, queryClaimWebhookDeliveries, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		delivery := models.WebhookDelivery{Status: models.DeliveryPending}
+
+		err = rows.Scan(
+			&delivery.Id,
+			&delivery.SubscriptionId,
+			&delivery.Attempts,
+			&delivery.CreatedAt,
+			&delivery.Event.Id,
+			&delivery.Event.Type,
+			&delivery.Event.QuoteId,
+			&delivery.Event.Payload,
+			&delivery.Event.CreatedAt,
+			&delivery.Url,
+			&delivery.Secret,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (d *WebhookDriver) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	var lastError *string
+	if delivery.LastError != "" {
+		lastError = &delivery.LastError
+	}
+
+	_, err := d.adapter.Exec(
+		ctx,
+		queryRecordWebhookDelivery,
+		delivery.Id,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.LastAttemptAt,
+		delivery.LastStatusCode,
+		lastError,
+	)
+
+	return err
+}
+
+func (d *WebhookDriver) GetDeliveries(ctx context.Context, subscriptionId pgtype.UUID, status string, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := d.adapter.Query(ctx, queryGetWebhookDeliveries, subscriptionId, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		delivery := models.WebhookDelivery{SubscriptionId: subscriptionId}
+
+		err = rows.Scan(
+			&delivery.Id,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.LastAttemptAt,
+			&delivery.LastStatusCode,
+			&delivery.LastError,
+			&delivery.CreatedAt,
+			&delivery.Event.Id,
+			&delivery.Event.Type,
+			&delivery.Event.QuoteId,
+			&delivery.Event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (d *WebhookDriver) RetryDelivery(ctx context.Context, subscriptionId pgtype.UUID, deliveryId int64) error {
+	tag, err := d.adapter.Exec(ctx, queryRetryWebhookDelivery, subscriptionId, deliveryId)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package drivers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/models"
+)
+
+func TestQuoteChangesRecordOutboxEvents(t *testing.T) {
+	pool, cleanup := setupPostgresContainer(t)
+	defer cleanup()
+
+	driver := NewQuoteDriver(pool)
+	ctx := context.Background()
+
+	quote := &models.Quote{Id: pgtype.UUID{Bytes: uuid.New(), Valid: true}, Author: "author", Text: "text"}
+	require.NoError(t, driver.CreateQuote(ctx, quote))
+	quote.Text = "changed"
+	require.NoError(t, driver.UpdateQuote(ctx, quote, quote.Version))
+	// A failed change records no event.
+	require.Error(t, driver.UpdateQuote(ctx, quote, quote.Version+1))
+	require.NoError(t, driver.DeleteQuote(ctx, quote.Id, 0))
+	require.NoError(t, driver.DeleteQuote(ctx, quote.Id, 0))
+
+	rows, err := pool.Query(ctx, `SELECT event_type, payload FROM outbox_events WHERE quote_id = $1 ORDER BY id`, quote.Id)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var types []string
+	var payloads []outboxQuote
+	for rows.Next() {
+		var eventType string
+		var payload []byte
+		require.NoError(t, rows.Scan(&eventType, &payload))
+
+		var decoded outboxQuote
+		require.NoError(t, json.Unmarshal(payload, &decoded))
+		types = append(types, eventType)
+		payloads = append(payloads, decoded)
+	}
+	require.NoError(t, rows.Err())
+
+	assert.Equal(t, []string{OutboxQuoteCreated, OutboxQuoteUpdated, OutboxQuoteDeleted}, types)
+	assert.Equal(t, "text", payloads[0].Text)
+	assert.Equal(t, "changed", payloads[2].Text)
+	assert.Equal(t, int64(2), payloads[2].Version)
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	pool, cleanup := setupPostgresContainer(t)
+	defer cleanup()
+
+	quotes := NewQuoteDriver(pool)
+	outbox := NewOutboxDriver(pool)
+	driver := NewWebhookDriver(pool)
+	ctx := context.Background()
+
+	subscription := &models.WebhookSubscription{
+		Id:         pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		Url:        "https://example.com/hook",
+		Secret:     "secret",
+		EventTypes: []string{OutboxQuoteCreated},
+		Active:     true,
+	}
+	require.NoError(t, driver.CreateSubscription(ctx, subscription))
+
+	quote := &models.Quote{Id: pgtype.UUID{Bytes: uuid.New(), Valid: true}, Author: "author", Text: "text"}
+	require.NoError(t, quotes.CreateQuote(ctx, quote))
+	require.NoError(t, quotes.DeleteQuote(ctx, quote.Id, 0))
+
+	createDeliveries := func(ctx context.Context, events []models.OutboxEvent) error {
+		ids := make([]int64, len(events))
+		for i, event := range events {
+			ids[i] = event.Id
+		}
+		return driver.CreateDeliveries(ctx, ids)
+	}
+
+	sinks := []string{"http", "webhooks"}
+	_, err := outbox.PublishEvents(ctx, "http", sinks, 10, func(context.Context, []models.OutboxEvent) error {
+		return errors.New("sink unavailable")
+	})
+	require.Error(t, err)
+	published, err := outbox.PublishEvents(ctx, "webhooks", sinks, 10, createDeliveries)
+	require.NoError(t, err)
+	assert.Equal(t, 2, published, "a failing sink does not hold back the others")
+	published, err = outbox.PublishEvents(ctx, "webhooks", sinks, 10, createDeliveries)
+	require.NoError(t, err)
+	assert.Zero(t, published, "published events are not published again")
+
+	pruned, err := outbox.PruneEvents(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, pruned, "events are kept until every sink has published them")
+	published, err = outbox.PublishEvents(ctx, "http", sinks, 10, func(context.Context, []models.OutboxEvent) error {
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, published, "events are published again after a failure")
+
+	claimed, err := driver.ClaimDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1, "only the subscribed event is delivered")
+	assert.Equal(t, OutboxQuoteCreated, claimed[0].Event.Type)
+	assert.Equal(t, "secret", claimed[0].Secret)
+
+	again, err := driver.ClaimDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, again, "claimed deliveries are leased")
+
+	delivery := claimed[0]
+	now := time.Now()
+	statusCode := 500
+	delivery.Status = models.DeliveryDead
+	delivery.Attempts = 8
+	delivery.LastAttemptAt = &now
+	delivery.LastStatusCode = &statusCode
+	delivery.LastError = "unexpected status 500"
+	require.NoError(t, driver.RecordAttempt(ctx, &delivery))
+
+	dead, err := driver.GetDeliveries(ctx, subscription.Id, models.DeliveryDead, 10)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "unexpected status 500", dead[0].LastError)
+	assert.Equal(t, 500, *dead[0].LastStatusCode)
+
+	require.NoError(t, driver.RetryDelivery(ctx, subscription.Id, delivery.Id))
+	assert.ErrorIs(t, driver.RetryDelivery(ctx, subscription.Id, delivery.Id), pgx.ErrNoRows, "only dead deliveries are retried")
+
+	pruned, err = outbox.PruneEvents(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), pruned, "events with pending deliveries are kept")
+
+	require.NoError(t, driver.DeleteSubscription(ctx, subscription.Id))
+	assert.ErrorIs(t, driver.DeleteSubscription(ctx, subscription.Id), pgx.ErrNoRows)
+}
@@ -0,0 +1,42 @@
+package drivers
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"quotes/internal/models"
+)
+
+// WebhookDriverInterface stores webhook subscriptions and the deliveries of
+// outbox events to them. Subscriptions that do not exist are reported with
+// pgx.ErrNoRows.
+type WebhookDriverInterface interface {
+	// CreateSubscription and UpdateSubscription fill in the timestamps of
+	// the subscription. UpdateSubscription keeps the stored secret if the
+	// subscription has none.
+	CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	UpdateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, id pgtype.UUID) error
+	GetSubscription(ctx context.Context, id pgtype.UUID) (*models.WebhookSubscription, error)
+	GetSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+
+	// CreateDeliveries creates a pending delivery of each of the given
+	// outbox events for every active subscription to its type, unless one
+	// exists already.
+	CreateDeliveries(ctx context.Context, eventIds []int64) error
+	// ClaimDeliveries returns up to limit pending deliveries that are due,
+	// postponing them by lease so that other instances skip them meanwhile.
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	// RecordAttempt saves the status, attempts, next attempt and outcome of
+	// the latest attempt of delivery.
+	RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error
+	// GetDeliveries returns the latest limit deliveries to a subscription,
+	// newest first, only those with the given status unless it is empty.
+	// Their events have no payload.
+	GetDeliveries(ctx context.Context, subscriptionId pgtype.UUID, status string, limit int) ([]models.WebhookDelivery, error)
+	// RetryDelivery makes a dead delivery pending again with its attempts
+	// reset, returning pgx.ErrNoRows if the subscription has no such dead
+	// delivery.
+	RetryDelivery(ctx context.Context, subscriptionId pgtype.UUID, deliveryId int64) error
+}
@@ -0,0 +1,41 @@
+package dtos
+
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// BatchRequestDto is the body of a batch of quote changes. The operations
+// are applied in one transaction unless Atomic is false, in which case each
+// is applied on its own.
+type BatchRequestDto struct {
+	Atomic     *bool               `json:"atomic,omitempty"`
+	Operations []BatchOperationDto `json:"operations"`
+}
+
+// BatchOperationDto is one change of a batch: Op is create, update or
+// delete. Id names the quote to update or delete, and Version the version
+// it must have, like If-Match; 0 matches any version. Quote holds the
+// author, text and tags to create or update with.
+type BatchOperationDto struct {
+	Op      string       `json:"op"`
+	Id      *pgtype.UUID `json:"id,omitempty"`
+	Version int64        `json:"version,omitempty"`
+	Quote   *QuoteDto    `json:"quote,omitempty"`
+}
+
+// BatchResponseDto lists the result of every operation of a batch, in the
+// order of the request.
+type BatchResponseDto struct {
+	Atomic  bool             `json:"atomic"`
+	Results []BatchResultDto `json:"results"`
+}
+
+// BatchResultDto is the outcome of one operation: the status the single
+// request would have been answered with, and the created or updated quote
+// with its version, or the problem the operation failed with.
+type BatchResultDto struct {
+	Status  int         `json:"status"`
+	Quote   *QuoteDto   `json:"quote,omitempty"`
+	Version int64       `json:"version,omitempty"`
+	Error   *ProblemDto `json:"error,omitempty"`
+}
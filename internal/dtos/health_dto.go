@@ -0,0 +1,12 @@
+package dtos
+
+type HealthDto struct {
+	Status string                    `json:"status"`
+	Checks map[string]HealthCheckDto `json:"checks,omitempty"`
+}
+
+type HealthCheckDto struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
@@ -0,0 +1,20 @@
+package dtos
+
+// ProblemDto is an RFC 7807 problem details object. Type identifies the kind
+// of problem for clients, Instance is the ID of the failed request.
+type ProblemDto struct {
+	Type     string          `json:"type"`
+	Title    string          `json:"title"`
+	Status   int             `json:"status"`
+	Detail   string          `json:"detail,omitempty"`
+	Instance string          `json:"instance,omitempty"`
+	Errors   []FieldErrorDto `json:"errors,omitempty"`
+}
+
+// FieldErrorDto describes why the value of a request body field was rejected.
+// Code is stable for clients, Message is meant for humans.
+type FieldErrorDto struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
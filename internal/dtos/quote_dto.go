@@ -1,9 +1,34 @@
 package dtos
 
-import "github.com/jackc/pgx/v5/pgtype"
+import (
+	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// QuoteDto is the JSON representation of a quote. Version and UpdatedAt are
+// sent as the ETag and Last-Modified headers rather than in the body.
 type QuoteDto struct {
-	Id     *pgtype.UUID `json:"id"`
-	Author *string      `json:"author"`
-	Text   *string      `json:"text"`
+	Id        *pgtype.UUID `json:"id"`
+	Author    *string      `json:"author"`
+	Text      *string      `json:"text"`
+	Tags      []string     `json:"tags"`
+	Version   int64        `json:"-"`
+	UpdatedAt time.Time    `json:"-"`
+}
+
+// AuthorRenameDto gives the quotes of every author in From the author To,
+// renaming a single author or merging several. With DryRun nothing is
+// changed.
+type AuthorRenameDto struct {
+	From   []string `json:"from"`
+	To     string   `json:"to"`
+	DryRun bool     `json:"dry_run,omitempty"`
+}
+
+// AuthorRenameResultDto reports how many quotes a rename changed, or would
+// change if it was a dry run.
+type AuthorRenameResultDto struct {
+	Quotes int  `json:"quotes"`
+	DryRun bool `json:"dry_run"`
 }
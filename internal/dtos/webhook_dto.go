@@ -0,0 +1,36 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// WebhookDto is the JSON representation of a webhook subscription. Secret
+// is only sent in the response creating the subscription. Active defaults
+// to true when creating and to the current value when updating.
+type WebhookDto struct {
+	Id        *pgtype.UUID `json:"id"`
+	Url       string       `json:"url"`
+	Events    []string     `json:"events"`
+	Active    *bool        `json:"active"`
+	Secret    string       `json:"secret,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// WebhookDeliveryDto is an entry of the delivery log of a subscription.
+// NextAttemptAt is only sent for pending deliveries.
+type WebhookDeliveryDto struct {
+	Id             int64       `json:"id"`
+	EventId        int64       `json:"event_id"`
+	EventType      string      `json:"event_type"`
+	QuoteId        pgtype.UUID `json:"quote_id"`
+	Status         string      `json:"status"`
+	Attempts       int         `json:"attempts"`
+	NextAttemptAt  *time.Time  `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time  `json:"last_attempt_at,omitempty"`
+	LastStatusCode *int        `json:"last_status_code,omitempty"`
+	LastError      string      `json:"last_error,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
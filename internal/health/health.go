@@ -0,0 +1,127 @@
+package health
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"quotes/internal/drivers"
+	"quotes/internal/dtos"
+)
+
+const (
+	StatusOk          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+type Checker struct {
+	checks       []Check
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, timeout: timeout}
+}
+
+// SetShuttingDown makes every subsequent Check report the service as
+// unavailable so that load balancers stop routing new traffic to it.
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Check runs all registered checks concurrently, each bounded by the
+// configured timeout, and reports the overall status.
+func (c *Checker) Check(ctx context.Context) dtos.HealthDto {
+	if c.shuttingDown.Load() {
+		return dtos.HealthDto{
+			Status: StatusUnavailable,
+			Checks: map[string]dtos.HealthCheckDto{
+				"server": {Status: StatusUnavailable, Error: "server is shutting down"},
+			},
+		}
+	}
+
+	results := make([]dtos.HealthCheckDto, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = c.runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	health := dtos.HealthDto{Status: StatusOk, Checks: make(map[string]dtos.HealthCheckDto, len(c.checks))}
+	for i, check := range c.checks {
+		if results[i].Status != StatusOk {
+			health.Status = StatusUnavailable
+		}
+		health.Checks[check.Name] = results[i]
+	}
+
+	return health
+}
+
+func (c *Checker) runCheck(ctx context.Context, check Check) dtos.HealthCheckDto {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	result := dtos.HealthCheckDto{
+		Status:     StatusOk,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		result.Status = StatusUnavailable
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+func DatabaseCheck(driver drivers.HealthDriverInterface) Check {
+	return Check{
+		Name: "database",
+		Run:  driver.Ping,
+	}
+}
+
+func MigrationCheck(driver drivers.HealthDriverInterface, expectedVersion int64) Check {
+	return Check{
+		Name: "migrations",
+		Run: func(ctx context.Context) error {
+			version, err := driver.GetMigrationVersion(ctx)
+			if err != nil {
+				return err
+			}
+
+			if version != expectedVersion {
+				return &MigrationVersionError{Expected: expectedVersion, Actual: version}
+			}
+
+			return nil
+		},
+	}
+}
+
+type MigrationVersionError struct {
+	Expected int64
+	Actual   int64
+}
+
+func (e *MigrationVersionError) Error() string {
+	return "unexpected migration version: expected " + strconv.FormatInt(e.Expected, 10) +
+		", got " + strconv.FormatInt(e.Actual, 10)
+}
@@ -0,0 +1,99 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockHealthDriver struct {
+	mock.Mock
+}
+
+func (m *MockHealthDriver) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockHealthDriver) GetMigrationVersion(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestCheckHealthy(t *testing.T) {
+	ctx := context.Background()
+	mockDriver := new(MockHealthDriver)
+	checker := NewChecker(time.Second, DatabaseCheck(mockDriver), MigrationCheck(mockDriver, 1))
+
+	mockDriver.On("Ping", mock.Anything).Return(nil)
+	mockDriver.On("GetMigrationVersion", mock.Anything).Return(int64(1), nil)
+
+	result := checker.Check(ctx)
+
+	assert.Equal(t, StatusOk, result.Status)
+	assert.Equal(t, StatusOk, result.Checks["database"].Status)
+	assert.Equal(t, StatusOk, result.Checks["migrations"].Status)
+	mockDriver.AssertExpectations(t)
+}
+
+func TestCheckDatabaseUnavailable(t *testing.T) {
+	ctx := context.Background()
+	mockDriver := new(MockHealthDriver)
+	checker := NewChecker(time.Second, DatabaseCheck(mockDriver))
+
+	mockDriver.On("Ping", mock.Anything).Return(errors.New("connection refused"))
+
+	result := checker.Check(ctx)
+
+	assert.Equal(t, StatusUnavailable, result.Status)
+	assert.Equal(t, StatusUnavailable, result.Checks["database"].Status)
+	assert.Equal(t, "connection refused", result.Checks["database"].Error)
+	mockDriver.AssertExpectations(t)
+}
+
+func TestCheckMigrationVersionMismatch(t *testing.T) {
+	ctx := context.Background()
+	mockDriver := new(MockHealthDriver)
+	checker := NewChecker(time.Second, MigrationCheck(mockDriver, 2))
+
+	mockDriver.On("GetMigrationVersion", mock.Anything).Return(int64(1), nil)
+
+	result := checker.Check(ctx)
+
+	assert.Equal(t, StatusUnavailable, result.Status)
+	assert.Equal(t, "unexpected migration version: expected 2, got 1", result.Checks["migrations"].Error)
+	mockDriver.AssertExpectations(t)
+}
+
+func TestCheckTimeout(t *testing.T) {
+	ctx := context.Background()
+	checker := NewChecker(10*time.Millisecond, Check{
+		Name: "slow",
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+
+	result := checker.Check(ctx)
+
+	assert.Equal(t, StatusUnavailable, result.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), result.Checks["slow"].Error)
+}
+
+func TestCheckShuttingDown(t *testing.T) {
+	ctx := context.Background()
+	mockDriver := new(MockHealthDriver)
+	checker := NewChecker(time.Second, DatabaseCheck(mockDriver))
+
+	checker.SetShuttingDown()
+	result := checker.Check(ctx)
+
+	assert.Equal(t, StatusUnavailable, result.Status)
+	assert.Equal(t, "server is shutting down", result.Checks["server"].Error)
+	mockDriver.AssertNotCalled(t, "Ping", mock.Anything)
+}
@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLoggerJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger, err := NewLogger(&buf, "json", "info")
+	require.NoError(t, err)
+
+	logger.Debug("hidden")
+	logger.Info("visible", "key", "value")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "visible", record["msg"])
+	assert.Equal(t, "value", record["key"])
+}
+
+func TestNewLoggerInvalid(t *testing.T) {
+	_, err := NewLogger(&bytes.Buffer{}, "xml", "info")
+	assert.Error(t, err)
+
+	_, err = NewLogger(&bytes.Buffer{}, "json", "verbose")
+	assert.Error(t, err)
+}
+
+func TestContextHelpers(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, slog.Default(), FromContext(ctx))
+	assert.Empty(t, RequestIdFromContext(ctx))
+
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	ctx = WithRequestId(WithLogger(ctx, logger), "request-id")
+
+	assert.Equal(t, logger, FromContext(ctx))
+	assert.Equal(t, "request-id", RequestIdFromContext(ctx))
+}
@@ -1,9 +1,17 @@
 package models
 
-import "github.com/jackc/pgx/v5/pgtype"
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
 
 type Quote struct {
 	Id     pgtype.UUID
 	Author string
 	Text   string
+	// Tags is nil for quotes without tags.
+	Tags      []string
+	Version   int64
+	UpdatedAt time.Time
 }
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Delivery statuses of webhooks.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryDead      = "dead"
+)
+
+type WebhookSubscription struct {
+	Id     pgtype.UUID
+	Url    string
+	Secret string
+	// EventTypes are the outbox event types delivered, such as
+	// "quote.created".
+	EventTypes []string
+	Active     bool
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// OutboxEvent is a quote change recorded in the transaction of the change.
+type OutboxEvent struct {
+	Id      int64
+	Type    string
+	QuoteId pgtype.UUID
+	// Payload is the JSON of the quote after the change, or before it for
+	// deletions.
+	Payload   []byte
+	CreatedAt time.Time
+}
+
+// WebhookDelivery is the delivery of an event to a subscription and the
+// outcome of its latest attempt.
+type WebhookDelivery struct {
+	Id             int64
+	SubscriptionId pgtype.UUID
+	Event          OutboxEvent
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastAttemptAt  *time.Time
+	// LastStatusCode is nil if the latest attempt got no response.
+	LastStatusCode *int
+	LastError      string
+	CreatedAt      time.Time
+
+	// Url and Secret are those of the subscription when the delivery was
+	// claimed.
+	Url    string
+	Secret string
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"quotes/internal/config"
+	"quotes/internal/health"
+)
+
+type Server struct {
+	httpServer      *http.Server
+	grpcServer      *grpc.Server
+	grpcAddr        string
+	checker         *health.Checker
+	logger          *slog.Logger
+	shutdownDelay   time.Duration
+	shutdownTimeout time.Duration
+}
+
+func NewServer(cfg *config.Config, handler http.Handler, checker *health.Checker, logger *slog.Logger) *Server {
+	httpServer := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+
+	return &Server{
+		httpServer:      httpServer,
+		grpcAddr:        ":" + cfg.GrpcPort,
+		checker:         checker,
+		logger:          logger,
+		shutdownDelay:   cfg.ShutdownDelay,
+		shutdownTimeout: cfg.ShutdownTimeout,
+	}
+}
+
+// SetGrpcServer makes Run also serve grpcServer on the gRPC port.
+func (s *Server) SetGrpcServer(grpcServer *grpc.Server) {
+	s.grpcServer = grpcServer
+}
+
+// OnShutdown registers a function called when the HTTP server starts
+// draining, to end long-lived responses such as event streams.
+func (s *Server) OnShutdown(f func()) {
+	s.httpServer.RegisterOnShutdown(f)
+}
+
+// Run serves HTTP, and gRPC if set, until ctx is cancelled or SIGINT/SIGTERM
+// is received, then marks the service unready, waits for the shutdown delay
+// so that load balancers notice, and drains in-flight requests and calls
+// within the shutdown timeout.
+func (s *Server) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+
+	var grpcListener net.Listener
+	if s.grpcServer != nil {
+		grpcListener, err = net.Listen("tcp", s.grpcAddr)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+	}
+
+	serveErr := make(chan error, 2)
+	go func() {
+		s.logger.Info("Server listening", "addr", listener.Addr().String())
+		serveErr <- s.httpServer.Serve(listener)
+	}()
+	if grpcListener != nil {
+		go func() {
+			s.logger.Info("gRPC server listening", "addr", grpcListener.Addr().String())
+			serveErr <- s.grpcServer.Serve(grpcListener)
+		}()
+	}
+
+	var runErr error
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			runErr = err
+		}
+		if s.grpcServer == nil {
+			return runErr
+		}
+		// Stop the other server as well.
+		s.shutdownDelay = 0
+	case <-ctx.Done():
+	}
+
+	s.logger.Info("Shutting down", "shutdown_delay", s.shutdownDelay, "shutdown_timeout", s.shutdownTimeout)
+	s.checker.SetShuttingDown()
+
+	if s.shutdownDelay > 0 {
+		time.Sleep(s.shutdownDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	grpcStopped := make(chan struct{})
+	if s.grpcServer != nil {
+		go func() {
+			defer close(grpcStopped)
+			s.grpcServer.GracefulStop()
+		}()
+	}
+
+	if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
+		s.httpServer.Close()
+		runErr = errors.Join(runErr, err)
+	}
+
+	if s.grpcServer == nil {
+		return runErr
+	}
+
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		// Streams such as health watches never end on their own.
+		s.grpcServer.Stop()
+		<-grpcStopped
+	}
+
+	return runErr
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"quotes/internal/config"
+	"quotes/internal/health"
+)
+
+func TestRunShutsDownOnContextCancel(t *testing.T) {
+	cfg := &config.Config{
+		Port:            "0",
+		ShutdownTimeout: time.Second,
+	}
+	checker := health.NewChecker(time.Second)
+	srv := NewServer(cfg, http.NotFoundHandler(), checker, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Run(ctx)
+	}()
+
+	cancel()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+
+	assert.Equal(t, health.StatusUnavailable, checker.Check(context.Background()).Status)
+}
+
+func TestNewServerAppliesTimeouts(t *testing.T) {
+	cfg := &config.Config{
+		Port:              "8080",
+		ReadTimeout:       time.Second,
+		ReadHeaderTimeout: 2 * time.Second,
+		WriteTimeout:      3 * time.Second,
+		IdleTimeout:       4 * time.Second,
+		MaxHeaderBytes:    4096,
+	}
+	srv := NewServer(cfg, http.NotFoundHandler(), health.NewChecker(time.Second), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	assert.Equal(t, ":8080", srv.httpServer.Addr)
+	assert.Equal(t, time.Second, srv.httpServer.ReadTimeout)
+	assert.Equal(t, 2*time.Second, srv.httpServer.ReadHeaderTimeout)
+	assert.Equal(t, 3*time.Second, srv.httpServer.WriteTimeout)
+	assert.Equal(t, 4*time.Second, srv.httpServer.IdleTimeout)
+	assert.Equal(t, 4096, srv.httpServer.MaxHeaderBytes)
+}
+
+func TestRunStopsGrpcServer(t *testing.T) {
+	cfg := &config.Config{
+		Port:            "0",
+		GrpcPort:        "0",
+		ShutdownTimeout: time.Second,
+	}
+	srv := NewServer(cfg, http.NotFoundHandler(), health.NewChecker(time.Second), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	grpcServer := grpc.NewServer()
+	srv.SetGrpcServer(grpcServer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Run(ctx)
+	}()
+
+	cancel()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+
+	// A stopped server cannot serve again.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	assert.ErrorIs(t, grpcServer.Serve(listener), grpc.ErrServerStopped)
+}
+
+func TestRunTimesOutWithoutGrpcServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	listener.Close()
+
+	cfg := &config.Config{
+		Port:            port,
+		ShutdownTimeout: 50 * time.Millisecond,
+	}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	var once sync.Once
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { close(started) })
+		<-release
+	})
+	srv := NewServer(cfg, handler, health.NewChecker(time.Second), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Run(ctx)
+	}()
+
+	// A request still running at the deadline makes the HTTP shutdown time out.
+	require.Eventually(t, func() bool {
+		go http.Get("http://127.0.0.1:" + port)
+		select {
+		case <-started:
+			return true
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"strconv"
+
+	"quotes/internal/drivers"
+	"quotes/internal/dtos"
+)
+
+// Operations of a batch.
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// BatchResult is the outcome of one operation of a batch: the created or
+// updated quote, or the error the operation failed with.
+type BatchResult struct {
+	Quote *dtos.QuoteDto
+	Err   error
+}
+
+// BatchError reports the operation that failed an atomic batch, none of
+// whose operations were applied.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return "operation " + strconv.Itoa(e.Index) + ": " + e.Err.Error()
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyBatch checks the shape of every operation before applying any, and
+// for all-or-nothing batches their quotes too, since one invalid quote would
+// fail the whole batch anyway. Those are applied in one transaction, which
+// the first failing operation rolls back; otherwise an invalid quote only
+// fails its own operation.
+func (s *QuoteService) ApplyBatch(ctx context.Context, operations []dtos.BatchOperationDto, allOrNothing bool) ([]BatchResult, error) {
+	if err := s.rules.validateBatch(operations, allOrNothing); err != nil {
+		return nil, err
+	}
+
+	if !allOrNothing {
+		results := make([]BatchResult, len(operations))
+		for i, operation := range operations {
+			results[i].Quote, results[i].Err = s.applyOperation(ctx, operation)
+		}
+
+		return results, nil
+	}
+
+	var results []BatchResult
+	err := s.driver.InTransaction(ctx, func(tx drivers.QuoteDriverInterface) error {
+		txService := NewQuoteService(tx, s.rules)
+		results = make([]BatchResult, len(operations))
+		for i, operation := range operations {
+			quote, err := txService.applyOperation(ctx, operation)
+			if err != nil {
+				return &BatchError{Index: i, Err: err}
+			}
+			results[i].Quote = quote
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (s *QuoteService) applyOperation(ctx context.Context, operation dtos.BatchOperationDto) (*dtos.QuoteDto, error) {
+	switch operation.Op {
+	case BatchCreate:
+		return s.CreateQuote(ctx, *operation.Quote)
+	case BatchUpdate:
+		return s.UpdateQuote(ctx, *operation.Id, *operation.Quote, operation.Version)
+	default:
+		return nil, s.DeleteQuote(ctx, *operation.Id, operation.Version)
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/dtos"
+	"quotes/internal/models"
+)
+
+func batchQuote(author, text string) *dtos.QuoteDto {
+	return &dtos.QuoteDto{Author: &author, Text: &text}
+}
+
+func TestApplyBatchValidatesOperationsFirst(t *testing.T) {
+	rules := DefaultValidationRules()
+	rules.MaxBatchOperations = 3
+	service := NewQuoteService(new(MockQuoteDriver), rules)
+	id := generateUuid()
+
+	for _, test := range []struct {
+		name       string
+		operations []dtos.BatchOperationDto
+		expected   map[string]string
+	}{
+		{"empty", nil, map[string]string{"operations": "required"}},
+		{"too many", make([]dtos.BatchOperationDto, 4), map[string]string{"operations": "too_many"}},
+		{"malformed", []dtos.BatchOperationDto{
+			{Op: "upsert", Id: &id},
+			{Op: BatchUpdate, Quote: batchQuote("author", "text")},
+			{Op: BatchDelete, Id: &id},
+		}, map[string]string{"operations[0].op": "invalid", "operations[1].id": "required"}},
+		{"invalid quote", []dtos.BatchOperationDto{
+			{Op: BatchCreate, Quote: batchQuote("author", "text")},
+			{Op: BatchCreate, Quote: batchQuote("author", " ")},
+		}, map[string]string{"operations[1].quote.text": "required"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := service.ApplyBatch(context.Background(), test.operations, true)
+
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			codes := make(map[string]string)
+			for _, field := range validationErr.Fields {
+				codes[field.Field] = field.Code
+			}
+			for field, code := range test.expected {
+				assert.Equal(t, code, codes[field], field)
+			}
+		})
+	}
+}
+
+func TestApplyBatchAtomicallyStopsAtFirstFailure(t *testing.T) {
+	mockDriver := new(MockQuoteDriver)
+	service := NewQuoteService(mockDriver, DefaultValidationRules())
+	id := generateUuid()
+
+	mockDriver.On("InTransaction", mock.Anything).Return(nil)
+	mockDriver.On("CreateQuote", mock.Anything, mock.Anything).Return(nil)
+	mockDriver.On("GetQuoteById", mock.Anything, id).Return(nil, pgx.ErrNoRows)
+
+	results, err := service.ApplyBatch(context.Background(), []dtos.BatchOperationDto{
+		{Op: BatchCreate, Quote: batchQuote("author", "text")},
+		{Op: BatchDelete, Id: &id},
+		{Op: BatchCreate, Quote: batchQuote("author", "other")},
+	}, true)
+
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 1, batchErr.Index)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	assert.Nil(t, results)
+	mockDriver.AssertNumberOfCalls(t, "CreateQuote", 1)
+}
+
+func TestApplyBatchBestEffortReportsEachOperation(t *testing.T) {
+	mockDriver := new(MockQuoteDriver)
+	service := NewQuoteService(mockDriver, DefaultValidationRules())
+	id := generateUuid()
+	failure := errors.New("connection reset")
+
+	mockDriver.On("CreateQuote", mock.Anything, mock.Anything).Return(nil)
+	mockDriver.On("GetQuoteById", mock.Anything, id).Return(&models.Quote{Id: id, Version: 2}, nil)
+	mockDriver.On("DeleteQuote", mock.Anything, id, int64(2)).Return(failure)
+
+	results, err := service.ApplyBatch(context.Background(), []dtos.BatchOperationDto{
+		{Op: BatchCreate, Quote: batchQuote("author", "")},
+		{Op: BatchDelete, Id: &id, Version: 2},
+		{Op: BatchCreate, Quote: batchQuote(" author ", "text")},
+	}, false)
+	require.NoError(t, err)
+
+	require.Len(t, results, 3)
+	var validationErr *ValidationError
+	assert.ErrorAs(t, results[0].Err, &validationErr)
+	assert.ErrorIs(t, results[1].Err, failure)
+	require.NoError(t, results[2].Err)
+	assert.Equal(t, "author", *results[2].Quote.Author)
+	mockDriver.AssertNotCalled(t, "InTransaction", mock.Anything)
+}
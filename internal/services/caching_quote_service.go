@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/jackc/pgx/v5/pgtype"
+	"quotes/internal/drivers"
+	"quotes/internal/dtos"
+)
+
+const (
+	cacheKeyList      = "list:"
+	cacheKeyAllQuotes = cacheKeyList + "all"
+	cacheKeyAuthor    = cacheKeyList + "author:"
+	// The time of the latest change goes stale together with the lists.
+	cacheKeyLastModified = cacheKeyList + "modified"
+	cacheKeyQuote        = "quote:"
+)
+
+type cacheEntry struct {
+	quotes     []dtos.QuoteDto
+	quote      *dtos.QuoteDto
+	modifiedAt time.Time
+}
+
+type CacheStats struct {
+	Hits          int64 `json:"hits"`
+	Misses        int64 `json:"misses"`
+	Invalidations int64 `json:"invalidations"`
+	Size          int   `json:"size"`
+}
+
+// CachingQuoteService wraps a QuoteServiceInterface and keeps the results of
+// quote lists and lookups by ID in an LRU cache with a TTL. Writes made
+// through it invalidate the cache; writes made by other instances are
+// reported through Invalidate and InvalidateAll. Random quotes are never
+// cached, and requests pinned to the primary database bypass the cache so
+// that clients still read their own writes.
+type CachingQuoteService struct {
+	service QuoteServiceInterface
+	entries *expirable.LRU[string, cacheEntry]
+
+	// generation is bumped under mu by every invalidation, so that a result
+	// read before an invalidation is not stored after it.
+	mu         sync.Mutex
+	generation uint64
+
+	hits          atomic.Int64
+	misses        atomic.Int64
+	invalidations atomic.Int64
+}
+
+func NewCachingQuoteService(service QuoteServiceInterface, size int, ttl time.Duration) *CachingQuoteService {
+	return &CachingQuoteService{
+		service: service,
+		entries: expirable.NewLRU[string, cacheEntry](size, nil, ttl),
+	}
+}
+
+func (s *CachingQuoteService) CreateQuote(ctx context.Context, quoteDto dtos.QuoteDto) (*dtos.QuoteDto, error) {
+	quote, err := s.service.CreateQuote(ctx, quoteDto)
+	if err == nil {
+		s.Invalidate(*quote.Id)
+	}
+
+	return quote, err
+}
+
+func (s *CachingQuoteService) UpdateQuote(ctx context.Context, id pgtype.UUID, quoteDto dtos.QuoteDto, version int64) (*dtos.QuoteDto, error) {
+	// The quote may have changed even if the call reports an error, e.g. a
+	// timeout after the commit, so the cache is invalidated regardless.
+	defer s.Invalidate(id)
+
+	return s.service.UpdateQuote(ctx, id, quoteDto, version)
+}
+
+func (s *CachingQuoteService) DeleteQuote(ctx context.Context, id pgtype.UUID, version int64) error {
+	defer s.Invalidate(id)
+
+	return s.service.DeleteQuote(ctx, id, version)
+}
+
+func (s *CachingQuoteService) ApplyBatch(ctx context.Context, operations []dtos.BatchOperationDto, allOrNothing bool) ([]BatchResult, error) {
+	results, err := s.service.ApplyBatch(ctx, operations, allOrNothing)
+
+	// Like UpdateQuote, the quotes are invalidated even if the batch failed.
+	for _, operation := range operations {
+		if operation.Id != nil {
+			s.Invalidate(*operation.Id)
+		}
+	}
+	for _, result := range results {
+		if result.Quote != nil {
+			s.Invalidate(*result.Quote.Id)
+		}
+	}
+
+	return results, err
+}
+
+func (s *CachingQuoteService) RenameAuthors(ctx context.Context, renameDto dtos.AuthorRenameDto) (*dtos.AuthorRenameResultDto, error) {
+	if !renameDto.DryRun {
+		// The renamed quotes are not known, and may be many.
+		defer s.InvalidateAll()
+	}
+
+	return s.service.RenameAuthors(ctx, renameDto)
+}
+
+func (s *CachingQuoteService) GetAllQuotes(ctx context.Context) ([]dtos.QuoteDto, error) {
+	entry, err := s.load(ctx, cacheKeyAllQuotes, func() (cacheEntry, error) {
+		quotes, err := s.service.GetAllQuotes(ctx)
+		return cacheEntry{quotes: quotes}, err
+	})
+
+	return entry.quotes, err
+}
+
+func (s *CachingQuoteService) GetQuotesByAuthor(ctx context.Context, author string) ([]dtos.QuoteDto, error) {
+	entry, err := s.load(ctx, cacheKeyAuthor+author, func() (cacheEntry, error) {
+		quotes, err := s.service.GetQuotesByAuthor(ctx, author)
+		return cacheEntry{quotes: quotes}, err
+	})
+
+	return entry.quotes, err
+}
+
+// GetQuotesByAuthors and GetQuotesByTags are not cached, since the same
+// combination of keys is rarely requested twice.
+func (s *CachingQuoteService) GetQuotesByAuthors(ctx context.Context, authors []string) (map[string][]dtos.QuoteDto, error) {
+	return s.service.GetQuotesByAuthors(ctx, authors)
+}
+
+func (s *CachingQuoteService) GetQuotesByTags(ctx context.Context, tags []string) (map[string][]dtos.QuoteDto, error) {
+	return s.service.GetQuotesByTags(ctx, tags)
+}
+
+func (s *CachingQuoteService) GetQuotesByTag(ctx context.Context, tag, author string) ([]dtos.QuoteDto, error) {
+	return s.service.GetQuotesByTag(ctx, tag, author)
+}
+
+// GetQuotesPage is not cached either, since pages are read once each by
+// clients walking through all quotes.
+func (s *CachingQuoteService) GetQuotesPage(ctx context.Context, author string, after pgtype.UUID, limit int) ([]dtos.QuoteDto, error) {
+	return s.service.GetQuotesPage(ctx, author, after, limit)
+}
+
+func (s *CachingQuoteService) GetRandomQuote(ctx context.Context) (*dtos.QuoteDto, error) {
+	return s.service.GetRandomQuote(ctx)
+}
+
+func (s *CachingQuoteService) GetQuoteById(ctx context.Context, id pgtype.UUID) (*dtos.QuoteDto, error) {
+	entry, err := s.load(ctx, cacheKeyQuote+id.String(), func() (cacheEntry, error) {
+		quote, err := s.service.GetQuoteById(ctx, id)
+		return cacheEntry{quote: quote}, err
+	})
+
+	return entry.quote, err
+}
+
+func (s *CachingQuoteService) GetLastModified(ctx context.Context) (time.Time, error) {
+	entry, err := s.load(ctx, cacheKeyLastModified, func() (cacheEntry, error) {
+		modifiedAt, err := s.service.GetLastModified(ctx)
+		return cacheEntry{modifiedAt: modifiedAt}, err
+	})
+
+	return entry.modifiedAt, err
+}
+
+// Invalidate drops the cached quote with the given ID and every cached
+// list, since any of them may contain it.
+func (s *CachingQuoteService) Invalidate(id pgtype.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.generation++
+	s.invalidations.Add(1)
+
+	s.entries.Remove(cacheKeyQuote + id.String())
+	for _, key := range s.entries.Keys() {
+		if strings.HasPrefix(key, cacheKeyList) {
+			s.entries.Remove(key)
+		}
+	}
+}
+
+// InvalidateAll empties the cache, e.g. after change notifications may
+// have been missed.
+func (s *CachingQuoteService) InvalidateAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.generation++
+	s.invalidations.Add(1)
+	s.entries.Purge()
+}
+
+func (s *CachingQuoteService) Stats() CacheStats {
+	return CacheStats{
+		Hits:          s.hits.Load(),
+		Misses:        s.misses.Load(),
+		Invalidations: s.invalidations.Load(),
+		Size:          s.entries.Len(),
+	}
+}
+
+// load returns a copy of the cached entry for key, fetching and caching it
+// if it is missing, so that callers never share quotes with the cache.
+func (s *CachingQuoteService) load(ctx context.Context, key string, fetch func() (cacheEntry, error)) (cacheEntry, error) {
+	if drivers.UsePrimary(ctx) {
+		return fetch()
+	}
+
+	if entry, ok := s.entries.Get(key); ok {
+		s.hits.Add(1)
+		return entry.clone(), nil
+	}
+	s.misses.Add(1)
+
+	s.mu.Lock()
+	generation := s.generation
+	s.mu.Unlock()
+
+	entry, err := fetch()
+	if err != nil {
+		return entry, err
+	}
+
+	s.mu.Lock()
+	if s.generation == generation {
+		s.entries.Add(key, entry)
+	}
+	s.mu.Unlock()
+
+	return entry.clone(), nil
+}
+
+// clone copies the quotes of the entry, down to their fields and tags.
+func (e cacheEntry) clone() cacheEntry {
+	if e.quotes != nil {
+		quotes := make([]dtos.QuoteDto, len(e.quotes))
+		for i, quote := range e.quotes {
+			quotes[i] = cloneQuoteDto(quote)
+		}
+		e.quotes = quotes
+	}
+	if e.quote != nil {
+		quote := cloneQuoteDto(*e.quote)
+		e.quote = &quote
+	}
+
+	return e
+}
+
+func cloneQuoteDto(quote dtos.QuoteDto) dtos.QuoteDto {
+	if quote.Id != nil {
+		id := *quote.Id
+		quote.Id = &id
+	}
+	if quote.Author != nil {
+		author := *quote.Author
+		quote.Author = &author
+	}
+	if quote.Text != nil {
+		text := *quote.Text
+		quote.Text = &text
+	}
+	quote.Tags = slices.Clone(quote.Tags)
+
+	return quote
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/drivers"
+	"quotes/internal/dtos"
+	"quotes/internal/models"
+)
+
+func newTestQuote() models.Quote {
+	return models.Quote{Id: pgtype.UUID{Bytes: uuid.New(), Valid: true}, Author: "author", Text: "text"}
+}
+
+func TestCachingQuoteServiceCachesReads(t *testing.T) {
+	ctx := context.Background()
+	mockDriver := new(MockQuoteDriver)
+	quoteService := NewCachingQuoteService(NewQuoteService(mockDriver, DefaultValidationRules()), 10, time.Minute)
+
+	quote := newTestQuote()
+	mockDriver.On("GetAllQuotes", mock.Anything).Return([]models.Quote{quote}, nil).Once()
+	mockDriver.On("GetQuoteById", mock.Anything, quote.Id).Return(&quote, nil).Once()
+
+	for range 3 {
+		quotes, err := quoteService.GetAllQuotes(ctx)
+		require.NoError(t, err)
+		assert.Len(t, quotes, 1)
+
+		quoteDto, err := quoteService.GetQuoteById(ctx, quote.Id)
+		require.NoError(t, err)
+		assert.Equal(t, quote.Text, *quoteDto.Text)
+	}
+
+	stats := quoteService.Stats()
+	assert.Equal(t, int64(4), stats.Hits)
+	assert.Equal(t, int64(2), stats.Misses)
+	assert.Equal(t, 2, stats.Size)
+	mockDriver.AssertExpectations(t)
+}
+
+func TestCachingQuoteServiceReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	mockDriver := new(MockQuoteDriver)
+	quoteService := NewCachingQuoteService(NewQuoteService(mockDriver, DefaultValidationRules()), 10, time.Minute)
+
+	quote := newTestQuote()
+	quote.Tags = []string{"wisdom"}
+	mockDriver.On("GetAllQuotes", mock.Anything).Return([]models.Quote{quote}, nil).Once()
+	mockDriver.On("GetQuoteById", mock.Anything, quote.Id).Return(&quote, nil).Once()
+
+	for range 2 {
+		quotes, err := quoteService.GetAllQuotes(ctx)
+		require.NoError(t, err)
+		require.Len(t, quotes, 1)
+		assert.Equal(t, "text", *quotes[0].Text)
+		assert.Equal(t, []string{"wisdom"}, quotes[0].Tags)
+		*quotes[0].Text = "changed"
+		quotes[0].Tags[0] = "changed"
+		quotes[0] = dtos.QuoteDto{}
+
+		quoteDto, err := quoteService.GetQuoteById(ctx, quote.Id)
+		require.NoError(t, err)
+		assert.Equal(t, "author", *quoteDto.Author)
+		*quoteDto.Author = "changed"
+	}
+}
+
+func TestCachingQuoteServiceDoesNotCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	mockDriver := new(MockQuoteDriver)
+	quoteService := NewCachingQuoteService(NewQuoteService(mockDriver, DefaultValidationRules()), 10, time.Minute)
+
+	id := pgtype.UUID{Bytes: uuid.New(), Valid: true}
+	mockDriver.On("GetQuoteById", mock.Anything, id).Return(nil, errors.New("no rows")).Twice()
+
+	_, err := quoteService.GetQuoteById(ctx, id)
+	assert.Error(t, err)
+	_, err = quoteService.GetQuoteById(ctx, id)
+	assert.Error(t, err)
+
+	mockDriver.AssertExpectations(t)
+}
+
+func TestCachingQuoteServiceInvalidatesOnWrites(t *testing.T) {
+	ctx := context.Background()
+	mockDriver := new(MockQuoteDriver)
+	quoteService := NewCachingQuoteService(NewQuoteService(mockDriver, DefaultValidationRules()), 10, time.Minute)
+
+	quote := newTestQuote()
+	mockDriver.On("GetQuotesByAuthor", mock.Anything, quote.Author).Return([]models.Quote{quote}, nil).Times(3)
+	mockDriver.On("GetQuoteById", mock.Anything, quote.Id).Return(&quote, nil)
+	mockDriver.On("CreateQuote", mock.Anything, mock.Anything).Return(nil)
+	mockDriver.On("DeleteQuote", mock.Anything, quote.Id, int64(0)).Return(nil)
+
+	_, err := quoteService.GetQuotesByAuthor(ctx, quote.Author)
+	require.NoError(t, err)
+
+	_, err = quoteService.CreateQuote(ctx, dtos.QuoteDto{Author: &quote.Author, Text: &quote.Text})
+	require.NoError(t, err)
+
+	_, err = quoteService.GetQuotesByAuthor(ctx, quote.Author)
+	require.NoError(t, err)
+
+	err = quoteService.DeleteQuote(ctx, quote.Id, 0)
+	require.NoError(t, err)
+
+	_, err = quoteService.GetQuotesByAuthor(ctx, quote.Author)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(0), quoteService.Stats().Hits)
+	mockDriver.AssertExpectations(t)
+}
+
+func TestCachingQuoteServiceInvalidateFromNotification(t *testing.T) {
+	ctx := context.Background()
+	mockDriver := new(MockQuoteDriver)
+	quoteService := NewCachingQuoteService(NewQuoteService(mockDriver, DefaultValidationRules()), 10, time.Minute)
+
+	quote := newTestQuote()
+	other := newTestQuote()
+	mockDriver.On("GetQuoteById", mock.Anything, quote.Id).Return(&quote, nil).Twice()
+	mockDriver.On("GetQuoteById", mock.Anything, other.Id).Return(&other, nil).Once()
+
+	for _, id := range []pgtype.UUID{quote.Id, other.Id} {
+		_, err := quoteService.GetQuoteById(ctx, id)
+		require.NoError(t, err)
+	}
+
+	quoteService.Invalidate(quote.Id)
+
+	for _, id := range []pgtype.UUID{quote.Id, other.Id} {
+		_, err := quoteService.GetQuoteById(ctx, id)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int64(1), quoteService.Stats().Hits, "only the other quote should stay cached")
+	mockDriver.AssertExpectations(t)
+}
+
+func TestCachingQuoteServiceSkipsStaleResults(t *testing.T) {
+	ctx := context.Background()
+	mockDriver := new(MockQuoteDriver)
+	quoteService := NewCachingQuoteService(NewQuoteService(mockDriver, DefaultValidationRules()), 10, time.Minute)
+
+	quote := newTestQuote()
+	mockDriver.On("GetAllQuotes", mock.Anything).Return([]models.Quote{quote}, nil).
+		Run(func(mock.Arguments) { quoteService.InvalidateAll() }).Once()
+	mockDriver.On("GetAllQuotes", mock.Anything).Return([]models.Quote{}, nil).Once()
+
+	_, err := quoteService.GetAllQuotes(ctx)
+	require.NoError(t, err)
+
+	quotes, err := quoteService.GetAllQuotes(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, quotes, "a result read during an invalidation must not be cached")
+	mockDriver.AssertExpectations(t)
+}
+
+func TestCachingQuoteServiceBypassedForPrimaryReads(t *testing.T) {
+	ctx := drivers.WithPrimary(context.Background())
+	mockDriver := new(MockQuoteDriver)
+	quoteService := NewCachingQuoteService(NewQuoteService(mockDriver, DefaultValidationRules()), 10, time.Minute)
+
+	mockDriver.On("GetAllQuotes", mock.Anything).Return([]models.Quote{}, nil).Twice()
+
+	for range 2 {
+		_, err := quoteService.GetAllQuotes(ctx)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 0, quoteService.Stats().Size)
+	mockDriver.AssertExpectations(t)
+}
+
+func TestCachingQuoteServiceExpiresEntries(t *testing.T) {
+	ctx := context.Background()
+	mockDriver := new(MockQuoteDriver)
+	quoteService := NewCachingQuoteService(NewQuoteService(mockDriver, DefaultValidationRules()), 10, 10*time.Millisecond)
+
+	mockDriver.On("GetAllQuotes", mock.Anything).Return([]models.Quote{}, nil).Twice()
+
+	_, err := quoteService.GetAllQuotes(ctx)
+	require.NoError(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+
+	_, err = quoteService.GetAllQuotes(ctx)
+	require.NoError(t, err)
+	mockDriver.AssertExpectations(t)
+}
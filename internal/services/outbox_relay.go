@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"quotes/internal/drivers"
+	"quotes/internal/models"
+)
+
+const (
+	// outboxPruneInterval is how often expired events are deleted.
+	outboxPruneInterval = time.Hour
+)
+
+// OutboxSink receives the events of the outbox. Publish may be called again
+// with events it has received already, if recording them published failed,
+// so sinks must tolerate duplicates. Name identifies the progress of the
+// sink in the outbox and must not change.
+type OutboxSink interface {
+	Name() string
+	Publish(ctx context.Context, events []models.OutboxEvent) error
+}
+
+// OutboxOptions configures an OutboxRelay.
+type OutboxOptions struct {
+	BatchSize    int
+	PollInterval time.Duration
+	// Retention is how long events are kept after being published.
+	Retention time.Duration
+}
+
+// outboxEnvelope is the JSON representation of an event sent to sinks and
+// webhooks. Data is the quote after the change, or before it for
+// deletions.
+type outboxEnvelope struct {
+	Id        int64           `json:"id"`
+	Type      string          `json:"type"`
+	QuoteId   pgtype.UUID     `json:"quote_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+func newOutboxEnvelope(event models.OutboxEvent) outboxEnvelope {
+	return outboxEnvelope{
+		Id:        event.Id,
+		Type:      event.Type,
+		QuoteId:   event.QuoteId,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Payload,
+	}
+}
+
+// OutboxRelay publishes the events of the outbox to every sink, in the
+// order they were recorded. Each sink keeps its own progress, so a failing
+// sink is retried on its own while the others go on; events count as
+// published once all sinks have accepted them. Events are delivered at
+// least once; instances relaying at the same time publish different
+// events.
+type OutboxRelay struct {
+	driver    drivers.OutboxDriverInterface
+	sinks     []OutboxSink
+	sinkNames []string
+	options   OutboxOptions
+	logger    *slog.Logger
+	now       func() time.Time
+}
+
+func NewOutboxRelay(driver drivers.OutboxDriverInterface, sinks []OutboxSink, options OutboxOptions, logger *slog.Logger) *OutboxRelay {
+	sinkNames := make([]string, len(sinks))
+	for i, sink := range sinks {
+		sinkNames[i] = sink.Name()
+	}
+
+	return &OutboxRelay{driver: driver, sinks: sinks, sinkNames: sinkNames, options: options, logger: logger, now: time.Now}
+}
+
+// Run relays every poll interval until ctx is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.options.PollInterval)
+	defer ticker.Stop()
+
+	var prunedAt time.Time
+	for {
+		r.relay(ctx)
+
+		if now := r.now(); now.Sub(prunedAt) >= outboxPruneInterval {
+			prunedAt = now
+			if pruned, err := r.driver.PruneEvents(ctx, now.Add(-r.options.Retention)); err != nil {
+				r.logger.Error("Failed to prune quote events", "error", err)
+			} else if pruned > 0 {
+				r.logger.Info("Pruned quote events", "count", pruned)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relay publishes the pending events to every sink in turn.
+func (r *OutboxRelay) relay(ctx context.Context) {
+	for _, sink := range r.sinks {
+		r.relayTo(ctx, sink)
+	}
+}
+
+// relayTo publishes batches of events to sink until none are left or
+// publishing fails, in which case the events are published to it again on
+// the next poll.
+func (r *OutboxRelay) relayTo(ctx context.Context, sink OutboxSink) {
+	publish := publisher(sink)
+	for ctx.Err() == nil {
+		published, err := r.driver.PublishEvents(ctx, sink.Name(), r.sinkNames, r.options.BatchSize, publish)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.logger.Error("Failed to relay quote events", "sink", sink.Name(), "error", err)
+			}
+			return
+		}
+		if published < r.options.BatchSize {
+			return
+		}
+	}
+}
+
+// publisher returns a function publishing events to sink, which reports
+// its failures as a *SinkError.
+func publisher(sink OutboxSink) func(ctx context.Context, events []models.OutboxEvent) error {
+	return func(ctx context.Context, events []models.OutboxEvent) error {
+		if err := sink.Publish(ctx, events); err != nil {
+			return &SinkError{Sink: sink.Name(), Err: err}
+		}
+		return nil
+	}
+}
+
+// SinkError reports a sink that failed to publish events.
+type SinkError struct {
+	Sink string
+	Err  error
+}
+
+func (e *SinkError) Error() string {
+	return "sink " + e.Sink + ": " + e.Err.Error()
+}
+
+func (e *SinkError) Unwrap() error {
+	return e.Err
+}
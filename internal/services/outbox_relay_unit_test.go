@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/models"
+)
+
+type MockOutboxDriver struct {
+	mock.Mock
+}
+
+// PublishEvents passes the events given to Return to publish, returning its
+// error, or the error given to Return.
+func (m *MockOutboxDriver) PublishEvents(ctx context.Context, sink string, sinks []string, limit int, publish func(ctx context.Context, events []models.OutboxEvent) error) (int, error) {
+	args := m.Called(ctx, sink, sinks, limit)
+	if err := args.Error(1); err != nil {
+		return 0, err
+	}
+
+	events := args.Get(0).([]models.OutboxEvent)
+	if len(events) == 0 {
+		return 0, nil
+	}
+	if err := publish(ctx, events); err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+func (m *MockOutboxDriver) PruneEvents(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type MockOutboxSink struct {
+	mock.Mock
+	name string
+}
+
+func (m *MockOutboxSink) Name() string {
+	return m.name
+}
+
+func (m *MockOutboxSink) Publish(ctx context.Context, events []models.OutboxEvent) error {
+	args := m.Called(ctx, events)
+	return args.Error(0)
+}
+
+var testOutboxOptions = OutboxOptions{BatchSize: 2, PollInterval: time.Second, Retention: time.Hour}
+
+func newTestRelay(driver *MockOutboxDriver, sinks ...OutboxSink) *OutboxRelay {
+	return NewOutboxRelay(driver, sinks, testOutboxOptions, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestOutboxRelayPublishesBatchesToEverySink(t *testing.T) {
+	first := []models.OutboxEvent{{Id: 1, Type: "quote.created"}, {Id: 2, Type: "quote.updated"}}
+	second := []models.OutboxEvent{{Id: 3, Type: "quote.deleted"}}
+	sinks := []*MockOutboxSink{{name: "log"}, {name: "file"}}
+	names := []string{"log", "file"}
+
+	mockDriver := new(MockOutboxDriver)
+	for _, sink := range sinks {
+		mockDriver.On("PublishEvents", mock.Anything, sink.name, names, 2).Return(first, nil).Once()
+		mockDriver.On("PublishEvents", mock.Anything, sink.name, names, 2).Return(second, nil).Once()
+		sink.On("Publish", mock.Anything, first).Return(nil).Once()
+		sink.On("Publish", mock.Anything, second).Return(nil).Once()
+	}
+
+	newTestRelay(mockDriver, sinks[0], sinks[1]).relay(context.Background())
+
+	// The partial second batch ends the relay to a sink without polling
+	// again.
+	mockDriver.AssertNumberOfCalls(t, "PublishEvents", 4)
+	for _, sink := range sinks {
+		sink.AssertExpectations(t)
+	}
+}
+
+func TestOutboxRelayFailingSinkDoesNotHoldBackOthers(t *testing.T) {
+	events := []models.OutboxEvent{{Id: 1, Type: "quote.created"}}
+	failing, working := &MockOutboxSink{name: "http"}, &MockOutboxSink{name: "webhooks"}
+	names := []string{"http", "webhooks"}
+	failing.On("Publish", mock.Anything, events).Return(errors.New("unavailable"))
+	working.On("Publish", mock.Anything, events).Return(nil)
+
+	mockDriver := new(MockOutboxDriver)
+	mockDriver.On("PublishEvents", mock.Anything, "http", names, 2).Return(events, nil).Once()
+	mockDriver.On("PublishEvents", mock.Anything, "webhooks", names, 2).Return(events, nil).Once()
+
+	newTestRelay(mockDriver, failing, working).relay(context.Background())
+
+	failing.AssertExpectations(t)
+	working.AssertExpectations(t)
+	mockDriver.AssertExpectations(t)
+}
+
+func TestOutboxRelayReportsFailingSinks(t *testing.T) {
+	events := []models.OutboxEvent{{Id: 1, Type: "quote.created"}}
+	failing := &MockOutboxSink{name: "http"}
+	failing.On("Publish", mock.Anything, events).Return(errors.New("unavailable"))
+
+	err := publisher(failing)(context.Background(), events)
+
+	var sinkErr *SinkError
+	require.ErrorAs(t, err, &sinkErr)
+	assert.Equal(t, "sink http: unavailable", sinkErr.Error())
+}
+
+func TestOutboxRelayStopsAfterFailure(t *testing.T) {
+	sinks := []*MockOutboxSink{{name: "log"}, {name: "file"}}
+	names := []string{"log", "file"}
+	mockDriver := new(MockOutboxDriver)
+	for _, sink := range sinks {
+		mockDriver.On("PublishEvents", mock.Anything, sink.name, names, 2).Return(nil, errors.New("connection refused"))
+	}
+
+	newTestRelay(mockDriver, sinks[0], sinks[1]).relay(context.Background())
+
+	// Each sink is tried once per poll.
+	mockDriver.AssertNumberOfCalls(t, "PublishEvents", 2)
+}
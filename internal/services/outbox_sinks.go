@@ -0,0 +1,160 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"quotes/internal/drivers"
+	"quotes/internal/models"
+)
+
+// LogSink writes each event to the log.
+type LogSink struct {
+	logger *slog.Logger
+}
+
+func NewLogSink(logger *slog.Logger) *LogSink {
+	return &LogSink{logger: logger}
+}
+
+func (s *LogSink) Name() string {
+	return "log"
+}
+
+func (s *LogSink) Publish(_ context.Context, events []models.OutboxEvent) error {
+	for _, event := range events {
+		s.logger.Info("Quote event", "event_id", event.Id, "type", event.Type, "quote_id", event.QuoteId.String(),
+			"data", json.RawMessage(event.Payload))
+	}
+
+	return nil
+}
+
+// maxSinkErrorLength bounds the response excerpt kept in the error of a
+// failed request of the http sink.
+const maxSinkErrorLength = 512
+
+// HttpSink posts each batch of events as a JSON array to a URL, succeeding
+// on any 2xx response.
+type HttpSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewHttpSink(url string, timeout time.Duration) *HttpSink {
+	return &HttpSink{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (s *HttpSink) Name() string {
+	return "http"
+}
+
+func (s *HttpSink) Publish(ctx context.Context, events []models.OutboxEvent) error {
+	body, err := marshalEnvelopes(events)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "quotes-outbox")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxSinkErrorLength))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(excerpt))
+	}
+
+	return nil
+}
+
+// FileSink appends each event as a line of JSON to a file, which is synced
+// before the events count as published.
+type FileSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+func (s *FileSink) Publish(_ context.Context, events []models.OutboxEvent) error {
+	var lines bytes.Buffer
+	encoder := json.NewEncoder(&lines)
+	for _, event := range events {
+		if err := encoder.Encode(newOutboxEnvelope(event)); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(lines.Bytes())
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// WebhookSink queues the events for the webhooks subscribed to them, which
+// the WebhookDispatcher then delivers.
+type WebhookSink struct {
+	driver drivers.WebhookDriverInterface
+}
+
+func NewWebhookSink(driver drivers.WebhookDriverInterface) *WebhookSink {
+	return &WebhookSink{driver: driver}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhooks"
+}
+
+func (s *WebhookSink) Publish(ctx context.Context, events []models.OutboxEvent) error {
+	ids := make([]int64, len(events))
+	for i, event := range events {
+		ids[i] = event.Id
+	}
+
+	return s.driver.CreateDeliveries(ctx, ids)
+}
+
+func marshalEnvelopes(events []models.OutboxEvent) ([]byte, error) {
+	envelopes := make([]outboxEnvelope, len(events))
+	for i, event := range events {
+		envelopes[i] = newOutboxEnvelope(event)
+	}
+
+	return json.Marshal(envelopes)
+}
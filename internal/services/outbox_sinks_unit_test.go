@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/models"
+)
+
+var testOutboxEvents = []models.OutboxEvent{
+	{Id: 1, Type: "quote.created", Payload: []byte(`{"text":"First"}`)},
+	{Id: 2, Type: "quote.deleted", Payload: []byte(`{"text":"Second"}`)},
+}
+
+func TestFileSinkAppendsJsonLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	sink := NewFileSink(path)
+
+	require.NoError(t, sink.Publish(context.Background(), testOutboxEvents[:1]))
+	require.NoError(t, sink.Publish(context.Background(), testOutboxEvents[1:]))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t,
+		`{"id":1,"type":"quote.created","quote_id":null,"created_at":"0001-01-01T00:00:00Z","data":{"text":"First"}}`+"\n"+
+			`{"id":2,"type":"quote.deleted","quote_id":null,"created_at":"0001-01-01T00:00:00Z","data":{"text":"Second"}}`+"\n",
+		string(content))
+}
+
+func TestHttpSinkPostsBatches(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	require.NoError(t, NewHttpSink(server.URL, time.Second).Publish(context.Background(), testOutboxEvents))
+
+	assert.JSONEq(t, `[
+		{"id":1,"type":"quote.created","quote_id":null,"created_at":"0001-01-01T00:00:00Z","data":{"text":"First"}},
+		{"id":2,"type":"quote.deleted","quote_id":null,"created_at":"0001-01-01T00:00:00Z","data":{"text":"Second"}}
+	]`, string(body))
+}
+
+func TestHttpSinkFailsOnErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := NewHttpSink(server.URL, time.Second).Publish(context.Background(), testOutboxEvents)
+
+	assert.EqualError(t, err, "unexpected status 500: overloaded")
+}
+
+func TestWebhookSinkCreatesDeliveries(t *testing.T) {
+	mockDriver := new(MockWebhookDriver)
+	mockDriver.On("CreateDeliveries", mock.Anything, []int64{1, 2}).Return(nil)
+
+	require.NoError(t, NewWebhookSink(mockDriver).Publish(context.Background(), testOutboxEvents))
+
+	mockDriver.AssertExpectations(t)
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"quotes/internal/drivers"
+	"quotes/internal/dtos"
+	"quotes/internal/logging"
+)
+
+const (
+	QuoteEventCreated = "created"
+	QuoteEventUpdated = "updated"
+	QuoteEventDeleted = "deleted"
+	// QuoteEventResync tells subscribers that events may have been missed,
+	// so that they reload the quotes they show.
+	QuoteEventResync = "resync"
+)
+
+const (
+	// subscriptionBuffer is how many events a subscriber may fall behind
+	// before it is dropped.
+	subscriptionBuffer = 64
+	quoteLookupTimeout = 5 * time.Second
+)
+
+var changeEventTypes = map[string]string{
+	drivers.QuoteInserted: QuoteEventCreated,
+	drivers.QuoteUpdated:  QuoteEventUpdated,
+	drivers.QuoteDeleted:  QuoteEventDeleted,
+}
+
+// QuoteEvent is a change of a quote as streamed to clients. Quote is the
+// quote after the change; it is nil for deletions, for resyncs and when the
+// quote was deleted again before it could be loaded.
+type QuoteEvent struct {
+	Id      int64
+	Type    string
+	QuoteId pgtype.UUID
+	Quote   *dtos.QuoteDto
+}
+
+// QuoteSubscription receives the events published after it was created.
+// Events is closed when the subscriber falls behind, when it unsubscribes
+// and when the broker is closed.
+type QuoteSubscription struct {
+	Events <-chan QuoteEvent
+	events chan QuoteEvent
+}
+
+// QuoteEventBroker fans the quote changes reported by
+// drivers.QuoteChangeListener out to subscribers, such as the clients of the
+// event stream. It keeps the latest events so that subscribers can resume
+// after the last event they saw, on any instance, as all instances receive
+// the same changes in the same order.
+type QuoteEventBroker struct {
+	service     QuoteServiceInterface
+	historySize int
+
+	mu          sync.Mutex
+	history     []QuoteEvent
+	subscribers map[*QuoteSubscription]struct{}
+	closed      bool
+}
+
+func NewQuoteEventBroker(service QuoteServiceInterface, historySize int) *QuoteEventBroker {
+	return &QuoteEventBroker{
+		service:     service,
+		historySize: historySize,
+		subscribers: make(map[*QuoteSubscription]struct{}),
+	}
+}
+
+// Publish loads the changed quote and sends the event to every subscriber.
+// It is meant to be registered with QuoteChangeListener.OnChange, after the
+// cache invalidation, so that the quote is not read from a stale cache.
+func (b *QuoteEventBroker) Publish(change drivers.QuoteChange) {
+	if change.Operation == drivers.QuotesResync {
+		b.mu.Lock()
+		// Events were missed, so the history can no longer be resumed.
+		b.history = nil
+		b.broadcast(QuoteEvent{Type: QuoteEventResync})
+		b.mu.Unlock()
+		return
+	}
+
+	eventType, ok := changeEventTypes[change.Operation]
+	if !ok {
+		return
+	}
+
+	event := QuoteEvent{Id: change.EventId, Type: eventType, QuoteId: change.Id}
+	if eventType != QuoteEventDeleted {
+		event.Quote = b.loadQuote(change.Id)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Changes without an ID predate the migration that numbers them and
+	// cannot be resumed from.
+	if event.Id != 0 {
+		b.history = append(b.history, event)
+		if len(b.history) > b.historySize {
+			b.history = b.history[len(b.history)-b.historySize:]
+		}
+	}
+	b.broadcast(event)
+}
+
+func (b *QuoteEventBroker) loadQuote(id pgtype.UUID) *dtos.QuoteDto {
+	ctx, cancel := context.WithTimeout(context.Background(), quoteLookupTimeout)
+	defer cancel()
+
+	// Replicas may not have the change yet, and the cache may hold the quote
+	// from before it.
+	quote, err := b.service.GetQuoteById(drivers.WithPrimary(ctx), id)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logging.FromContext(ctx).Error("Failed to load changed quote", "id", id.String(), "error", err)
+		}
+		return nil
+	}
+
+	return quote
+}
+
+// broadcast sends event to every subscriber, dropping those that fell
+// behind. They can resume from their last event. mu must be held.
+func (b *QuoteEventBroker) broadcast(event QuoteEvent) {
+	for subscription := range b.subscribers {
+		select {
+		case subscription.events <- event:
+		default:
+			b.remove(subscription)
+		}
+	}
+}
+
+// Subscribe starts receiving events. With a lastEventId other than 0, it
+// also returns the events that followed it, or a single resync event if
+// they are no longer known.
+func (b *QuoteEventBroker) Subscribe(lastEventId int64) ([]QuoteEvent, *QuoteSubscription) {
+	events := make(chan QuoteEvent, subscriptionBuffer)
+	subscription := &QuoteSubscription{Events: events, events: events}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(events)
+		return nil, subscription
+	}
+	b.subscribers[subscription] = struct{}{}
+
+	if lastEventId == 0 {
+		return nil, subscription
+	}
+	for i, event := range b.history {
+		if event.Id == lastEventId {
+			return append([]QuoteEvent(nil), b.history[i+1:]...), subscription
+		}
+	}
+
+	return []QuoteEvent{{Type: QuoteEventResync}}, subscription
+}
+
+// Unsubscribe stops the events of subscription and closes its channel.
+func (b *QuoteEventBroker) Unsubscribe(subscription *QuoteSubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(subscription)
+}
+
+func (b *QuoteEventBroker) remove(subscription *QuoteSubscription) {
+	if _, ok := b.subscribers[subscription]; ok {
+		delete(b.subscribers, subscription)
+		close(subscription.events)
+	}
+}
+
+// Close ends every subscription, so that streams do not hold up a graceful
+// shutdown, and rejects new ones.
+func (b *QuoteEventBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for subscription := range b.subscribers {
+		b.remove(subscription)
+	}
+}
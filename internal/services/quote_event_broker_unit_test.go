@@ -0,0 +1,82 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/drivers"
+)
+
+func TestQuoteEventBrokerPublishesChanges(t *testing.T) {
+	mockDriver := new(MockQuoteDriver)
+	broker := NewQuoteEventBroker(NewQuoteService(mockDriver, DefaultValidationRules()), 10)
+
+	quote := newTestQuote()
+	mockDriver.On("GetQuoteById", mock.MatchedBy(drivers.UsePrimary), quote.Id).Return(&quote, nil).Once()
+	_, subscription := broker.Subscribe(0)
+
+	broker.Publish(drivers.QuoteChange{Operation: drivers.QuoteInserted, Id: quote.Id, EventId: 1})
+	broker.Publish(drivers.QuoteChange{Operation: drivers.QuoteDeleted, Id: quote.Id, EventId: 2})
+
+	created := <-subscription.Events
+	assert.Equal(t, int64(1), created.Id)
+	assert.Equal(t, QuoteEventCreated, created.Type)
+	require.NotNil(t, created.Quote)
+	assert.Equal(t, quote.Text, *created.Quote.Text)
+
+	deleted := <-subscription.Events
+	assert.Equal(t, QuoteEventDeleted, deleted.Type)
+	assert.Equal(t, quote.Id, deleted.QuoteId)
+	assert.Nil(t, deleted.Quote)
+	mockDriver.AssertExpectations(t)
+}
+
+func TestQuoteEventBrokerResumesAfterLastEvent(t *testing.T) {
+	mockDriver := new(MockQuoteDriver)
+	broker := NewQuoteEventBroker(NewQuoteService(mockDriver, DefaultValidationRules()), 2)
+
+	quote := newTestQuote()
+	mockDriver.On("GetQuoteById", mock.Anything, quote.Id).Return(nil, pgx.ErrNoRows)
+	// IDs follow the order of the calls to nextval, not of the commits.
+	for _, id := range []int64{5, 7, 6} {
+		broker.Publish(drivers.QuoteChange{Operation: drivers.QuoteUpdated, Id: quote.Id, EventId: id})
+	}
+
+	replay, _ := broker.Subscribe(7)
+	require.Len(t, replay, 1)
+	assert.Equal(t, int64(6), replay[0].Id)
+	assert.Nil(t, replay[0].Quote)
+
+	// Event 5 no longer fits in the history.
+	replay, _ = broker.Subscribe(5)
+	assert.Equal(t, []QuoteEvent{{Type: QuoteEventResync}}, replay)
+
+	broker.Publish(drivers.QuoteChange{Operation: drivers.QuotesResync})
+	replay, _ = broker.Subscribe(6)
+	assert.Equal(t, []QuoteEvent{{Type: QuoteEventResync}}, replay)
+}
+
+func TestQuoteEventBrokerDropsSlowSubscribers(t *testing.T) {
+	mockDriver := new(MockQuoteDriver)
+	broker := NewQuoteEventBroker(NewQuoteService(mockDriver, DefaultValidationRules()), 10)
+
+	quote := newTestQuote()
+	_, subscription := broker.Subscribe(0)
+	for i := range subscriptionBuffer + 1 {
+		broker.Publish(drivers.QuoteChange{Operation: drivers.QuoteDeleted, Id: quote.Id, EventId: int64(i + 1)})
+	}
+
+	received := 0
+	for range subscription.Events {
+		received++
+	}
+	assert.Equal(t, subscriptionBuffer, received)
+
+	broker.Close()
+	_, subscription = broker.Subscribe(0)
+	_, open := <-subscription.Events
+	assert.False(t, open)
+}
@@ -7,37 +7,60 @@ import (
 	"quotes/internal/drivers"
 	"quotes/internal/dtos"
 	"quotes/internal/models"
+	"slices"
+	"time"
 )
 
 type QuoteService struct {
 	driver drivers.QuoteDriverInterface
+	rules  ValidationRules
 }
 
-func NewQuoteService(driver drivers.QuoteDriverInterface) *QuoteService {
-	return &QuoteService{driver: driver}
+func NewQuoteService(driver drivers.QuoteDriverInterface, rules ValidationRules) *QuoteService {
+	return &QuoteService{driver: driver, rules: rules}
 }
 
 func (s *QuoteService) CreateQuote(ctx context.Context, quoteDto dtos.QuoteDto) (*dtos.QuoteDto, error) {
+	if err := s.rules.validateQuote(&quoteDto); err != nil {
+		return nil, err
+	}
+
 	id := generateUuid()
 
-	quote := &models.Quote{Id: id, Author: *quoteDto.Author, Text: *quoteDto.Text}
+	quote := &models.Quote{Id: id, Author: *quoteDto.Author, Text: *quoteDto.Text, Tags: quoteDto.Tags}
 	err := s.driver.CreateQuote(ctx, quote)
 	if err != nil {
 		return nil, err
 	}
 
-	quoteDto.Id = &id
+	quoteDto = newQuoteDto(*quote)
 
 	return &quoteDto, nil
 }
 
-func (s *QuoteService) DeleteQuote(ctx context.Context, id pgtype.UUID) error {
+func (s *QuoteService) UpdateQuote(ctx context.Context, id pgtype.UUID, quoteDto dtos.QuoteDto, version int64) (*dtos.QuoteDto, error) {
+	if err := s.rules.validateQuote(&quoteDto); err != nil {
+		return nil, err
+	}
+
+	quote := &models.Quote{Id: id, Author: *quoteDto.Author, Text: *quoteDto.Text, Tags: quoteDto.Tags}
+	err := s.driver.UpdateQuote(ctx, quote, version)
+	if err != nil {
+		return nil, err
+	}
+
+	quoteDto = newQuoteDto(*quote)
+
+	return &quoteDto, nil
+}
+
+func (s *QuoteService) DeleteQuote(ctx context.Context, id pgtype.UUID, version int64) error {
 	_, err := s.driver.GetQuoteById(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	err = s.driver.DeleteQuote(ctx, id)
+	err = s.driver.DeleteQuote(ctx, id, version)
 	return err
 }
 
@@ -47,26 +70,93 @@ func (s *QuoteService) GetAllQuotes(ctx context.Context) ([]dtos.QuoteDto, error
 		return nil, err
 	}
 
-	quoteDtos := make([]dtos.QuoteDto, len(quotes))
-	for i, quote := range quotes {
-		quoteDtos[i] = dtos.QuoteDto{Id: &quote.Id, Author: &quote.Author, Text: &quote.Text}
+	return newQuoteDtos(quotes), nil
+}
+
+// GetQuotesByAuthor normalizes author like stored authors, so that it matches
+// them however it is composed.
+func (s *QuoteService) GetQuotesByAuthor(ctx context.Context, author string) ([]dtos.QuoteDto, error) {
+	quotes, err := s.driver.GetQuotesByAuthor(ctx, s.rules.normalize(author))
+	if err != nil {
+		return nil, err
 	}
 
-	return quoteDtos, nil
+	return newQuoteDtos(quotes), nil
 }
 
-func (s *QuoteService) GetQuotesByAuthor(ctx context.Context, author string) ([]dtos.QuoteDto, error) {
-	quotes, err := s.driver.GetQuotesByAuthor(ctx, author)
+// GetQuotesByAuthors and GetQuotesByTags normalize authors and tags like
+// stored ones, and group the quotes by the authors and tags as given.
+func (s *QuoteService) GetQuotesByAuthors(ctx context.Context, authors []string) (map[string][]dtos.QuoteDto, error) {
+	normalized := make([]string, len(authors))
+	for i, author := range authors {
+		normalized[i] = s.rules.normalize(author)
+	}
+
+	quotes, err := s.driver.GetQuotesByAuthors(ctx, normalized)
 	if err != nil {
 		return nil, err
 	}
 
-	quoteDtos := make([]dtos.QuoteDto, len(quotes))
-	for i, quote := range quotes {
-		quoteDtos[i] = dtos.QuoteDto{Id: &quote.Id, Author: &quote.Author, Text: &quote.Text}
+	grouped := make(map[string][]dtos.QuoteDto)
+	for _, quote := range quotes {
+		for i, author := range normalized {
+			if quote.Author == author {
+				grouped[authors[i]] = append(grouped[authors[i]], newQuoteDto(quote))
+			}
+		}
+	}
+
+	return grouped, nil
+}
+
+func (s *QuoteService) GetQuotesByTags(ctx context.Context, tags []string) (map[string][]dtos.QuoteDto, error) {
+	normalized := make([]string, len(tags))
+	for i, tag := range tags {
+		normalized[i] = s.rules.NormalizeTag(tag)
+	}
+
+	quotes, err := s.driver.GetQuotesByTags(ctx, normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	grouped := make(map[string][]dtos.QuoteDto)
+	for _, quote := range quotes {
+		for i, tag := range normalized {
+			if slices.Contains(quote.Tags, tag) {
+				grouped[tags[i]] = append(grouped[tags[i]], newQuoteDto(quote))
+			}
+		}
+	}
+
+	return grouped, nil
+}
+
+func (s *QuoteService) GetQuotesByTag(ctx context.Context, tag, author string) ([]dtos.QuoteDto, error) {
+	quotes, err := s.driver.GetQuotesByTags(ctx, []string{s.rules.NormalizeTag(tag)})
+	if err != nil {
+		return nil, err
+	}
+
+	if author != "" {
+		author = s.rules.normalize(author)
+		quotes = slices.DeleteFunc(quotes, func(quote models.Quote) bool { return quote.Author != author })
+	}
+
+	return newQuoteDtos(quotes), nil
+}
+
+func (s *QuoteService) GetQuotesPage(ctx context.Context, author string, after pgtype.UUID, limit int) ([]dtos.QuoteDto, error) {
+	if author != "" {
+		author = s.rules.normalize(author)
+	}
+
+	quotes, err := s.driver.GetQuotesPage(ctx, author, after, limit)
+	if err != nil {
+		return nil, err
 	}
 
-	return quoteDtos, nil
+	return newQuoteDtos(quotes), nil
 }
 
 func (s *QuoteService) GetRandomQuote(ctx context.Context) (*dtos.QuoteDto, error) {
@@ -75,8 +165,100 @@ func (s *QuoteService) GetRandomQuote(ctx context.Context) (*dtos.QuoteDto, erro
 		return nil, err
 	}
 
-	quoteDto := &dtos.QuoteDto{Id: &quote.Id, Author: &quote.Author, Text: &quote.Text}
-	return quoteDto, nil
+	quoteDto := newQuoteDto(*quote)
+	return &quoteDto, nil
+}
+
+func (s *QuoteService) GetQuoteById(ctx context.Context, id pgtype.UUID) (*dtos.QuoteDto, error) {
+	quote, err := s.driver.GetQuoteById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	quote.Id = id
+	quoteDto := newQuoteDto(*quote)
+	return &quoteDto, nil
+}
+
+// RenameAuthors updates the quotes one by one, so that each change is
+// versioned and announced like an update through the API.
+func (s *QuoteService) RenameAuthors(ctx context.Context, renameDto dtos.AuthorRenameDto) (*dtos.AuthorRenameResultDto, error) {
+	if err := s.rules.validateAuthorRename(&renameDto); err != nil {
+		return nil, err
+	}
+
+	rename := func(driver drivers.QuoteDriverInterface) (int, error) {
+		quotes, err := driver.GetQuotesByAuthors(ctx, renameDto.From)
+		if err != nil {
+			return 0, err
+		}
+
+		renamed := 0
+		for _, quote := range quotes {
+			if quote.Author == renameDto.To {
+				continue
+			}
+			renamed++
+			if renameDto.DryRun {
+				continue
+			}
+
+			quote.Author = renameDto.To
+			if err := driver.UpdateQuote(ctx, &quote, quote.Version); err != nil {
+				return 0, err
+			}
+		}
+
+		return renamed, nil
+	}
+
+	result := &dtos.AuthorRenameResultDto{DryRun: renameDto.DryRun}
+	if renameDto.DryRun {
+		var err error
+		result.Quotes, err = rename(s.driver)
+		return result, err
+	}
+
+	err := s.driver.InTransaction(ctx, func(tx drivers.QuoteDriverInterface) error {
+		var err error
+		result.Quotes, err = rename(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *QuoteService) GetLastModified(ctx context.Context) (time.Time, error) {
+	return s.driver.GetLastModified(ctx)
+}
+
+// newQuoteDto converts a stored quote. Quotes without tags get an empty list
+// rather than none.
+func newQuoteDto(quote models.Quote) dtos.QuoteDto {
+	if quote.Tags == nil {
+		quote.Tags = []string{}
+	}
+
+	return dtos.QuoteDto{
+		Id:        &quote.Id,
+		Author:    &quote.Author,
+		Text:      &quote.Text,
+		Tags:      quote.Tags,
+		Version:   quote.Version,
+		UpdatedAt: quote.UpdatedAt,
+	}
+}
+
+func newQuoteDtos(quotes []models.Quote) []dtos.QuoteDto {
+	quoteDtos := make([]dtos.QuoteDto, len(quotes))
+	for i, quote := range quotes {
+		quoteDtos[i] = newQuoteDto(quote)
+	}
+
+	return quoteDtos
 }
 
 func generateUuid() pgtype.UUID {
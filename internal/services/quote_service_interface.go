@@ -2,14 +2,45 @@ package services
 
 import (
 	"context"
+	"time"
+
 	"github.com/jackc/pgx/v5/pgtype"
 	"quotes/internal/dtos"
 )
 
 type QuoteServiceInterface interface {
 	CreateQuote(ctx context.Context, quoteDto dtos.QuoteDto) (*dtos.QuoteDto, error)
-	DeleteQuote(ctx context.Context, id pgtype.UUID) error
+	// UpdateQuote and DeleteQuote apply only to the given version of the
+	// quote, or to any version if it is 0.
+	UpdateQuote(ctx context.Context, id pgtype.UUID, quoteDto dtos.QuoteDto, version int64) (*dtos.QuoteDto, error)
+	DeleteQuote(ctx context.Context, id pgtype.UUID, version int64) error
 	GetAllQuotes(ctx context.Context) ([]dtos.QuoteDto, error)
 	GetQuotesByAuthor(ctx context.Context, author string) ([]dtos.QuoteDto, error)
+	// GetQuotesByAuthors and GetQuotesByTags load the quotes of many authors
+	// or tags at once. The result maps each given author or tag, as given, to
+	// its quotes; authors and tags without quotes are left out.
+	GetQuotesByAuthors(ctx context.Context, authors []string) (map[string][]dtos.QuoteDto, error)
+	GetQuotesByTags(ctx context.Context, tags []string) (map[string][]dtos.QuoteDto, error)
+	// GetQuotesByTag returns the quotes having tag, those of author only
+	// unless it is empty, matching both like GetQuotesByTags and
+	// GetQuotesByAuthor do.
+	GetQuotesByTag(ctx context.Context, tag, author string) ([]dtos.QuoteDto, error)
+	// GetQuotesPage returns up to limit quotes in the order of their ids,
+	// those of author only unless it is empty, following the quote with id
+	// after, or from the first if after is not valid.
+	GetQuotesPage(ctx context.Context, author string, after pgtype.UUID, limit int) ([]dtos.QuoteDto, error)
 	GetRandomQuote(ctx context.Context) (*dtos.QuoteDto, error)
+	GetQuoteById(ctx context.Context, id pgtype.UUID) (*dtos.QuoteDto, error)
+	GetLastModified(ctx context.Context) (time.Time, error)
+	// ApplyBatch applies create, update and delete operations in order. It
+	// returns a *ValidationError, applying none, if any operation is
+	// malformed or there are too many, or if a quote of an all-or-nothing
+	// batch is invalid. In such a batch the first failing operation undoes
+	// the others and is returned as a *BatchError. Otherwise each operation
+	// is applied on its own and its result reports whether it failed.
+	ApplyBatch(ctx context.Context, operations []dtos.BatchOperationDto, allOrNothing bool) ([]BatchResult, error)
+	// RenameAuthors gives every quote of the given authors the new author in
+	// one transaction, which fails with a *VersionConflictError if one of
+	// the quotes changes meanwhile. A dry run only counts the quotes.
+	RenameAuthors(ctx context.Context, renameDto dtos.AuthorRenameDto) (*dtos.AuthorRenameResultDto, error)
 }
@@ -6,9 +6,12 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/drivers"
 	"quotes/internal/dtos"
 	"quotes/internal/models"
 	"testing"
+	"time"
 )
 
 type MockQuoteDriver struct {
@@ -20,11 +23,31 @@ func (m *MockQuoteDriver) CreateQuote(ctx context.Context, quote *models.Quote)
 	return args.Error(0)
 }
 
-func (m *MockQuoteDriver) DeleteQuote(ctx context.Context, id pgtype.UUID) error {
-	args := m.Called(ctx, id)
+func (m *MockQuoteDriver) UpdateQuote(ctx context.Context, quote *models.Quote, version int64) error {
+	args := m.Called(ctx, quote, version)
 	return args.Error(0)
 }
 
+func (m *MockQuoteDriver) DeleteQuote(ctx context.Context, id pgtype.UUID, version int64) error {
+	args := m.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+// InTransaction calls fn with the mock itself, unless an error is given to
+// Return.
+func (m *MockQuoteDriver) InTransaction(ctx context.Context, fn func(tx drivers.QuoteDriverInterface) error) error {
+	if err := m.Called(ctx).Error(0); err != nil {
+		return err
+	}
+
+	return fn(m)
+}
+
+func (m *MockQuoteDriver) GetLastModified(ctx context.Context) (time.Time, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
 func (m *MockQuoteDriver) GetAllQuotes(ctx context.Context) ([]models.Quote, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
@@ -41,6 +64,30 @@ func (m *MockQuoteDriver) GetQuotesByAuthor(ctx context.Context, author string)
 	return args.Get(0).([]models.Quote), args.Error(1)
 }
 
+func (m *MockQuoteDriver) GetQuotesByAuthors(ctx context.Context, authors []string) ([]models.Quote, error) {
+	args := m.Called(ctx, authors)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Quote), args.Error(1)
+}
+
+func (m *MockQuoteDriver) GetQuotesByTags(ctx context.Context, tags []string) ([]models.Quote, error) {
+	args := m.Called(ctx, tags)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Quote), args.Error(1)
+}
+
+func (m *MockQuoteDriver) GetQuotesPage(ctx context.Context, author string, after pgtype.UUID, limit int) ([]models.Quote, error) {
+	args := m.Called(ctx, author, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Quote), args.Error(1)
+}
+
 func (m *MockQuoteDriver) GetRandomQuote(ctx context.Context) (*models.Quote, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
@@ -60,7 +107,7 @@ func (m *MockQuoteDriver) GetQuoteById(ctx context.Context, id pgtype.UUID) (*mo
 func TestCreateQuote(t *testing.T) {
 	ctx := context.Background()
 	mockDriver := new(MockQuoteDriver)
-	quoteService := NewQuoteService(mockDriver)
+	quoteService := NewQuoteService(mockDriver, DefaultValidationRules())
 
 	author := "author"
 	text := "text"
@@ -82,7 +129,7 @@ func TestCreateQuote(t *testing.T) {
 func TestDeleteQuote(t *testing.T) {
 	ctx := context.Background()
 	mockDriver := new(MockQuoteDriver)
-	quoteService := NewQuoteService(mockDriver)
+	quoteService := NewQuoteService(mockDriver, DefaultValidationRules())
 
 	idBytes := uuid.New()
 	id := pgtype.UUID{Bytes: idBytes, Valid: true}
@@ -94,9 +141,9 @@ func TestDeleteQuote(t *testing.T) {
 		Author: author,
 		Text:   text,
 	}, nil)
-	mockDriver.On("DeleteQuote", mock.Anything, mock.Anything).Return(nil)
+	mockDriver.On("DeleteQuote", mock.Anything, mock.Anything, int64(0)).Return(nil)
 
-	err := quoteService.DeleteQuote(ctx, id)
+	err := quoteService.DeleteQuote(ctx, id, 0)
 
 	assert.NoError(t, err)
 	mockDriver.AssertExpectations(t)
@@ -105,7 +152,7 @@ func TestDeleteQuote(t *testing.T) {
 func TestGetAllQuotes(t *testing.T) {
 	ctx := context.Background()
 	mockDriver := new(MockQuoteDriver)
-	quoteService := NewQuoteService(mockDriver)
+	quoteService := NewQuoteService(mockDriver, DefaultValidationRules())
 
 	idBytes := uuid.New()
 	id := pgtype.UUID{Bytes: idBytes, Valid: true}
@@ -132,7 +179,7 @@ func TestGetAllQuotes(t *testing.T) {
 func TestGetQuotesByAuthor(t *testing.T) {
 	ctx := context.Background()
 	mockDriver := new(MockQuoteDriver)
-	quoteService := NewQuoteService(mockDriver)
+	quoteService := NewQuoteService(mockDriver, DefaultValidationRules())
 
 	idBytes := uuid.New()
 	id := pgtype.UUID{Bytes: idBytes, Valid: true}
@@ -159,7 +206,7 @@ func TestGetQuotesByAuthor(t *testing.T) {
 func TestGetRandomQuote(t *testing.T) {
 	ctx := context.Background()
 	mockDriver := new(MockQuoteDriver)
-	quoteService := NewQuoteService(mockDriver)
+	quoteService := NewQuoteService(mockDriver, DefaultValidationRules())
 
 	idBytes := uuid.New()
 	id := pgtype.UUID{Bytes: idBytes, Valid: true}
@@ -179,3 +226,116 @@ func TestGetRandomQuote(t *testing.T) {
 	assert.Equal(t, text, *quoteDto.Text)
 	mockDriver.AssertExpectations(t)
 }
+
+func TestGetQuoteById(t *testing.T) {
+	ctx := context.Background()
+	mockDriver := new(MockQuoteDriver)
+	quoteService := NewQuoteService(mockDriver, DefaultValidationRules())
+
+	id := pgtype.UUID{Bytes: uuid.New(), Valid: true}
+	author := "author"
+	text := "text"
+
+	mockDriver.On("GetQuoteById", mock.Anything, id).Return(&models.Quote{Author: author, Text: text}, nil)
+
+	quoteDto, err := quoteService.GetQuoteById(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, id, *quoteDto.Id)
+	assert.Equal(t, author, *quoteDto.Author)
+	assert.Equal(t, text, *quoteDto.Text)
+	mockDriver.AssertExpectations(t)
+}
+
+func TestGetQuotesByTagsGroupsByGivenTag(t *testing.T) {
+	ctx := context.Background()
+	mockDriver := new(MockQuoteDriver)
+	quoteService := NewQuoteService(mockDriver, DefaultValidationRules())
+
+	life := models.Quote{Id: pgtype.UUID{Bytes: uuid.New(), Valid: true}, Author: "author", Text: "text0", Tags: []string{"life"}}
+	both := models.Quote{Id: pgtype.UUID{Bytes: uuid.New(), Valid: true}, Author: "author", Text: "text1", Tags: []string{"life", "love"}}
+
+	mockDriver.On("GetQuotesByTags", mock.Anything, []string{"life", "love", "missing"}).Return([]models.Quote{life, both}, nil)
+
+	grouped, err := quoteService.GetQuotesByTags(ctx, []string{" Life", "love", "missing"})
+	assert.NoError(t, err)
+	assert.Len(t, grouped, 2)
+	assert.Len(t, grouped[" Life"], 2)
+	assert.Len(t, grouped["love"], 1)
+	assert.Equal(t, "text1", *grouped["love"][0].Text)
+	mockDriver.AssertExpectations(t)
+}
+
+func TestGetQuotesByTagMatchesAuthorLikeGetQuotesByAuthor(t *testing.T) {
+	ctx := context.Background()
+	mockDriver := new(MockQuoteDriver)
+	quoteService := NewQuoteService(mockDriver, DefaultValidationRules())
+
+	quotes := []models.Quote{
+		{Id: generateUuid(), Author: "Mark Twain", Text: "text0", Tags: []string{"life"}},
+		{Id: generateUuid(), Author: "mark twain", Text: "text1", Tags: []string{"life"}},
+	}
+	mockDriver.On("GetQuotesByTags", mock.Anything, []string{"life"}).Return(quotes, nil)
+
+	all, err := quoteService.GetQuotesByTag(ctx, " Life", "")
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	byAuthor, err := quoteService.GetQuotesByTag(ctx, "life", "  Mark   Twain ")
+	require.NoError(t, err)
+	require.Len(t, byAuthor, 1)
+	assert.Equal(t, "text0", *byAuthor[0].Text)
+}
+
+func TestRenameAuthorsMergesIntoOneAuthor(t *testing.T) {
+	ctx := context.Background()
+	quotes := []models.Quote{
+		{Id: generateUuid(), Author: "Samuel Clemens", Text: "text0", Version: 3},
+		{Id: generateUuid(), Author: "Mark Twain", Text: "text1", Version: 1},
+		{Id: generateUuid(), Author: "S. Clemens", Text: "text2", Version: 2},
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		mockDriver := new(MockQuoteDriver)
+		quoteService := NewQuoteService(mockDriver, DefaultValidationRules())
+		mockDriver.On("InTransaction", mock.Anything).Return(nil)
+		mockDriver.On("GetQuotesByAuthors", mock.Anything, []string{"Samuel Clemens", "S. Clemens", "Mark Twain"}).Return(quotes, nil)
+		mockDriver.On("UpdateQuote", mock.Anything, mock.MatchedBy(func(quote *models.Quote) bool {
+			return quote.Author == "Mark Twain"
+		}), mock.Anything).Return(nil)
+
+		result, err := quoteService.RenameAuthors(ctx, dtos.AuthorRenameDto{
+			From:   []string{" Samuel  Clemens", "S. Clemens", "Mark Twain", "Samuel Clemens"},
+			To:     "Mark Twain ",
+			DryRun: dryRun,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, &dtos.AuthorRenameResultDto{Quotes: 2, DryRun: dryRun}, result)
+
+		if dryRun {
+			mockDriver.AssertNotCalled(t, "UpdateQuote", mock.Anything, mock.Anything, mock.Anything)
+			mockDriver.AssertNotCalled(t, "InTransaction", mock.Anything)
+		} else {
+			// Each quote is updated at the version it was read at.
+			mockDriver.AssertCalled(t, "UpdateQuote", mock.Anything, mock.Anything, int64(3))
+			mockDriver.AssertCalled(t, "UpdateQuote", mock.Anything, mock.Anything, int64(2))
+			mockDriver.AssertNumberOfCalls(t, "UpdateQuote", 2)
+		}
+	}
+}
+
+func TestRenameAuthorsFailsOnConcurrentChange(t *testing.T) {
+	ctx := context.Background()
+	mockDriver := new(MockQuoteDriver)
+	quoteService := NewQuoteService(mockDriver, DefaultValidationRules())
+	quote := models.Quote{Id: generateUuid(), Author: "Samuel Clemens", Text: "text", Version: 3}
+
+	mockDriver.On("InTransaction", mock.Anything).Return(nil)
+	mockDriver.On("GetQuotesByAuthors", mock.Anything, []string{"Samuel Clemens"}).Return([]models.Quote{quote}, nil)
+	mockDriver.On("UpdateQuote", mock.Anything, mock.Anything, int64(3)).Return(&drivers.VersionConflictError{Id: quote.Id, Version: 3})
+
+	result, err := quoteService.RenameAuthors(ctx, dtos.AuthorRenameDto{From: []string{"Samuel Clemens"}, To: "Mark Twain"})
+
+	var conflictErr *drivers.VersionConflictError
+	assert.ErrorAs(t, err, &conflictErr)
+	assert.Nil(t, result)
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/attribute"
+	"quotes/internal/dtos"
+	"quotes/internal/tracing"
+)
+
+// TracingQuoteService wraps a QuoteServiceInterface and records a span for
+// every service call.
+type TracingQuoteService struct {
+	service QuoteServiceInterface
+}
+
+func NewTracingQuoteService(service QuoteServiceInterface) *TracingQuoteService {
+	return &TracingQuoteService{service: service}
+}
+
+func (s *TracingQuoteService) CreateQuote(ctx context.Context, quoteDto dtos.QuoteDto) (*dtos.QuoteDto, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "QuoteService.CreateQuote")
+	defer span.End()
+
+	quote, err := s.service.CreateQuote(ctx, quoteDto)
+	tracing.RecordError(span, err)
+
+	return quote, err
+}
+
+func (s *TracingQuoteService) UpdateQuote(ctx context.Context, id pgtype.UUID, quoteDto dtos.QuoteDto, version int64) (*dtos.QuoteDto, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "QuoteService.UpdateQuote")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("quote.id", id.String()), attribute.Int64("quote.version", version))
+
+	quote, err := s.service.UpdateQuote(ctx, id, quoteDto, version)
+	tracing.RecordError(span, err)
+
+	return quote, err
+}
+
+func (s *TracingQuoteService) DeleteQuote(ctx context.Context, id pgtype.UUID, version int64) error {
+	ctx, span := tracing.Tracer().Start(ctx, "QuoteService.DeleteQuote")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("quote.id", id.String()), attribute.Int64("quote.version", version))
+
+	err := s.service.DeleteQuote(ctx, id, version)
+	tracing.RecordError(span, err)
+
+	return err
+}
+
+func (s *TracingQuoteService) ApplyBatch(ctx context.Context, operations []dtos.BatchOperationDto, allOrNothing bool) ([]BatchResult, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "QuoteService.ApplyBatch")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("batch.operations", len(operations)), attribute.Bool("batch.atomic", allOrNothing))
+
+	results, err := s.service.ApplyBatch(ctx, operations, allOrNothing)
+	tracing.RecordError(span, err)
+
+	return results, err
+}
+
+func (s *TracingQuoteService) RenameAuthors(ctx context.Context, renameDto dtos.AuthorRenameDto) (*dtos.AuthorRenameResultDto, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "QuoteService.RenameAuthors")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("authors.from", len(renameDto.From)), attribute.Bool("authors.dry_run", renameDto.DryRun))
+
+	result, err := s.service.RenameAuthors(ctx, renameDto)
+	tracing.RecordError(span, err)
+
+	return result, err
+}
+
+func (s *TracingQuoteService) GetAllQuotes(ctx context.Context) ([]dtos.QuoteDto, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "QuoteService.GetAllQuotes")
+	defer span.End()
+
+	quotes, err := s.service.GetAllQuotes(ctx)
+	tracing.RecordError(span, err)
+	span.SetAttributes(attribute.Int("quotes.count", len(quotes)))
+
+	return quotes, err
+}
+
+func (s *TracingQuoteService) GetQuotesByAuthor(ctx context.Context, author string) ([]dtos.QuoteDto, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "QuoteService.GetQuotesByAuthor")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("quote.author", author))
+
+	quotes, err := s.service.GetQuotesByAuthor(ctx, author)
+	tracing.RecordError(span, err)
+	span.SetAttributes(attribute.Int("quotes.count", len(quotes)))
+
+	return quotes, err
+}
+
+func (s *TracingQuoteService) GetQuotesByAuthors(ctx context.Context, authors []string) (map[string][]dtos.QuoteDto, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "QuoteService.GetQuotesByAuthors")
+	defer span.End()
+
+	span.SetAttributes(attribute.StringSlice("quote.authors", authors))
+
+	quotes, err := s.service.GetQuotesByAuthors(ctx, authors)
+	tracing.RecordError(span, err)
+	span.SetAttributes(attribute.Int("quotes.groups", len(quotes)))
+
+	return quotes, err
+}
+
+func (s *TracingQuoteService) GetQuotesByTags(ctx context.Context, tags []string) (map[string][]dtos.QuoteDto, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "QuoteService.GetQuotesByTags")
+	defer span.End()
+
+	span.SetAttributes(attribute.StringSlice("quote.tags", tags))
+
+	quotes, err := s.service.GetQuotesByTags(ctx, tags)
+	tracing.RecordError(span, err)
+	span.SetAttributes(attribute.Int("quotes.groups", len(quotes)))
+
+	return quotes, err
+}
+
+func (s *TracingQuoteService) GetQuotesByTag(ctx context.Context, tag, author string) ([]dtos.QuoteDto, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "QuoteService.GetQuotesByTag")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("quote.tag", tag), attribute.String("quote.author", author))
+
+	quotes, err := s.service.GetQuotesByTag(ctx, tag, author)
+	tracing.RecordError(span, err)
+	span.SetAttributes(attribute.Int("quotes.count", len(quotes)))
+
+	return quotes, err
+}
+
+func (s *TracingQuoteService) GetQuotesPage(ctx context.Context, author string, after pgtype.UUID, limit int) ([]dtos.QuoteDto, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "QuoteService.GetQuotesPage")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("quote.author", author), attribute.Int("quotes.limit", limit))
+
+	quotes, err := s.service.GetQuotesPage(ctx, author, after, limit)
+	tracing.RecordError(span, err)
+	span.SetAttributes(attribute.Int("quotes.count", len(quotes)))
+
+	return quotes, err
+}
+
+func (s *TracingQuoteService) GetRandomQuote(ctx context.Context) (*dtos.QuoteDto, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "QuoteService.GetRandomQuote")
+	defer span.End()
+
+	quote, err := s.service.GetRandomQuote(ctx)
+	tracing.RecordError(span, err)
+
+	return quote, err
+}
+
+func (s *TracingQuoteService) GetQuoteById(ctx context.Context, id pgtype.UUID) (*dtos.QuoteDto, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "QuoteService.GetQuoteById")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("quote.id", id.String()))
+
+	quote, err := s.service.GetQuoteById(ctx, id)
+	tracing.RecordError(span, err)
+
+	return quote, err
+}
+
+func (s *TracingQuoteService) GetLastModified(ctx context.Context) (time.Time, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "QuoteService.GetLastModified")
+	defer span.End()
+
+	modifiedAt, err := s.service.GetLastModified(ctx)
+	tracing.RecordError(span, err)
+
+	return modifiedAt, err
+}
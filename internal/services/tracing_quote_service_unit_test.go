@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingQuoteServiceRecordsSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx := context.Background()
+	mockDriver := new(MockQuoteDriver)
+	quoteService := NewTracingQuoteService(NewQuoteService(mockDriver, DefaultValidationRules()))
+
+	mockDriver.On("GetRandomQuote", mock.Anything).Return(nil, errors.New("connection refused"))
+
+	_, err := quoteService.GetRandomQuote(ctx)
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "QuoteService.GetRandomQuote", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	mockDriver.AssertExpectations(t)
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+	"quotes/internal/dtos"
+)
+
+// ValidationRules configure how the author, text and tags of a quote are
+// normalized and which values are rejected. A maximum of 0 means no limit.
+// Leading and trailing whitespace is always trimmed, tags are also
+// lower-cased and deduplicated.
+type ValidationRules struct {
+	MaxAuthorLength int
+	MaxTextLength   int
+	MaxTags         int
+	MaxTagLength    int
+	// MaxBatchOperations limits the operations of a batch.
+	MaxBatchOperations int
+	// NormalizeUnicode converts values to Unicode normalization form C, so
+	// that equal strings compare equal regardless of how they were composed.
+	NormalizeUnicode bool
+	// CollapseWhitespace replaces every run of whitespace with one space.
+	CollapseWhitespace bool
+	// RejectControlCharacters rejects control characters other than tabs
+	// and line breaks.
+	RejectControlCharacters bool
+	// RejectHtml rejects values containing HTML tags or comments.
+	RejectHtml bool
+}
+
+// DefaultValidationRules returns the rules used unless configured otherwise.
+func DefaultValidationRules() ValidationRules {
+	return ValidationRules{
+		MaxAuthorLength:         200,
+		MaxTextLength:           2000,
+		MaxTags:                 10,
+		MaxTagLength:            50,
+		MaxBatchOperations:      100,
+		NormalizeUnicode:        true,
+		CollapseWhitespace:      true,
+		RejectControlCharacters: true,
+		RejectHtml:              true,
+	}
+}
+
+// ValidationError lists every field of a request that was rejected.
+type ValidationError struct {
+	Fields []dtos.FieldErrorDto
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Message
+	}
+
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+var htmlPattern = regexp.MustCompile(`<(/?[A-Za-z][^<>]*|!--)`)
+
+// normalize applies the normalization rules to a single value.
+func (rules ValidationRules) normalize(value string) string {
+	if rules.NormalizeUnicode {
+		value = norm.NFC.String(value)
+	}
+
+	if rules.CollapseWhitespace {
+		return strings.Join(strings.Fields(value), " ")
+	}
+
+	return strings.TrimSpace(value)
+}
+
+// validateQuote normalizes the author, text and tags of quoteDto in place and
+// returns a *ValidationError describing every rule they break.
+func (rules ValidationRules) validateQuote(quoteDto *dtos.QuoteDto) error {
+	var fieldErrors []dtos.FieldErrorDto
+
+	for _, field := range []struct {
+		name      string
+		label     string
+		value     **string
+		maxLength int
+	}{
+		{"author", "Author", &quoteDto.Author, rules.MaxAuthorLength},
+		{"text", "Text", &quoteDto.Text, rules.MaxTextLength},
+	} {
+		if *field.value == nil {
+			fieldErrors = append(fieldErrors, fieldError(field.name, "required", "%s is required", field.label))
+			continue
+		}
+
+		value := rules.normalize(**field.value)
+		*field.value = &value
+
+		if fieldErr, ok := rules.checkValue(field.name, field.label, value, field.maxLength); !ok {
+			fieldErrors = append(fieldErrors, fieldErr)
+		}
+	}
+
+	fieldErrors = append(fieldErrors, rules.validateTags(quoteDto)...)
+
+	if len(fieldErrors) > 0 {
+		return &ValidationError{Fields: fieldErrors}
+	}
+
+	return nil
+}
+
+// checkValue applies the rules to a normalized author or text, returning the
+// first one it breaks.
+func (rules ValidationRules) checkValue(name, label, value string, maxLength int) (dtos.FieldErrorDto, bool) {
+	switch {
+	case value == "":
+		return fieldError(name, "required", "%s is required", label), false
+	case rules.RejectControlCharacters && strings.ContainsFunc(value, isDisallowedControl):
+		return fieldError(name, "control_characters", "%s must not contain control characters", label), false
+	case rules.RejectHtml && htmlPattern.MatchString(value):
+		return fieldError(name, "html", "%s must not contain HTML", label), false
+	case maxLength > 0 && utf8.RuneCountInString(value) > maxLength:
+		return fieldError(name, "too_long", "%s must be at most %d characters", label, maxLength), false
+	}
+
+	return dtos.FieldErrorDto{}, true
+}
+
+// validateAuthorRename normalizes the authors to rename and their new name
+// in place, like the authors of quotes.
+func (rules ValidationRules) validateAuthorRename(renameDto *dtos.AuthorRenameDto) error {
+	var fieldErrors []dtos.FieldErrorDto
+
+	if len(renameDto.From) == 0 {
+		fieldErrors = append(fieldErrors, fieldError("from", "required", "Authors to rename are required"))
+	}
+	from := make([]string, 0, len(renameDto.From))
+	for _, author := range renameDto.From {
+		author = rules.normalize(author)
+		if author == "" {
+			fieldErrors = append(fieldErrors, fieldError("from", "required", "Authors to rename must not be empty"))
+		} else if !slices.Contains(from, author) {
+			from = append(from, author)
+		}
+	}
+	renameDto.From = from
+
+	renameDto.To = rules.normalize(renameDto.To)
+	if fieldErr, ok := rules.checkValue("to", "Author", renameDto.To, rules.MaxAuthorLength); !ok {
+		fieldErrors = append(fieldErrors, fieldErr)
+	}
+
+	if len(fieldErrors) > 0 {
+		return &ValidationError{Fields: fieldErrors}
+	}
+
+	return nil
+}
+
+// validateTags normalizes and deduplicates the tags of quoteDto in place.
+// Tags are optional.
+func (rules ValidationRules) validateTags(quoteDto *dtos.QuoteDto) []dtos.FieldErrorDto {
+	var fieldErrors []dtos.FieldErrorDto
+
+	tags := make([]string, 0, len(quoteDto.Tags))
+	for _, tag := range quoteDto.Tags {
+		tag = rules.NormalizeTag(tag)
+
+		switch {
+		case tag == "":
+			fieldErrors = append(fieldErrors, fieldError("tags", "required", "Tags must not be empty"))
+		case rules.RejectControlCharacters && strings.ContainsFunc(tag, isDisallowedControl):
+			fieldErrors = append(fieldErrors, fieldError("tags", "control_characters", "Tags must not contain control characters"))
+		case rules.RejectHtml && htmlPattern.MatchString(tag):
+			fieldErrors = append(fieldErrors, fieldError("tags", "html", "Tags must not contain HTML"))
+		case rules.MaxTagLength > 0 && utf8.RuneCountInString(tag) > rules.MaxTagLength:
+			fieldErrors = append(fieldErrors, fieldError("tags", "too_long", "Tags must be at most %d characters", rules.MaxTagLength))
+		case !slices.Contains(tags, tag):
+			tags = append(tags, tag)
+		}
+	}
+
+	if rules.MaxTags > 0 && len(tags) > rules.MaxTags {
+		fieldErrors = append(fieldErrors, fieldError("tags", "too_many", "At most %d tags are allowed", rules.MaxTags))
+	}
+
+	quoteDto.Tags = nil
+	if len(tags) > 0 {
+		quoteDto.Tags = tags
+	}
+
+	return fieldErrors
+}
+
+// validateBatch checks that operations is not empty nor too long, and that
+// every operation has a known op and the fields it needs. With checkQuotes
+// it validates the quotes too, on copies, naming the fields of the operation;
+// otherwise they are validated as each operation is applied.
+func (rules ValidationRules) validateBatch(operations []dtos.BatchOperationDto, checkQuotes bool) error {
+	var fieldErrors []dtos.FieldErrorDto
+
+	switch {
+	case len(operations) == 0:
+		fieldErrors = append(fieldErrors, fieldError("operations", "required", "Operations are required"))
+	case rules.MaxBatchOperations > 0 && len(operations) > rules.MaxBatchOperations:
+		fieldErrors = append(fieldErrors, fieldError("operations", "too_many", "At most %d operations are allowed", rules.MaxBatchOperations))
+	}
+
+	for i, operation := range operations {
+		field := fmt.Sprintf("operations[%d]", i)
+
+		switch operation.Op {
+		case BatchCreate, BatchUpdate, BatchDelete:
+		default:
+			fieldErrors = append(fieldErrors, fieldError(field+".op", "invalid", "Op must be create, update or delete"))
+			continue
+		}
+
+		if operation.Op != BatchCreate && operation.Id == nil {
+			fieldErrors = append(fieldErrors, fieldError(field+".id", "required", "Id is required to %s a quote", operation.Op))
+		}
+		if operation.Op != BatchDelete && operation.Quote == nil {
+			fieldErrors = append(fieldErrors, fieldError(field+".quote", "required", "Quote is required to %s a quote", operation.Op))
+		}
+
+		if checkQuotes && operation.Op != BatchDelete && operation.Quote != nil {
+			quote := *operation.Quote
+			var validationErr *ValidationError
+			if errors.As(rules.validateQuote(&quote), &validationErr) {
+				for _, fieldErr := range validationErr.Fields {
+					fieldErr.Field = field + ".quote." + fieldErr.Field
+					fieldErrors = append(fieldErrors, fieldErr)
+				}
+			}
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return &ValidationError{Fields: fieldErrors}
+	}
+
+	return nil
+}
+
+// NormalizeTag converts a tag to the form it is stored in, so that lookups
+// match however the tag is written.
+func (rules ValidationRules) NormalizeTag(tag string) string {
+	return strings.ToLower(rules.normalize(tag))
+}
+
+func fieldError(field, code, format string, args ...any) dtos.FieldErrorDto {
+	return dtos.FieldErrorDto{Field: field, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func isDisallowedControl(r rune) bool {
+	return unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r'
+}
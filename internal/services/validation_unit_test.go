@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/dtos"
+	"quotes/internal/models"
+)
+
+func TestValidateQuote(t *testing.T) {
+	rules := DefaultValidationRules()
+	rules.MaxAuthorLength = 10
+	rules.MaxTextLength = 20
+
+	stringPtr := func(value string) *string { return &value }
+
+	for _, test := range []struct {
+		name           string
+		author         *string
+		text           *string
+		expectedAuthor string
+		expectedText   string
+		expectedErrors []dtos.FieldErrorDto
+	}{
+		{
+			name:           "valid",
+			author:         stringPtr("Author"),
+			text:           stringPtr("Text"),
+			expectedAuthor: "Author",
+			expectedText:   "Text",
+		},
+		{
+			name:           "normalized",
+			author:         stringPtr("  Rene\u0301  "),
+			text:           stringPtr("one \t two\n\nthree "),
+			expectedAuthor: "Ren\u00e9",
+			expectedText:   "one two three",
+		},
+		{
+			name:   "missing",
+			author: nil,
+			text:   stringPtr(" \n "),
+			expectedErrors: []dtos.FieldErrorDto{
+				{Field: "author", Code: "required", Message: "Author is required"},
+				{Field: "text", Code: "required", Message: "Text is required"},
+			},
+		},
+		{
+			name:   "too long",
+			author: stringPtr(strings.Repeat("é", 11)),
+			text:   stringPtr(strings.Repeat("a", 20)),
+			expectedErrors: []dtos.FieldErrorDto{
+				{Field: "author", Code: "too_long", Message: "Author must be at most 10 characters"},
+			},
+		},
+		{
+			name:   "control characters",
+			author: stringPtr("Auth\x00or"),
+			text:   stringPtr("Zero\u200bwidth"),
+			expectedErrors: []dtos.FieldErrorDto{
+				{Field: "author", Code: "control_characters", Message: "Author must not contain control characters"},
+			},
+		},
+		{
+			name:   "HTML",
+			author: stringPtr("1 < 2 > 0"),
+			text:   stringPtr("<b>bold</b>"),
+			expectedErrors: []dtos.FieldErrorDto{
+				{Field: "text", Code: "html", Message: "Text must not contain HTML"},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			quoteDto := dtos.QuoteDto{Author: test.author, Text: test.text}
+
+			err := rules.validateQuote(&quoteDto)
+
+			if test.expectedErrors != nil {
+				var validationErr *ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Equal(t, test.expectedErrors, validationErr.Fields)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedAuthor, *quoteDto.Author)
+			assert.Equal(t, test.expectedText, *quoteDto.Text)
+		})
+	}
+}
+
+func TestValidateQuoteWithRulesDisabled(t *testing.T) {
+	rules := ValidationRules{}
+	author := "  Rene\u0301 <i>x</i>"
+	text := strings.Repeat("line\x07\n", 1000)
+	quoteDto := dtos.QuoteDto{Author: &author, Text: &text}
+
+	require.NoError(t, rules.validateQuote(&quoteDto))
+	assert.Equal(t, "Rene\u0301 <i>x</i>", *quoteDto.Author)
+	assert.Equal(t, strings.TrimSpace(text), *quoteDto.Text)
+}
+
+func TestCreateQuoteStoresNormalizedValues(t *testing.T) {
+	mockDriver := new(MockQuoteDriver)
+	quoteService := NewQuoteService(mockDriver, DefaultValidationRules())
+
+	mockDriver.On("CreateQuote", mock.Anything, mock.MatchedBy(func(quote *models.Quote) bool {
+		return quote.Author == "Author" && quote.Text == "Some text"
+	})).Return(nil)
+
+	author := " Author "
+	text := "Some   text"
+	_, err := quoteService.CreateQuote(context.Background(), dtos.QuoteDto{Author: &author, Text: &text})
+
+	require.NoError(t, err)
+	mockDriver.AssertExpectations(t)
+}
+
+func TestCreateQuoteRejectsInvalidQuote(t *testing.T) {
+	mockDriver := new(MockQuoteDriver)
+	quoteService := NewQuoteService(mockDriver, DefaultValidationRules())
+
+	text := "text"
+	_, err := quoteService.CreateQuote(context.Background(), dtos.QuoteDto{Text: &text})
+
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	mockDriver.AssertNotCalled(t, "CreateQuote", mock.Anything, mock.Anything)
+}
+
+func TestValidateQuoteTags(t *testing.T) {
+	rules := DefaultValidationRules()
+	rules.MaxTags = 2
+	rules.MaxTagLength = 5
+	author, text := "Author", "Text"
+
+	quote := dtos.QuoteDto{Author: &author, Text: &text, Tags: []string{" Life ", "life", "WISDOM"}}
+	err := rules.validateQuote(&quote)
+	require.Error(t, err)
+	assert.Equal(t, []dtos.FieldErrorDto{
+		{Field: "tags", Code: "too_long", Message: "Tags must be at most 5 characters"},
+	}, err.(*ValidationError).Fields)
+
+	quote = dtos.QuoteDto{Author: &author, Text: &text, Tags: []string{" Life ", "life", "Love"}}
+	require.NoError(t, rules.validateQuote(&quote))
+	assert.Equal(t, []string{"life", "love"}, quote.Tags)
+
+	quote = dtos.QuoteDto{Author: &author, Text: &text, Tags: []string{"a", "b", "c", " "}}
+	err = rules.validateQuote(&quote)
+	require.Error(t, err)
+	assert.Equal(t, []dtos.FieldErrorDto{
+		{Field: "tags", Code: "required", Message: "Tags must not be empty"},
+		{Field: "tags", Code: "too_many", Message: "At most 2 tags are allowed"},
+	}, err.(*ValidationError).Fields)
+}
+
+func TestValidateAuthorRename(t *testing.T) {
+	rules := DefaultValidationRules()
+
+	err := rules.validateAuthorRename(&dtos.AuthorRenameDto{From: []string{"author", "  "}, To: "<b>author</b>"})
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []dtos.FieldErrorDto{
+		{Field: "from", Code: "required", Message: "Authors to rename must not be empty"},
+		{Field: "to", Code: "html", Message: "Author must not contain HTML"},
+	}, validationErr.Fields)
+
+	err = rules.validateAuthorRename(&dtos.AuthorRenameDto{To: "author"})
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "from", validationErr.Fields[0].Field)
+}
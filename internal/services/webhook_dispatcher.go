@@ -0,0 +1,241 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"quotes/internal/drivers"
+	"quotes/internal/models"
+)
+
+const (
+	// webhookBatchSize bounds the deliveries attempted at once.
+	webhookBatchSize = 100
+	// maxWebhookDrainLength bounds the response read so that the
+	// connection can be reused. The response is discarded: subscribers
+	// choose the URL, so what it answers must not be readable through the
+	// delivery log.
+	maxWebhookDrainLength = 4096
+)
+
+// WebhookOptions configures the deliveries of a WebhookDispatcher.
+type WebhookOptions struct {
+	// MaxAttempts is how many attempts a delivery gets before it is
+	// dead-lettered.
+	MaxAttempts int
+	// RetryBaseDelay follows the first failed attempt and doubles after
+	// each further one, up to RetryMaxDelay.
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	Timeout        time.Duration
+	PollInterval   time.Duration
+	// AllowPrivateTargets permits deliveries to loopback, private and
+	// link-local addresses, which are otherwise refused so that webhooks
+	// cannot reach the internal network.
+	AllowPrivateTargets bool
+}
+
+// WebhookDispatcher posts the deliveries queued by the WebhookSink to the
+// subscribed webhooks, retrying failures with exponential backoff.
+// Deliveries are claimed in the database, so any number of instances may
+// dispatch at once.
+type WebhookDispatcher struct {
+	driver  drivers.WebhookDriverInterface
+	options WebhookOptions
+	client  *http.Client
+	logger  *slog.Logger
+	now     func() time.Time
+}
+
+func NewWebhookDispatcher(driver drivers.WebhookDriverInterface, options WebhookOptions, logger *slog.Logger) *WebhookDispatcher {
+	dialer := &net.Dialer{Timeout: options.Timeout}
+	if !options.AllowPrivateTargets {
+		dialer.Control = refusePrivateAddress
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialled instead of the target, bypassing the check.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &WebhookDispatcher{
+		driver:  driver,
+		options: options,
+		client: &http.Client{
+			Timeout:   options.Timeout,
+			Transport: transport,
+			// A redirect is a failed delivery rather than a request to
+			// another URL.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// refusePrivateAddress fails connections to addresses that are not public.
+// It runs on the resolved address of every connection, so a host name
+// that resolves to a public address when the webhook is registered and to
+// a private one later is refused as well.
+func refusePrivateAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("webhook target %s is not a public address", ip)
+	}
+
+	return nil
+}
+
+// Run dispatches every poll interval until ctx is cancelled.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch attempts the due deliveries, a batch at a time until none are
+// left.
+func (d *WebhookDispatcher) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		// The lease outlasts the attempts of the batch, which run at once.
+		deliveries, err := d.driver.ClaimDeliveries(ctx, webhookBatchSize, 2*d.options.Timeout)
+		if err != nil {
+			d.logger.Error("Failed to claim webhook deliveries", "error", err)
+			return
+		}
+
+		var wg sync.WaitGroup
+		for i := range deliveries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.deliver(ctx, &deliveries[i])
+			}()
+		}
+		wg.Wait()
+
+		if len(deliveries) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+// deliver attempts delivery and records the outcome.
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	statusCode, err := d.post(ctx, delivery)
+	if ctx.Err() != nil {
+		// Interrupted by shutdown; the lease expires and the delivery is
+		// attempted again.
+		return
+	}
+
+	now := d.now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = ""
+
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliverySucceeded
+	case delivery.Attempts >= d.options.MaxAttempts:
+		delivery.Status = models.DeliveryDead
+		delivery.LastError = err.Error()
+		d.logger.Warn("Webhook delivery dead-lettered", "delivery_id", delivery.Id, "url", delivery.Url, "attempts", delivery.Attempts, "error", err)
+	default:
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+	}
+
+	if err := d.driver.RecordAttempt(ctx, delivery); err != nil {
+		d.logger.Error("Failed to record webhook delivery attempt", "delivery_id", delivery.Id, "error", err)
+	}
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func (d *WebhookDispatcher) backoff(attempts int) time.Duration {
+	delay := d.options.RetryBaseDelay
+	for i := 1; i < attempts && delay < d.options.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, d.options.RetryMaxDelay)
+}
+
+// post sends the event of delivery to its URL. A response other than 2xx
+// is an error; the status code is nil if there was no response.
+func (d *WebhookDispatcher) post(ctx context.Context, delivery *models.WebhookDelivery) (*int, error) {
+	body, err := json.Marshal(newOutboxEnvelope(delivery.Event))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "quotes-webhooks")
+	req.Header.Set("X-Webhook-Id", strconv.FormatInt(delivery.Event.Id, 10))
+	req.Header.Set("X-Webhook-Event", delivery.Event.Type)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhook(delivery.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookDrainLength))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err = fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return &resp.StatusCode, err
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of timestamp, a dot and body
+// keyed with secret, which subscribers compare with the
+// X-Webhook-Signature header.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
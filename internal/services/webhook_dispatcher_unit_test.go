@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/models"
+)
+
+var testWebhookOptions = WebhookOptions{
+	MaxAttempts:    3,
+	RetryBaseDelay: 10 * time.Second,
+	RetryMaxDelay:  25 * time.Second,
+	Timeout:        time.Second,
+	PollInterval:   time.Second,
+	// The test servers listen on loopback.
+	AllowPrivateTargets: true,
+}
+
+func newTestDispatcher(driver *MockWebhookDriver) *WebhookDispatcher {
+	dispatcher := NewWebhookDispatcher(driver, testWebhookOptions, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	dispatcher.now = func() time.Time { return now }
+	return dispatcher
+}
+
+func testDelivery(url string, attempts int) models.WebhookDelivery {
+	return models.WebhookDelivery{
+		Id:       1,
+		Status:   models.DeliveryPending,
+		Attempts: attempts,
+		Url:      url,
+		Secret:   "subscription secret",
+		Event: models.OutboxEvent{
+			Id:      42,
+			Type:    "quote.created",
+			Payload: []byte(`{"text":"Text"}`),
+		},
+	}
+}
+
+func TestWebhookDispatcherSignsDeliveries(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	mockDriver := new(MockWebhookDriver)
+	dispatcher := newTestDispatcher(mockDriver)
+	mockDriver.On("ClaimDeliveries", mock.Anything, webhookBatchSize, 2*time.Second).
+		Return([]models.WebhookDelivery{testDelivery(server.URL, 0)}, nil)
+	mockDriver.On("RecordAttempt", mock.Anything, mock.MatchedBy(func(d *models.WebhookDelivery) bool {
+		return d.Status == models.DeliverySucceeded && d.Attempts == 1 && *d.LastStatusCode == http.StatusOK
+	})).Return(nil)
+
+	dispatcher.dispatch(context.Background())
+
+	require.NotNil(t, received)
+	assert.Equal(t, "quote.created", received.Header.Get("X-Webhook-Event"))
+	assert.Equal(t, "42", received.Header.Get("X-Webhook-Id"))
+	timestamp := received.Header.Get("X-Webhook-Timestamp")
+	assert.Equal(t, "sha256="+SignWebhook("subscription secret", timestamp, body), received.Header.Get("X-Webhook-Signature"))
+	assert.JSONEq(t, `{"id":42,"type":"quote.created","quote_id":null,"created_at":"0001-01-01T00:00:00Z","data":{"text":"Text"}}`, string(body))
+	mockDriver.AssertExpectations(t)
+}
+
+func TestWebhookDispatcherRetriesWithBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	mockDriver := new(MockWebhookDriver)
+	dispatcher := newTestDispatcher(mockDriver)
+	mockDriver.On("RecordAttempt", mock.Anything, mock.Anything).Return(nil)
+	now := dispatcher.now()
+
+	for _, test := range []struct {
+		attempts    int
+		status      string
+		nextAttempt time.Time
+	}{
+		{0, models.DeliveryPending, now.Add(10 * time.Second)},
+		{1, models.DeliveryPending, now.Add(20 * time.Second)},
+		{2, models.DeliveryDead, time.Time{}},
+	} {
+		delivery := testDelivery(server.URL, test.attempts)
+		dispatcher.deliver(context.Background(), &delivery)
+
+		assert.Equal(t, test.attempts+1, delivery.Attempts)
+		assert.Equal(t, test.status, delivery.Status)
+		assert.Equal(t, test.nextAttempt, delivery.NextAttemptAt)
+		assert.Equal(t, http.StatusServiceUnavailable, *delivery.LastStatusCode)
+		// The response body is never kept, since subscribers choose the URL.
+		assert.Equal(t, "unexpected status 503", delivery.LastError)
+	}
+	mockDriver.AssertNumberOfCalls(t, "RecordAttempt", 3)
+}
+
+func TestWebhookDispatcherRefusesPrivateTargets(t *testing.T) {
+	var requested bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+
+	mockDriver := new(MockWebhookDriver)
+	options := testWebhookOptions
+	options.AllowPrivateTargets = false
+	dispatcher := NewWebhookDispatcher(mockDriver, options, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	mockDriver.On("RecordAttempt", mock.Anything, mock.Anything).Return(nil)
+
+	delivery := testDelivery(server.URL, 0)
+	dispatcher.deliver(context.Background(), &delivery)
+
+	assert.False(t, requested)
+	assert.Equal(t, models.DeliveryPending, delivery.Status)
+	assert.Nil(t, delivery.LastStatusCode)
+	assert.Contains(t, delivery.LastError, "is not a public address")
+}
+
+func TestRefusePrivateAddress(t *testing.T) {
+	for address, refused := range map[string]bool{
+		"127.0.0.1:80":          true,
+		"[::1]:443":             true,
+		"10.1.2.3:80":           true,
+		"172.16.0.1:80":         true,
+		"192.168.1.1:80":        true,
+		"169.254.169.254:80":    true,
+		"[fe80::1]:80":          true,
+		"[fd00:ec2::254]:80":    true,
+		"[::ffff:127.0.0.1]:80": true,
+		"0.0.0.0:80":            true,
+		"224.0.0.1:80":          true,
+		"93.184.215.14:443":     false,
+		"[2606:4700::1111]:443": false,
+	} {
+		err := refusePrivateAddress("tcp", address, nil)
+		assert.Equal(t, refused, err != nil, address)
+	}
+}
+
+func TestWebhookBackoffIsCapped(t *testing.T) {
+	dispatcher := newTestDispatcher(new(MockWebhookDriver))
+
+	assert.Equal(t, 10*time.Second, dispatcher.backoff(1))
+	assert.Equal(t, 20*time.Second, dispatcher.backoff(2))
+	assert.Equal(t, 25*time.Second, dispatcher.backoff(3))
+	assert.Equal(t, 25*time.Second, dispatcher.backoff(100))
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"quotes/internal/drivers"
+	"quotes/internal/dtos"
+	"quotes/internal/models"
+)
+
+const (
+	maxWebhookUrlLength = 2048
+	minSecretLength     = 16
+	maxSecretLength     = 256
+)
+
+// WebhookServiceInterface manages webhook subscriptions. Missing
+// subscriptions are reported with pgx.ErrNoRows.
+type WebhookServiceInterface interface {
+	// CreateWebhook generates a secret unless one is given and returns it
+	// with the subscription; it is never returned again.
+	CreateWebhook(ctx context.Context, webhookDto dtos.WebhookDto) (*dtos.WebhookDto, error)
+	// UpdateWebhook replaces the URL and events of a subscription, and its
+	// secret if one is given.
+	UpdateWebhook(ctx context.Context, id pgtype.UUID, webhookDto dtos.WebhookDto) (*dtos.WebhookDto, error)
+	DeleteWebhook(ctx context.Context, id pgtype.UUID) error
+	GetWebhook(ctx context.Context, id pgtype.UUID) (*dtos.WebhookDto, error)
+	GetWebhooks(ctx context.Context) ([]dtos.WebhookDto, error)
+	// GetDeliveries returns the delivery log of a subscription, newest
+	// first, with only the deliveries of the given status unless it is
+	// empty.
+	GetDeliveries(ctx context.Context, id pgtype.UUID, status string, limit int) ([]dtos.WebhookDeliveryDto, error)
+	// RetryDelivery schedules a dead-lettered delivery again.
+	RetryDelivery(ctx context.Context, id pgtype.UUID, deliveryId int64) error
+}
+
+type WebhookService struct {
+	driver drivers.WebhookDriverInterface
+}
+
+func NewWebhookService(driver drivers.WebhookDriverInterface) *WebhookService {
+	return &WebhookService{driver: driver}
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, webhookDto dtos.WebhookDto) (*dtos.WebhookDto, error) {
+	if err := validateWebhook(&webhookDto); err != nil {
+		return nil, err
+	}
+
+	secret := webhookDto.Secret
+	if secret == "" {
+		secret = generateSecret()
+	}
+
+	subscription := &models.WebhookSubscription{
+		Id:         generateUuid(),
+		Url:        webhookDto.Url,
+		Secret:     secret,
+		EventTypes: webhookDto.Events,
+		Active:     webhookDto.Active == nil || *webhookDto.Active,
+	}
+	if err := s.driver.CreateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	webhookDto = newWebhookDto(*subscription)
+	webhookDto.Secret = secret
+
+	return &webhookDto, nil
+}
+
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id pgtype.UUID, webhookDto dtos.WebhookDto) (*dtos.WebhookDto, error) {
+	if err := validateWebhook(&webhookDto); err != nil {
+		return nil, err
+	}
+
+	subscription, err := s.driver.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.Url = webhookDto.Url
+	subscription.EventTypes = webhookDto.Events
+	subscription.Secret = webhookDto.Secret
+	if webhookDto.Active != nil {
+		subscription.Active = *webhookDto.Active
+	}
+	if err := s.driver.UpdateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	secret := webhookDto.Secret
+	webhookDto = newWebhookDto(*subscription)
+	webhookDto.Secret = secret
+
+	return &webhookDto, nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id pgtype.UUID) error {
+	return s.driver.DeleteSubscription(ctx, id)
+}
+
+func (s *WebhookService) GetWebhook(ctx context.Context, id pgtype.UUID) (*dtos.WebhookDto, error) {
+	subscription, err := s.driver.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	webhookDto := newWebhookDto(*subscription)
+
+	return &webhookDto, nil
+}
+
+func (s *WebhookService) GetWebhooks(ctx context.Context) ([]dtos.WebhookDto, error) {
+	subscriptions, err := s.driver.GetSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	webhookDtos := make([]dtos.WebhookDto, len(subscriptions))
+	for i, subscription := range subscriptions {
+		webhookDtos[i] = newWebhookDto(subscription)
+	}
+
+	return webhookDtos, nil
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, id pgtype.UUID, status string, limit int) ([]dtos.WebhookDeliveryDto, error) {
+	if _, err := s.driver.GetSubscription(ctx, id); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.driver.GetDeliveries(ctx, id, status, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveryDtos := make([]dtos.WebhookDeliveryDto, len(deliveries))
+	for i, delivery := range deliveries {
+		deliveryDtos[i] = dtos.WebhookDeliveryDto{
+			Id:             delivery.Id,
+			EventId:        delivery.Event.Id,
+			EventType:      delivery.Event.Type,
+			QuoteId:        delivery.Event.QuoteId,
+			Status:         delivery.Status,
+			Attempts:       delivery.Attempts,
+			LastAttemptAt:  delivery.LastAttemptAt,
+			LastStatusCode: delivery.LastStatusCode,
+			LastError:      delivery.LastError,
+			CreatedAt:      delivery.CreatedAt,
+		}
+		if delivery.Status == models.DeliveryPending {
+			deliveryDtos[i].NextAttemptAt = &delivery.NextAttemptAt
+		}
+	}
+
+	return deliveryDtos, nil
+}
+
+func (s *WebhookService) RetryDelivery(ctx context.Context, id pgtype.UUID, deliveryId int64) error {
+	return s.driver.RetryDelivery(ctx, id, deliveryId)
+}
+
+// validateWebhook trims and deduplicates the fields of webhookDto in place
+// and returns a *ValidationError describing every rule they break.
+func validateWebhook(webhookDto *dtos.WebhookDto) error {
+	var fieldErrors []dtos.FieldErrorDto
+
+	webhookDto.Url = strings.TrimSpace(webhookDto.Url)
+	parsed, err := url.Parse(webhookDto.Url)
+	switch {
+	case webhookDto.Url == "":
+		fieldErrors = append(fieldErrors, fieldError("url", "required", "URL is required"))
+	case err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "":
+		fieldErrors = append(fieldErrors, fieldError("url", "invalid_url", "URL must be an absolute http or https URL"))
+	case len(webhookDto.Url) > maxWebhookUrlLength:
+		fieldErrors = append(fieldErrors, fieldError("url", "too_long", "URL must be at most %d characters", maxWebhookUrlLength))
+	}
+
+	events := make([]string, 0, len(webhookDto.Events))
+	for _, event := range webhookDto.Events {
+		event = strings.TrimSpace(event)
+		switch {
+		case !slices.Contains(drivers.OutboxEventTypes, event):
+			fieldErrors = append(fieldErrors, fieldError("events", "unknown_event",
+				"Events must be one of %s", strings.Join(drivers.OutboxEventTypes, ", ")))
+		case !slices.Contains(events, event):
+			events = append(events, event)
+		}
+	}
+	if len(webhookDto.Events) == 0 {
+		fieldErrors = append(fieldErrors, fieldError("events", "required", "At least one event is required"))
+	}
+	webhookDto.Events = events
+
+	if secret := webhookDto.Secret; secret != "" && (len(secret) < minSecretLength || len(secret) > maxSecretLength) {
+		fieldErrors = append(fieldErrors, fieldError("secret", "invalid_length",
+			"Secret must be from %d to %d characters", minSecretLength, maxSecretLength))
+	}
+
+	if len(fieldErrors) > 0 {
+		return &ValidationError{Fields: fieldErrors}
+	}
+
+	return nil
+}
+
+func newWebhookDto(subscription models.WebhookSubscription) dtos.WebhookDto {
+	return dtos.WebhookDto{
+		Id:        &subscription.Id,
+		Url:       subscription.Url,
+		Events:    subscription.EventTypes,
+		Active:    &subscription.Active,
+		CreatedAt: subscription.CreatedAt,
+		UpdatedAt: subscription.UpdatedAt,
+	}
+}
+
+// generateSecret returns 32 random bytes in hex.
+func generateSecret() string {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+
+	return hex.EncodeToString(secret)
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"quotes/internal/dtos"
+	"quotes/internal/models"
+)
+
+type MockWebhookDriver struct {
+	mock.Mock
+}
+
+func (m *MockWebhookDriver) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+func (m *MockWebhookDriver) UpdateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+func (m *MockWebhookDriver) DeleteSubscription(ctx context.Context, id pgtype.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookDriver) GetSubscription(ctx context.Context, id pgtype.UUID) (*models.WebhookSubscription, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookDriver) GetSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookDriver) CreateDeliveries(ctx context.Context, eventIds []int64) error {
+	args := m.Called(ctx, eventIds)
+	return args.Error(0)
+}
+
+func (m *MockWebhookDriver) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	args := m.Called(ctx, limit, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDriver) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookDriver) GetDeliveries(ctx context.Context, subscriptionId pgtype.UUID, status string, limit int) ([]models.WebhookDelivery, error) {
+	args := m.Called(ctx, subscriptionId, status, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDriver) RetryDelivery(ctx context.Context, subscriptionId pgtype.UUID, deliveryId int64) error {
+	args := m.Called(ctx, subscriptionId, deliveryId)
+	return args.Error(0)
+}
+
+func TestCreateWebhookGeneratesSecret(t *testing.T) {
+	mockDriver := new(MockWebhookDriver)
+	service := NewWebhookService(mockDriver)
+
+	var stored *models.WebhookSubscription
+	mockDriver.On("CreateSubscription", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*models.WebhookSubscription)
+	}).Return(nil)
+
+	created, err := service.CreateWebhook(context.Background(), dtos.WebhookDto{
+		Url:    " https://example.com/hook ",
+		Events: []string{"quote.created", "quote.deleted", "quote.created"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://example.com/hook", created.Url)
+	assert.Equal(t, []string{"quote.created", "quote.deleted"}, created.Events)
+	assert.True(t, *created.Active)
+	assert.Len(t, created.Secret, 64)
+	assert.Equal(t, created.Secret, stored.Secret)
+	assert.True(t, stored.Id.Valid)
+}
+
+func TestCreateWebhookValidation(t *testing.T) {
+	service := NewWebhookService(new(MockWebhookDriver))
+
+	_, err := service.CreateWebhook(context.Background(), dtos.WebhookDto{
+		Url:    "ftp://example.com",
+		Events: []string{"quote.approved"},
+		Secret: "short",
+	})
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	codes := make(map[string]string)
+	for _, field := range validationErr.Fields {
+		codes[field.Field] = field.Code
+	}
+	assert.Equal(t, map[string]string{"url": "invalid_url", "events": "unknown_event", "secret": "invalid_length"}, codes)
+}
+
+func TestUpdateWebhookKeepsActiveAndSecret(t *testing.T) {
+	mockDriver := new(MockWebhookDriver)
+	service := NewWebhookService(mockDriver)
+	id := generateUuid()
+
+	mockDriver.On("GetSubscription", mock.Anything, id).Return(&models.WebhookSubscription{
+		Id: id, Url: "https://old.example.com", Secret: "stored secret value", EventTypes: []string{"quote.created"},
+	}, nil)
+	mockDriver.On("UpdateSubscription", mock.Anything, mock.MatchedBy(func(s *models.WebhookSubscription) bool {
+		return s.Url == "https://new.example.com" && !s.Active && s.Secret == ""
+	})).Return(nil)
+
+	updated, err := service.UpdateWebhook(context.Background(), id, dtos.WebhookDto{
+		Url: "https://new.example.com", Events: []string{"quote.updated"},
+	})
+	require.NoError(t, err)
+
+	assert.False(t, *updated.Active)
+	assert.Empty(t, updated.Secret)
+	mockDriver.AssertExpectations(t)
+}
+
+func TestGetDeliveriesOfMissingWebhook(t *testing.T) {
+	mockDriver := new(MockWebhookDriver)
+	service := NewWebhookService(mockDriver)
+	id := generateUuid()
+	mockDriver.On("GetSubscription", mock.Anything, id).Return(nil, pgx.ErrNoRows)
+
+	_, err := service.GetDeliveries(context.Background(), id, "", 10)
+
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	mockDriver.AssertNotCalled(t, "GetDeliveries", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
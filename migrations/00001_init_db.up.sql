@@ -3,4 +3,7 @@ CREATE TABLE IF NOT EXISTS quotes (
     id UUID PRIMARY KEY,
     author TEXT NOT NULL,
     text TEXT NOT NULL
-);
\ No newline at end of file
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS quotes;
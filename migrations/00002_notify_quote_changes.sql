@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_quote_change() RETURNS trigger AS $$
+DECLARE
+    quote_id UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        quote_id := OLD.id;
+    ELSE
+        quote_id := NEW.id;
+    END IF;
+
+    PERFORM pg_notify('quote_changes', json_build_object('operation', TG_OP, 'id', quote_id)::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER quotes_notify_change
+AFTER INSERT OR UPDATE OR DELETE ON quotes
+FOR EACH ROW EXECUTE FUNCTION notify_quote_change();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS quotes_notify_change ON quotes;
+DROP FUNCTION IF EXISTS notify_quote_change();
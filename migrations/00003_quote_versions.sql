@@ -0,0 +1,32 @@
+-- +goose Up
+ALTER TABLE quotes
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 1,
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- quotes_modified holds a single row with the time of the latest change to
+-- the quotes table, deletions included, which lists use as Last-Modified.
+CREATE TABLE quotes_modified (
+    singleton BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (singleton),
+    modified_at TIMESTAMPTZ NOT NULL
+);
+
+INSERT INTO quotes_modified (modified_at) VALUES (now());
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION touch_quotes_modified() RETURNS trigger AS $$
+BEGIN
+    UPDATE quotes_modified SET modified_at = now();
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER quotes_touch_modified
+AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON quotes
+FOR EACH STATEMENT EXECUTE FUNCTION touch_quotes_modified();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS quotes_touch_modified ON quotes;
+DROP FUNCTION IF EXISTS touch_quotes_modified();
+DROP TABLE IF EXISTS quotes_modified;
+ALTER TABLE quotes DROP COLUMN IF EXISTS updated_at, DROP COLUMN IF EXISTS version;
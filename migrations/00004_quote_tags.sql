@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE quotes ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX quotes_tags_idx ON quotes USING GIN (tags);
+
+-- +goose Down
+DROP INDEX IF EXISTS quotes_tags_idx;
+ALTER TABLE quotes DROP COLUMN IF EXISTS tags;
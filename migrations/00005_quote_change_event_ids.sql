@@ -0,0 +1,45 @@
+-- +goose Up
+-- Every change notification carries an ID from quote_change_events, so that
+-- all instances agree on the IDs of the events they stream to clients.
+CREATE SEQUENCE quote_change_events;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_quote_change() RETURNS trigger AS $$
+DECLARE
+    quote_id UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        quote_id := OLD.id;
+    ELSE
+        quote_id := NEW.id;
+    END IF;
+
+    PERFORM pg_notify('quote_changes', json_build_object(
+        'operation', TG_OP,
+        'id', quote_id,
+        'event_id', nextval('quote_change_events')
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_quote_change() RETURNS trigger AS $$
+DECLARE
+    quote_id UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        quote_id := OLD.id;
+    ELSE
+        quote_id := NEW.id;
+    END IF;
+
+    PERFORM pg_notify('quote_changes', json_build_object('operation', TG_OP, 'id', quote_id)::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP SEQUENCE IF EXISTS quote_change_events;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Every sink publishes events at its own pace, so that a failing sink does
+-- not hold back the others. A row leases an event to a relay publishing it
+-- to the sink, and records when it was published. The published_at of an
+-- event is set once every sink has published it.
+CREATE TABLE outbox_publications (
+    sink TEXT NOT NULL,
+    event_id BIGINT NOT NULL REFERENCES outbox_events (id) ON DELETE CASCADE,
+    leased_until TIMESTAMPTZ,
+    published_at TIMESTAMPTZ,
+    PRIMARY KEY (sink, event_id)
+);
+
+CREATE INDEX outbox_publications_event_idx ON outbox_publications (event_id);
+
+-- +goose Down
+DROP TABLE outbox_publications;
@@ -0,0 +1,49 @@
+-- +goose Up
+-- Updating the single quotes_modified row on every change serialized all
+-- writers on its lock, and could deadlock transactions changing several
+-- quotes. The latest change is now the newest of the updated_at of the
+-- quotes and the deleted_at of the quotes deleted since, each recorded on a
+-- row of its own. quotes_modified is only touched by TRUNCATE, which locks
+-- the whole table anyway, and otherwise keeps the time the quotes last
+-- changed before this migration.
+DROP TRIGGER IF EXISTS quotes_touch_modified ON quotes;
+
+CREATE TRIGGER quotes_touch_modified
+AFTER TRUNCATE ON quotes
+FOR EACH STATEMENT EXECUTE FUNCTION touch_quotes_modified();
+
+CREATE TABLE quote_deletions (
+    quote_id UUID PRIMARY KEY,
+    deleted_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX quote_deletions_deleted_at_idx ON quote_deletions (deleted_at);
+CREATE INDEX quotes_updated_at_idx ON quotes (updated_at);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION record_quote_deletion() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO quote_deletions (quote_id, deleted_at) VALUES (OLD.id, now())
+    ON CONFLICT (quote_id) DO UPDATE SET deleted_at = EXCLUDED.deleted_at;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER quotes_record_deletion
+AFTER DELETE ON quotes
+FOR EACH ROW EXECUTE FUNCTION record_quote_deletion();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS quotes_record_deletion ON quotes;
+DROP FUNCTION IF EXISTS record_quote_deletion();
+DROP INDEX IF EXISTS quotes_updated_at_idx;
+DROP TABLE IF EXISTS quote_deletions;
+
+UPDATE quotes_modified SET modified_at = now();
+
+DROP TRIGGER IF EXISTS quotes_touch_modified ON quotes;
+
+CREATE TRIGGER quotes_touch_modified
+AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON quotes
+FOR EACH STATEMENT EXECUTE FUNCTION touch_quotes_modified();
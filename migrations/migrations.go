@@ -0,0 +1,18 @@
+// Package migrations embeds the SQL migrations so that the binary can apply
+// them without the goose CLI or access to the source tree.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed *.sql
+var Postgres embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFiles embed.FS
+
+// SQLite holds the SQLite migrations, which mirror the PostgreSQL ones using
+// SQLite types.
+var SQLite, _ = fs.Sub(sqliteFiles, "sqlite")
@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS quotes (
+    id TEXT PRIMARY KEY,
+    author TEXT NOT NULL,
+    text TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS quotes;
@@ -0,0 +1,41 @@
+-- +goose Up
+-- Timestamps are stored as Unix microseconds.
+ALTER TABLE quotes ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE quotes ADD COLUMN updated_at INTEGER NOT NULL DEFAULT 0;
+UPDATE quotes SET updated_at = CAST(unixepoch('subsec') * 1000000 AS INTEGER);
+
+CREATE TABLE quotes_modified (
+    singleton INTEGER PRIMARY KEY CHECK (singleton = 1),
+    modified_at INTEGER NOT NULL
+);
+
+INSERT INTO quotes_modified (singleton, modified_at) VALUES (1, CAST(unixepoch('subsec') * 1000000 AS INTEGER));
+
+-- +goose StatementBegin
+CREATE TRIGGER quotes_touch_modified_insert AFTER INSERT ON quotes
+BEGIN
+    UPDATE quotes_modified SET modified_at = CAST(unixepoch('subsec') * 1000000 AS INTEGER);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER quotes_touch_modified_update AFTER UPDATE ON quotes
+BEGIN
+    UPDATE quotes_modified SET modified_at = CAST(unixepoch('subsec') * 1000000 AS INTEGER);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER quotes_touch_modified_delete AFTER DELETE ON quotes
+BEGIN
+    UPDATE quotes_modified SET modified_at = CAST(unixepoch('subsec') * 1000000 AS INTEGER);
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS quotes_touch_modified_delete;
+DROP TRIGGER IF EXISTS quotes_touch_modified_update;
+DROP TRIGGER IF EXISTS quotes_touch_modified_insert;
+DROP TABLE IF EXISTS quotes_modified;
+ALTER TABLE quotes DROP COLUMN updated_at;
+ALTER TABLE quotes DROP COLUMN version;
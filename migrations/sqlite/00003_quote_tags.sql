@@ -0,0 +1,6 @@
+-- +goose Up
+-- Tags are stored as a JSON array of strings.
+ALTER TABLE quotes ADD COLUMN tags TEXT NOT NULL DEFAULT '[]';
+
+-- +goose Down
+ALTER TABLE quotes DROP COLUMN tags;